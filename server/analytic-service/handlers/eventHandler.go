@@ -4,6 +4,7 @@ import (
 	"analytics-service/model"
 	"analytics-service/repository"
 	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
@@ -53,6 +54,34 @@ func (h *EventHandler) ProcessEventHandler(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+// ProcessEventBatchHandler will handle HTTP requests to process a list of events in order (POST).
+// Events are stored one by one, so a failure leaves the events before it in place.
+func (h *EventHandler) ProcessEventBatchHandler(w http.ResponseWriter, r *http.Request) {
+	var events []model.Event
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&events); err != nil {
+		http.Error(w, "Failed to decode event data", http.StatusBadRequest)
+		return
+	}
+
+	processed := 0
+	for _, event := range events {
+		message, err := h.processEvent(event)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to process event %d", processed), http.StatusInternalServerError)
+			return
+		}
+		if message == "" {
+			http.Error(w, fmt.Sprintf("Event type not handled: %s", event.Type), http.StatusBadRequest)
+			return
+		}
+		processed++
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(fmt.Sprintf("Successfully processed %d events", processed)))
+}
+
 // GetEventsHandler will handle HTTP requests to get events for a specific project (GET)
 func (h *EventHandler) GetEventsHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract the projectID variable from the URL
@@ -70,6 +99,17 @@ func (h *EventHandler) GetEventsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Optionally narrow the events down to a single label dimension (?label=bug)
+	if label := r.URL.Query().Get("label"); label != "" {
+		filtered := []model.Event{}
+		for _, event := range events {
+			if event.HasLabel(label) {
+				filtered = append(filtered, event)
+			}
+		}
+		events = filtered
+	}
+
 	// Respond with a JSON array of events
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -78,6 +118,52 @@ func (h *EventHandler) GetEventsHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// GetEffortHandler reports the time logged on a project per ISO week and per member (GET)
+func (h *EventHandler) GetEffortHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["projectID"]
+	if projectID == "" {
+		http.Error(w, "Missing projectID parameter", http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.repo.GetEventsByProjectID(projectID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
+		return
+	}
+
+	report := model.EffortReport{
+		ProjectID:     projectID,
+		WeekSeconds:   map[string]int64{},
+		MemberSeconds: map[string]int64{},
+	}
+	for _, event := range events {
+		if event.Type != model.WorkLoggedType {
+			continue
+		}
+		// the payload is decoded generically, so it is re-encoded into its concrete type
+		data, err := json.Marshal(event.Event)
+		if err != nil {
+			continue
+		}
+		var workLogged model.WorkLoggedEvent
+		if err := json.Unmarshal(data, &workLogged); err != nil {
+			log.Printf("Skipping malformed WorkLogged event: %v", err)
+			continue
+		}
+		year, week := workLogged.StartedAt.ISOWeek()
+		report.TotalSeconds += workLogged.DurationSeconds
+		report.WeekSeconds[fmt.Sprintf("%d-W%02d", year, week)] += workLogged.DurationSeconds
+		report.MemberSeconds[workLogged.UserID] += workLogged.DurationSeconds
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode effort report", http.StatusInternalServerError)
+	}
+}
+
 func (h *EventHandler) processEvent(event model.Event) (string, error) {
 	var message string
 	switch event.Type {
@@ -123,6 +209,42 @@ func (h *EventHandler) processEvent(event model.Event) (string, error) {
 			return "", err
 		}
 		message = "Successfully added document"
+	case model.DocumentVersionAddedType:
+		if err := h.repo.StoreEvent(event.ProjectID, event); err != nil {
+			log.Printf("Failed to store event: %v", err)
+			return "", err
+		}
+		message = "Successfully added document version"
+	case model.DocumentRemovedType:
+		if err := h.repo.StoreEvent(event.ProjectID, event); err != nil {
+			log.Printf("Failed to store event: %v", err)
+			return "", err
+		}
+		message = "Successfully removed document"
+	case model.TaskLabelAddedType:
+		if err := h.repo.StoreEvent(event.ProjectID, event); err != nil {
+			log.Printf("Failed to store event: %v", err)
+			return "", err
+		}
+		message = "Successfully added label to task"
+	case model.TaskLabelRemovedType:
+		if err := h.repo.StoreEvent(event.ProjectID, event); err != nil {
+			log.Printf("Failed to store event: %v", err)
+			return "", err
+		}
+		message = "Successfully removed label from task"
+	case model.WorkLoggedType:
+		if err := h.repo.StoreEvent(event.ProjectID, event); err != nil {
+			log.Printf("Failed to store event: %v", err)
+			return "", err
+		}
+		message = "Successfully logged work"
+	case model.TaskDeletedType:
+		if err := h.repo.StoreEvent(event.ProjectID, event); err != nil {
+			log.Printf("Failed to store event: %v", err)
+			return "", err
+		}
+		message = "Successfully deleted task"
 	default:
 		log.Printf("Unhandled event type: %s\n", event.Type)
 		return "", nil
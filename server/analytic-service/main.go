@@ -81,8 +81,10 @@ func main() {
 	r := mux.NewRouter()
 
 	// Define routes with mux variables
-	r.HandleFunc("/event/append", eventHandler.ProcessEventHandler).Methods("POST")   // POST method to process event
+	r.HandleFunc("/event/append", eventHandler.ProcessEventHandler).Methods("POST") // POST method to process event
+	r.HandleFunc("/event/append/batch", eventHandler.ProcessEventBatchHandler).Methods("POST")
 	r.HandleFunc("/events/{projectID}", eventHandler.GetEventsHandler).Methods("GET") // GET method to retrieve events
+	r.HandleFunc("/events/{projectID}/effort", eventHandler.GetEffortHandler).Methods("GET")
 
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
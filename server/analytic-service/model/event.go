@@ -6,13 +6,19 @@ import "time"
 type EventType string
 
 const (
-	MemberAddedType       EventType = "MemberAdded"
-	MemberRemovedType     EventType = "MemberRemoved"
-	MemberAddedTaskType   EventType = "MemberAddedTask"
-	MemberRemovedTaskType EventType = "MemberRemovedTask"
-	TaskCreatedType       EventType = "TaskCreated"
-	TaskStatusChangedType EventType = "TaskStatusChanged"
-	DocumentAddedType     EventType = "DocumentAdded"
+	MemberAddedType          EventType = "MemberAdded"
+	MemberRemovedType        EventType = "MemberRemoved"
+	MemberAddedTaskType      EventType = "MemberAddedTask"
+	MemberRemovedTaskType    EventType = "MemberRemovedTask"
+	TaskCreatedType          EventType = "TaskCreated"
+	TaskStatusChangedType    EventType = "TaskStatusChanged"
+	DocumentAddedType        EventType = "DocumentAdded"
+	DocumentVersionAddedType EventType = "DocumentVersionAdded"
+	DocumentRemovedType      EventType = "DocumentRemoved"
+	TaskLabelAddedType       EventType = "TaskLabelAdded"
+	TaskLabelRemovedType     EventType = "TaskLabelRemoved"
+	WorkLoggedType           EventType = "WorkLogged"
+	TaskDeletedType          EventType = "TaskDeleted"
 )
 
 // Event represents a generic event with a type and time
@@ -49,16 +55,18 @@ type MemberRemovedFromTaskEvent struct {
 
 // TaskCreatedEvent represents an event when a new task is created in a project
 type TaskCreatedEvent struct {
-	TaskID    string `json:"taskId"`
-	ProjectID string `json:"projectId"`
+	TaskID    string   `json:"taskId"`
+	ProjectID string   `json:"projectId"`
+	Labels    []string `json:"labels"`
 }
 
 // TaskStatusChangedEvent represents an event when the status of a task changes
 type TaskStatusChangedEvent struct {
-	TaskID    string `json:"taskId"`
-	ProjectID string `json:"projectId"`
-	Status    string `json:"status"`
-	ChangedBy string `json:"changedBy"`
+	TaskID    string   `json:"taskId"`
+	ProjectID string   `json:"projectId"`
+	Status    string   `json:"status"`
+	ChangedBy string   `json:"changedBy"`
+	Labels    []string `json:"labels"`
 }
 
 // DocumentAddedEvent represents an event when a document is added to a task
@@ -68,3 +76,79 @@ type DocumentAddedEvent struct {
 	DocumentID string `json:"documentId"`
 	AddedBy    string `json:"addedBy"`
 }
+
+// DocumentVersionAddedEvent represents an event when a new version of a document is uploaded,
+// or an earlier version is made current again
+type DocumentVersionAddedEvent struct {
+	TaskID       string `json:"taskId"`
+	ProjectID    string `json:"projectId"`
+	DocumentID   string `json:"documentId"`
+	Version      int    `json:"version"`
+	RevertedFrom int    `json:"revertedFrom,omitempty"`
+	AddedBy      string `json:"addedBy"`
+}
+
+// DocumentRemovedEvent represents an event when a document is removed from a task
+type DocumentRemovedEvent struct {
+	TaskID     string `json:"taskId"`
+	ProjectID  string `json:"projectId"`
+	DocumentID string `json:"documentId"`
+	RemovedBy  string `json:"removedBy"`
+}
+
+// TaskLabelChangedEvent represents an event when a label is attached to or detached from a task
+type TaskLabelChangedEvent struct {
+	TaskID    string `json:"taskId"`
+	ProjectID string `json:"projectId"`
+	Label     string `json:"label"`
+	ChangedBy string `json:"changedBy"`
+}
+
+// TaskDeletedEvent represents an event when a task is deleted from a project
+type TaskDeletedEvent struct {
+	TaskID    string `json:"taskId"`
+	ProjectID string `json:"projectId"`
+	DeletedBy string `json:"deletedBy"`
+}
+
+// WorkLoggedEvent represents time logged on a task. DurationSeconds is a delta: edits and
+// deletions of work logs are reported with negative amounts.
+type WorkLoggedEvent struct {
+	WorkLogID       string    `json:"workLogId"`
+	TaskID          string    `json:"taskId"`
+	ProjectID       string    `json:"projectId"`
+	UserID          string    `json:"userId"`
+	StartedAt       time.Time `json:"startedAt"`
+	DurationSeconds int64     `json:"durationSeconds"`
+	Action          string    `json:"action"`
+}
+
+// EffortReport sums up logged time of a project, in seconds, per week and per member
+type EffortReport struct {
+	ProjectID     string           `json:"projectId"`
+	TotalSeconds  int64            `json:"totalSeconds"`
+	WeekSeconds   map[string]int64 `json:"weekSeconds"`
+	MemberSeconds map[string]int64 `json:"memberSeconds"`
+}
+
+// HasLabel reports whether the event payload carries the given label,
+// either as a single "label" or inside a "labels" list
+func (e Event) HasLabel(label string) bool {
+	payload, ok := e.Event.(map[string]any)
+	if !ok {
+		return false
+	}
+	if l, ok := payload["label"].(string); ok && l == label {
+		return true
+	}
+	labels, ok := payload["labels"].([]any)
+	if !ok {
+		return false
+	}
+	for _, l := range labels {
+		if s, ok := l.(string); ok && s == label {
+			return true
+		}
+	}
+	return false
+}
@@ -23,6 +23,7 @@ import (
 	"notification-service/model"
 	"notification-service/repository"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -552,6 +553,15 @@ func (n *NotificationHandler) NotificationListener() {
 	subscribe("project.removed", n.handleProjectRemoved)
 	subscribe("task.removed", n.handleTaskRemoved)
 	subscribe("task.status.update", n.handleTaskStatusUpdate)
+	subscribe("task.mentioned", n.handleTaskMentioned)
+	subscribe("task.deleted", n.handleTaskDeleted)
+	subscribe("task.comment.saved", n.handleTaskCommentSaved)
+	subscribe("task.document.uploaded", n.handleTaskDocumentUploaded)
+	subscribe("task.document.removed", n.handleTaskDocumentRemoved)
+	subscribe("task.due.changed", n.handleTaskDueChanged)
+	subscribe("task.due.reminder", n.handleTaskDueReminder)
+	subscribe("task.overdue", n.handleTaskOverdue)
+	subscribe("task.storage.threshold", n.handleTaskStorageThreshold)
 
 	select {}
 }
@@ -561,8 +571,9 @@ func (n *NotificationHandler) handleProjectJoined(ctx context.Context, msg *nats
 	defer span.End()
 
 	var data struct {
-		UserID      string `json:"userId"`
-		ProjectName string `json:"projectName"`
+		UserID      string   `json:"userId"`
+		ProjectName string   `json:"projectName"`
+		WatcherIDs  []string `json:"watcherIds"`
 	}
 	if err := json.Unmarshal(msg.Data, &data); err != nil {
 		span.RecordError(err)
@@ -583,6 +594,7 @@ func (n *NotificationHandler) handleProjectJoined(ctx context.Context, msg *nats
 		span.SetStatus(codes.Error, err.Error())
 		n.logger.Println("Error inserting notification:", err)
 	}
+	n.notifyUsers(ctx, span, data.WatcherIDs, fmt.Sprintf("A member has been added to the %s project", data.ProjectName), data.UserID)
 	span.SetStatus(codes.Ok, message)
 }
 
@@ -590,8 +602,9 @@ func (n *NotificationHandler) handleTaskJoined(ctx context.Context, msg *nats.Ms
 	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskJoined")
 	defer span.End()
 	var data struct {
-		UserID   string `json:"userId"`
-		TaskName string `json:"taskName"`
+		UserID     string   `json:"userId"`
+		TaskName   string   `json:"taskName"`
+		WatcherIDs []string `json:"watcherIds"`
 	}
 	if err := json.Unmarshal(msg.Data, &data); err != nil {
 		span.RecordError(err)
@@ -612,6 +625,7 @@ func (n *NotificationHandler) handleTaskJoined(ctx context.Context, msg *nats.Ms
 		span.SetStatus(codes.Error, err.Error())
 		n.logger.Println("Error inserting notification:", err)
 	}
+	n.notifyUsers(ctx, span, data.WatcherIDs, fmt.Sprintf("A member has been added to the %s task", data.TaskName), data.UserID)
 	span.SetStatus(codes.Ok, message)
 }
 
@@ -620,8 +634,9 @@ func (n *NotificationHandler) handleProjectRemoved(ctx context.Context, msg *nat
 	defer span.End()
 
 	var data struct {
-		UserID      string `json:"userId"`
-		ProjectName string `json:"projectName"`
+		UserID      string   `json:"userId"`
+		ProjectName string   `json:"projectName"`
+		WatcherIDs  []string `json:"watcherIds"`
 	}
 	if err := json.Unmarshal(msg.Data, &data); err != nil {
 		span.RecordError(err)
@@ -642,6 +657,7 @@ func (n *NotificationHandler) handleProjectRemoved(ctx context.Context, msg *nat
 		span.SetStatus(codes.Error, err.Error())
 		n.logger.Println("Error inserting notification:", err)
 	}
+	n.notifyUsers(ctx, span, data.WatcherIDs, fmt.Sprintf("A member has been removed from the %s project", data.ProjectName), data.UserID)
 	span.SetStatus(codes.Ok, message)
 }
 
@@ -649,8 +665,9 @@ func (n *NotificationHandler) handleTaskRemoved(ctx context.Context, msg *nats.M
 	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskRemoved")
 	defer span.End()
 	var data struct {
-		UserID   string `json:"userId"`
-		TaskName string `json:"taskName"`
+		UserID     string   `json:"userId"`
+		TaskName   string   `json:"taskName"`
+		WatcherIDs []string `json:"watcherIds"`
 	}
 	if err := json.Unmarshal(msg.Data, &data); err != nil {
 		span.RecordError(err)
@@ -671,6 +688,7 @@ func (n *NotificationHandler) handleTaskRemoved(ctx context.Context, msg *nats.M
 		span.SetStatus(codes.Error, err.Error())
 		n.logger.Println("Error inserting notification:", err)
 	}
+	n.notifyUsers(ctx, span, data.WatcherIDs, fmt.Sprintf("A member has been removed from the %s task", data.TaskName), data.UserID)
 	span.SetStatus(codes.Ok, "")
 }
 
@@ -683,6 +701,7 @@ func (n *NotificationHandler) handleTaskStatusUpdate(ctx context.Context, msg *n
 		TaskName   string   `json:"taskName"`
 		TaskStatus string   `json:"taskStatus"`
 		MemberIds  []string `json:"memberIds"`
+		WatcherIds []string `json:"watcherIds"`
 	}
 
 	if err := json.Unmarshal(msg.Data, &update); err != nil {
@@ -695,7 +714,7 @@ func (n *NotificationHandler) handleTaskStatusUpdate(ctx context.Context, msg *n
 
 	message := fmt.Sprintf("The status of the %s task has been changed to %s", update.TaskName, update.TaskStatus)
 
-	for _, memberID := range update.MemberIds {
+	for _, memberID := range recipients(update.MemberIds, update.WatcherIds) {
 		notification := model.Notification{
 			UserID:    memberID,
 			Message:   message,
@@ -716,6 +735,300 @@ func (n *NotificationHandler) handleTaskStatusUpdate(ctx context.Context, msg *n
 	span.SetStatus(codes.Ok, message)
 }
 
+func (n *NotificationHandler) handleTaskMentioned(ctx context.Context, msg *nats.Msg) {
+	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskMentioned")
+	defer span.End()
+
+	var data struct {
+		UserIDs     []string `json:"userIds"`
+		TaskID      string   `json:"taskId"`
+		TaskName    string   `json:"taskName"`
+		ProjectID   string   `json:"projectId"`
+		CommentID   string   `json:"commentId"`
+		MentionedBy string   `json:"mentionedBy"`
+	}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		n.logger.Println("Error unmarshalling task.mentioned message:", err)
+		return
+	}
+
+	message := fmt.Sprintf("You have been mentioned in a comment on the %s task", data.TaskName)
+	for _, userID := range data.UserIDs {
+		notification := model.Notification{
+			UserID:    userID,
+			Message:   message,
+			CreatedAt: time.Now(),
+			Status:    model.Unread,
+		}
+		if err := n.repo.Create(ctx, &notification); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			n.logger.Printf("Error inserting notification for user %s: %v", userID, err)
+			continue
+		}
+	}
+	span.SetStatus(codes.Ok, message)
+}
+
+func (n *NotificationHandler) handleTaskDeleted(ctx context.Context, msg *nats.Msg) {
+	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskDeleted")
+	defer span.End()
+
+	var data struct {
+		TaskID     string   `json:"taskId"`
+		TaskName   string   `json:"taskName"`
+		MemberIds  []string `json:"memberIds"`
+		WatcherIds []string `json:"watcherIds"`
+		DeletedBy  string   `json:"deletedBy"`
+	}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		n.logger.Println("Error unmarshalling task.deleted message:", err)
+		return
+	}
+
+	message := fmt.Sprintf("The %s task has been deleted", data.TaskName)
+	for _, memberID := range recipients(data.MemberIds, data.WatcherIds) {
+		// the manager who deleted the task does not need to be told about it
+		if memberID == data.DeletedBy {
+			continue
+		}
+		notification := model.Notification{
+			UserID:    memberID,
+			Message:   message,
+			CreatedAt: time.Now(),
+			Status:    model.Unread,
+		}
+		if err := n.repo.Create(ctx, &notification); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			n.logger.Printf("Error inserting notification for user %s: %v", memberID, err)
+			continue
+		}
+	}
+	span.SetStatus(codes.Ok, message)
+}
+
+func (n *NotificationHandler) handleTaskCommentSaved(ctx context.Context, msg *nats.Msg) {
+	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskCommentSaved")
+	defer span.End()
+
+	var data struct {
+		TaskName   string   `json:"taskName"`
+		AuthorID   string   `json:"authorId"`
+		MentionIDs []string `json:"mentionIds"`
+		MemberIds  []string `json:"memberIds"`
+		WatcherIds []string `json:"watcherIds"`
+	}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		n.logger.Println("Error unmarshalling task.comment.saved message:", err)
+		return
+	}
+	// edited and restored comments carry no task, nobody is notified about them
+	if data.TaskName == "" {
+		span.SetStatus(codes.Ok, "Comment is not new")
+		return
+	}
+
+	// mentioned users already got a notification from task.mentioned
+	excluded := append([]string{data.AuthorID}, data.MentionIDs...)
+	message := fmt.Sprintf("A new comment has been posted on the %s task", data.TaskName)
+	n.notifyUsers(ctx, span, recipients(data.MemberIds, data.WatcherIds), message, excluded...)
+	span.SetStatus(codes.Ok, message)
+}
+
+func (n *NotificationHandler) handleTaskDocumentUploaded(ctx context.Context, msg *nats.Msg) {
+	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskDocumentUploaded")
+	defer span.End()
+
+	var data struct {
+		TaskName     string   `json:"taskName"`
+		FileName     string   `json:"fileName"`
+		Version      int      `json:"version"`
+		RevertedFrom int      `json:"revertedFrom"`
+		UploadedBy   string   `json:"uploadedBy"`
+		MemberIds    []string `json:"memberIds"`
+		WatcherIds   []string `json:"watcherIds"`
+	}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		n.logger.Println("Error unmarshalling task.document.uploaded message:", err)
+		return
+	}
+	// restored documents carry no task, nobody is notified about them
+	if data.TaskName == "" {
+		span.SetStatus(codes.Ok, "Document is not new")
+		return
+	}
+
+	message := fmt.Sprintf("The %s document has been uploaded to the %s task", data.FileName, data.TaskName)
+	if data.RevertedFrom > 0 {
+		message = fmt.Sprintf("The %s document of the %s task has been reverted to version %d", data.FileName, data.TaskName, data.RevertedFrom)
+	} else if data.Version > 1 {
+		message = fmt.Sprintf("Version %d of the %s document has been uploaded to the %s task", data.Version, data.FileName, data.TaskName)
+	}
+	n.notifyUsers(ctx, span, recipients(data.MemberIds, data.WatcherIds), message, data.UploadedBy)
+	span.SetStatus(codes.Ok, message)
+}
+
+func (n *NotificationHandler) handleTaskDocumentRemoved(ctx context.Context, msg *nats.Msg) {
+	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskDocumentRemoved")
+	defer span.End()
+
+	var data struct {
+		TaskName   string   `json:"taskName"`
+		FileName   string   `json:"fileName"`
+		RemovedBy  string   `json:"removedBy"`
+		MemberIds  []string `json:"memberIds"`
+		WatcherIds []string `json:"watcherIds"`
+	}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		n.logger.Println("Error unmarshalling task.document.removed message:", err)
+		return
+	}
+
+	message := fmt.Sprintf("The %s document has been removed from the %s task", data.FileName, data.TaskName)
+	n.notifyUsers(ctx, span, recipients(data.MemberIds, data.WatcherIds), message, data.RemovedBy)
+	span.SetStatus(codes.Ok, message)
+}
+
+func (n *NotificationHandler) handleTaskDueChanged(ctx context.Context, msg *nats.Msg) {
+	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskDueChanged")
+	defer span.End()
+
+	var data struct {
+		TaskName   string     `json:"taskName"`
+		DueDate    *time.Time `json:"dueDate"`
+		ChangedBy  string     `json:"changedBy"`
+		MemberIds  []string   `json:"memberIds"`
+		WatcherIds []string   `json:"watcherIds"`
+	}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		n.logger.Println("Error unmarshalling task.due.changed message:", err)
+		return
+	}
+
+	message := fmt.Sprintf("The due date of the %s task has been removed", data.TaskName)
+	if data.DueDate != nil {
+		message = fmt.Sprintf("The %s task is now due on %s", data.TaskName, data.DueDate.Format(time.RFC1123))
+	}
+	n.notifyUsers(ctx, span, recipients(data.MemberIds, data.WatcherIds), message, data.ChangedBy)
+	span.SetStatus(codes.Ok, message)
+}
+
+func (n *NotificationHandler) handleTaskDueReminder(ctx context.Context, msg *nats.Msg) {
+	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskDueReminder")
+	defer span.End()
+
+	var data struct {
+		TaskName string    `json:"taskName"`
+		DueDate  time.Time `json:"dueDate"`
+		UserIds  []string  `json:"userIds"`
+	}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		n.logger.Println("Error unmarshalling task.due.reminder message:", err)
+		return
+	}
+
+	message := fmt.Sprintf("The %s task is due on %s", data.TaskName, data.DueDate.Format(time.RFC1123))
+	n.notifyUsers(ctx, span, data.UserIds, message)
+	span.SetStatus(codes.Ok, message)
+}
+
+func (n *NotificationHandler) handleTaskOverdue(ctx context.Context, msg *nats.Msg) {
+	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskOverdue")
+	defer span.End()
+
+	var data struct {
+		TaskName   string   `json:"taskName"`
+		MemberIds  []string `json:"memberIds"`
+		ManagerID  string   `json:"managerId"`
+		WatcherIds []string `json:"watcherIds"`
+	}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		n.logger.Println("Error unmarshalling task.overdue message:", err)
+		return
+	}
+
+	// the project manager is told about every overdue task, watching or not
+	message := fmt.Sprintf("The %s task is overdue", data.TaskName)
+	n.notifyUsers(ctx, span, recipients(append(data.MemberIds, data.ManagerID), data.WatcherIds), message)
+	span.SetStatus(codes.Ok, message)
+}
+
+func (n *NotificationHandler) handleTaskStorageThreshold(ctx context.Context, msg *nats.Msg) {
+	ctx, span := n.tracer.Start(ctx, "NotificationHandler.handleTaskStorageThreshold")
+	defer span.End()
+
+	var data struct {
+		ProjectName string `json:"projectName"`
+		ManagerID   string `json:"managerId"`
+		Percent     int    `json:"percent"`
+		Bytes       int64  `json:"bytes"`
+		QuotaBytes  int64  `json:"quotaBytes"`
+	}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		n.logger.Println("Error unmarshalling task.storage.threshold message:", err)
+		return
+	}
+
+	message := fmt.Sprintf("The documents of the %s project use %d%% of its storage quota (%d of %d MB)",
+		data.ProjectName, data.Percent, data.Bytes>>20, data.QuotaBytes>>20)
+	if data.Percent >= 100 {
+		message = fmt.Sprintf("The %s project has used up its storage quota of %d MB, no more documents can be uploaded",
+			data.ProjectName, data.QuotaBytes>>20)
+	}
+	n.notifyUsers(ctx, span, []string{data.ManagerID}, message)
+	span.SetStatus(codes.Ok, message)
+}
+
+// notifyUsers stores the same notification for every given user except the excluded ones
+func (n *NotificationHandler) notifyUsers(ctx context.Context, span trace.Span, userIDs []string, message string, excluded ...string) {
+	for _, userID := range userIDs {
+		if slices.Contains(excluded, userID) {
+			continue
+		}
+		notification := model.Notification{
+			UserID:    userID,
+			Message:   message,
+			CreatedAt: time.Now(),
+			Status:    model.Unread,
+		}
+		if err := n.repo.Create(ctx, &notification); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			n.logger.Printf("Error inserting notification for user %s: %v", userID, err)
+		}
+	}
+}
+
+// recipients merges the members of a task with its watchers, every user is listed once
+func recipients(memberIDs, watcherIDs []string) []string {
+	result := make([]string, 0, len(memberIDs)+len(watcherIDs))
+	for _, userID := range append(slices.Clone(memberIDs), watcherIDs...) {
+		if userID != "" && !slices.Contains(result, userID) {
+			result = append(result, userID)
+		}
+	}
+	return result
+}
+
 func Conn() (*nats.Conn, error) {
 	connection := os.Getenv("NATS_URL")
 	conn, err := nats.Connect(connection)
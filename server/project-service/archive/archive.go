@@ -0,0 +1,187 @@
+// Package archive defines the portable project archive: a zip file with the project, its
+// members, tasks, dependencies, comments, documents and analytics events. The export writes it
+// and the importer reads it back, SchemaDoc describes the format for everyone else.
+package archive
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// Format identifies a project archive in its manifest
+	Format = "project-archive"
+	// SchemaVersion is bumped whenever an entry changes in a way older readers cannot handle
+	SchemaVersion = 1
+
+	ManifestEntry     = "manifest.json"
+	SchemaEntry       = "SCHEMA.md"
+	ProjectEntry      = "project.json"
+	MembersEntry      = "members.json"
+	TasksEntry        = "tasks.json"
+	DependenciesEntry = "dependencies.json"
+	CommentsEntry     = "comments.json"
+	DocumentsEntry    = "documents.json"
+	EventsEntry       = "events.json"
+	FilesDir          = "files/"
+)
+
+// Manifest is written last so that its warnings cover everything that could not be exported
+type Manifest struct {
+	Format        string         `json:"format"`
+	SchemaVersion int            `json:"schemaVersion"`
+	ProjectID     string         `json:"projectId"`
+	ExportedAt    time.Time      `json:"exportedAt"`
+	ExportedBy    string         `json:"exportedBy"`
+	Counts        map[string]int `json:"counts"`
+	Warnings      []string       `json:"warnings"`
+}
+
+type Member struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	// Role is the role in the project, manager or member
+	Role string `json:"role"`
+}
+
+// Dependency is an edge of the task graph, the task From depends on the task To
+type Dependency struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Document struct {
+	ID         string    `json:"id"`
+	TaskID     string    `json:"taskId"`
+	FileName   string    `json:"fileName"`
+	FileType   string    `json:"fileType"`
+	UploadedAt time.Time `json:"uploadedAt"`
+	// Path is the entry holding the file, empty when the file could not be exported
+	Path   string `json:"path,omitempty"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// FilePath is the entry of a document file. The document ID keeps entries of files with the
+// same name apart, the name is reduced to its base so it cannot point outside of files/.
+func FilePath(documentID, fileName string) string {
+	name := path.Base(strings.ReplaceAll(fileName, `\`, "/"))
+	if name == "." || name == "/" || name == ".." {
+		name = "file"
+	}
+	return FilesDir + documentID + "/" + name
+}
+
+// Writer writes the entries of an archive
+type Writer struct {
+	zip *zip.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zip: zip.NewWriter(w)}
+}
+
+// WriteJSON writes an entry holding the value as indented JSON
+func (w *Writer) WriteJSON(name string, value interface{}) error {
+	entry, err := w.zip.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", name, err)
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	return nil
+}
+
+// Create starts an entry the caller writes to until the next entry is created
+func (w *Writer) Create(name string, modified time.Time) (io.Writer, error) {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified}
+	entry, err := w.zip.CreateHeader(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", name, err)
+	}
+	return entry, nil
+}
+
+func (w *Writer) Close() error {
+	return w.zip.Close()
+}
+
+// Reader gives access to the entries of an archive whose manifest has been checked
+type Reader struct {
+	Manifest Manifest
+	files    map[string]*zip.File
+}
+
+// NewReader opens an archive, archives of a newer schema version than this build knows are
+// rejected instead of being half understood
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project archive: %v", err)
+	}
+	reader := &Reader{files: map[string]*zip.File{}}
+	for _, file := range zr.File {
+		reader.files[file.Name] = file
+	}
+	if err := reader.ReadJSON(ManifestEntry, &reader.Manifest); err != nil {
+		return nil, err
+	}
+	if reader.Manifest.Format != Format {
+		return nil, fmt.Errorf("invalid project archive: unknown format %q", reader.Manifest.Format)
+	}
+	if reader.Manifest.SchemaVersion < 1 || reader.Manifest.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("project archive schema version %d is not supported, the newest supported version is %d", reader.Manifest.SchemaVersion, SchemaVersion)
+	}
+	return reader, nil
+}
+
+// Has reports whether the archive contains the entry
+func (r *Reader) Has(name string) bool {
+	_, ok := r.files[name]
+	return ok
+}
+
+// ReadJSON decodes an entry into value
+func (r *Reader) ReadJSON(name string, value interface{}) error {
+	file, ok := r.files[name]
+	if !ok {
+		return fmt.Errorf("invalid project archive: %s is missing", name)
+	}
+	entry, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("invalid project archive: %s: %v", name, err)
+	}
+	defer entry.Close()
+	if err := json.NewDecoder(entry).Decode(value); err != nil {
+		return fmt.Errorf("invalid project archive: %s: %v", name, err)
+	}
+	return nil
+}
+
+// ReadFile returns the content of an entry, like a document file
+func (r *Reader) ReadFile(name string) ([]byte, error) {
+	file, ok := r.files[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid project archive: %s is missing", name)
+	}
+	entry, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("invalid project archive: %s: %v", name, err)
+	}
+	defer entry.Close()
+	data, err := io.ReadAll(entry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project archive: %s: %v", name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,32 @@
+package archive
+
+// SchemaDoc is written into every archive as SCHEMA.md
+const SchemaDoc = "# Project archive, schema version 1\n" + `
+A project archive is a zip file. Every JSON entry is UTF-8, times are RFC 3339 and IDs are the
+ones the project had when it was exported.
+
+| Entry | Content |
+| --- | --- |
+| manifest.json | format ("project-archive"), schemaVersion, projectId, exportedAt, exportedBy, counts of the other entries and warnings about anything that could not be exported |
+| SCHEMA.md | this description |
+| project.json | the project: id, name, end_date, min_members, max_members, manager, user_ids, labels (id, name, color) and custom_fields |
+| members.json | list of the manager and the members: id, email, firstName, lastName and role ("manager" or "member") |
+| tasks.json | list of tasks: id, projectId, name, description, status ("Pending", "In Progress" or "Completed"), createdAt, updatedAt, user_ids, dependencies, blocked, labels (names), customFields, dueDate and checklist (name, done) |
+| dependencies.json | list of task graph edges: from and to, the task from depends on the task to |
+| comments.json | list of comments: id, taskId, projectId, parentId, authorId, body (Markdown), mentions, createdAt, editedAt and history (body, editedAt) |
+| documents.json | list of documents: id, taskId, fileName, fileType, uploadedAt, path, size and sha256 (hex) of the file |
+| events.json | the analytics event history of the project: list of events with type, time, projectId and the event body |
+| files/<document id>/<file name> | the content of a document, path in documents.json points here |
+
+A document whose file could not be read has no path and is named in the manifest warnings.
+
+Readers reject archives with a schemaVersion newer than the one they know. Fields may be added
+to any entry without changing the version, readers ignore fields they do not know. Removing or
+changing the meaning of a field raises the version.
+
+Importing an archive creates a new project with new IDs. The project settings, labels, custom
+fields, members that are users of this installation, tasks, dependencies, comments and documents
+are restored. Comments by users who are not members of the new project are attributed to the
+importing user, and documents are uploaded by the importing user as a first version. Events are
+the history of the exported project and are reported by the import as not restored.
+`
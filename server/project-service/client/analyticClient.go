@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type AnalyticClient struct {
+	address string
+}
+
+func NewAnalyticClient(address string) AnalyticClient {
+	return AnalyticClient{
+		address: strings.TrimSuffix(address, "/"),
+	}
+}
+
+// GetEvents retrieves the event history of a project as analytic-service stores it
+func (client AnalyticClient) GetEvents(ctx context.Context, projectId string) ([]json.RawMessage, error) {
+	httpReq, err := http.NewRequest(http.MethodGet, client.address+"/events/"+projectId, nil)
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return nil, errors.New("error while creating request")
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientAnalytic, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return nil, errors.New("error while creating request")
+	}
+	res, err := clientAnalytic.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return nil, errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		log.Printf("Error response body: %s", string(body))
+		return nil, fmt.Errorf("error while getting events: %s", res.Status)
+	}
+
+	var events []json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&events); err != nil {
+		log.Printf("Error decoding response body: %v", err)
+		return nil, errors.New("error decoding events")
+	}
+	if events == nil {
+		events = []json.RawMessage{}
+	}
+	return events, nil
+}
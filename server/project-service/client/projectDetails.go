@@ -4,18 +4,21 @@ import (
 	"encoding/json"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"io"
+	"project-service/model"
 )
 
 type ProjectDetails struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name       string             `bson:"name" json:"name"`
-	EndDate    string             `bson:"end_date" json:"end_date"`
-	MinMembers string             `bson:"min_members" json:"min_members"`
-	MaxMembers string             `bson:"max_members" json:"max_members"`
-	UserIDs    []string           `bson:"user_ids" json:"user_ids"`
-	Users      []*UserDetails     `bson:"users" json:"users"` // List of UserDetails
-	Manager    string             `bson:"manager" json:"manager"`
-	Tasks      []*TaskDetails     `bson:"tasks" json:"tasks"` // List of Tasks
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name         string              `bson:"name" json:"name"`
+	EndDate      string              `bson:"end_date" json:"end_date"`
+	MinMembers   string              `bson:"min_members" json:"min_members"`
+	MaxMembers   string              `bson:"max_members" json:"max_members"`
+	UserIDs      []string            `bson:"user_ids" json:"user_ids"`
+	Users        []*UserDetails      `bson:"users" json:"users"` // List of UserDetails
+	Manager      string              `bson:"manager" json:"manager"`
+	Tasks        []*TaskDetails      `bson:"tasks" json:"tasks"` // List of Tasks
+	Labels       []model.Label       `bson:"labels" json:"labels"`
+	CustomFields []model.CustomField `bson:"custom_fields" json:"custom_fields"`
 }
 
 type ProjectsDetails []*ProjectDetails
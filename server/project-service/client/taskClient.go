@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"go.opentelemetry.io/otel/propagation"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 )
 
@@ -65,3 +67,194 @@ func (client TaskClient) GetTasksByProjectId(projectId string, cookie *http.Cook
 
 	return tasks, nil
 }
+
+// CreateTask creates a task on behalf of the user whose cookie is passed and returns its ID
+func (client TaskClient) CreateTask(ctx context.Context, task *NewTask, cookie *http.Cookie) (string, error) {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("error while encoding task: %w", err)
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, client.address+"/tasks", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return "", errors.New("error while creating request")
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.AddCookie(cookie)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientTask, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return "", errors.New("error while creating request")
+	}
+	res, err := clientTask.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return "", errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(res.Body)
+		return "", fmt.Errorf("error while creating task: %s: %s", res.Status, bytes.TrimSpace(message))
+	}
+	var created struct {
+		TaskID string `json:"taskId"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		log.Printf("Error decoding response body: %v", err)
+		return "", errors.New("error decoding created task")
+	}
+	return created.TaskID, nil
+}
+
+// GetProjectExport retrieves the tasks of a project with their comments and document metadata
+func (client TaskClient) GetProjectExport(ctx context.Context, projectId string, cookie *http.Cookie) (*ProjectExport, error) {
+	httpReq, err := http.NewRequest(http.MethodGet, client.address+"/tasks/projects/"+projectId+"/export", nil)
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return nil, errors.New("error while creating request")
+	}
+	httpReq.AddCookie(cookie)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientTask, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return nil, errors.New("error while creating request")
+	}
+	res, err := clientTask.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return nil, errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		log.Printf("Error response body: %s", string(body))
+		return nil, fmt.Errorf("error while getting project export: %s", res.Status)
+	}
+
+	export := &ProjectExport{}
+	if err := json.NewDecoder(res.Body).Decode(export); err != nil {
+		log.Printf("Error decoding response body: %v", err)
+		return nil, errors.New("error decoding project export")
+	}
+	return export, nil
+}
+
+// DownloadDocument copies the file of a task document to w and returns its size
+func (client TaskClient) DownloadDocument(ctx context.Context, documentId string, cookie *http.Cookie, w io.Writer) (int64, error) {
+	httpReq, err := http.NewRequest(http.MethodGet, client.address+"/tasks/download/"+documentId, nil)
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return 0, errors.New("error while creating request")
+	}
+	httpReq.AddCookie(cookie)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientTask, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return 0, errors.New("error while creating request")
+	}
+	res, err := clientTask.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return 0, errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("error while downloading document: %s", res.Status)
+	}
+	size, err := io.Copy(w, res.Body)
+	if err != nil {
+		return size, fmt.Errorf("error while downloading document: %w", err)
+	}
+	return size, nil
+}
+
+// ImportComments restores the comments of a project archive on the tasks of the imported
+// project, only its manager may do so
+func (client TaskClient) ImportComments(ctx context.Context, projectId string, comments []ImportedComment, cookie *http.Cookie) error {
+	body, err := json.Marshal(comments)
+	if err != nil {
+		return fmt.Errorf("error while encoding comments: %w", err)
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, client.address+"/tasks/projects/"+projectId+"/comments/import", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return errors.New("error while creating request")
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.AddCookie(cookie)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientTask, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return errors.New("error while creating request")
+	}
+	res, err := clientTask.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("error while importing comments: %s: %s", res.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// UploadDocument attaches a file to a task on behalf of the user whose cookie is passed, the
+// document policy and the storage quota of the project apply as for any upload
+func (client TaskClient) UploadDocument(ctx context.Context, taskId, fileName string, data []byte, cookie *http.Cookie) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("taskId", taskId); err != nil {
+		return fmt.Errorf("error while encoding document: %w", err)
+	}
+	file, err := form.CreateFormFile("file", fileName)
+	if err != nil {
+		return fmt.Errorf("error while encoding document: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("error while encoding document: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return fmt.Errorf("error while encoding document: %w", err)
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, client.address+"/tasks/upload", &body)
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return errors.New("error while creating request")
+	}
+	httpReq.Header.Set("Content-Type", form.FormDataContentType())
+	httpReq.AddCookie(cookie)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientTask, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return errors.New("error while creating request")
+	}
+	res, err := clientTask.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("error while uploading document: %s: %s", res.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
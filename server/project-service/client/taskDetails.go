@@ -16,17 +16,73 @@ const (
 )
 
 type TaskDetails struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ProjectID    string             `bson:"project_id" json:"projectId"`
-	Name         string             `bson:"name" json:"name"`
-	Description  string             `bson:"description" json:"description"`
-	Status       TaskStatus         `bson:"status" json:"status"`
-	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
-	UpdatedAt    time.Time          `bson:"updated_at" json:"updatedAt"`
-	UserIDs      []string           `bson:"user_ids" json:"user_ids"`
-	Users        []*UserDetails     `bson:"users" json:"users"` // List of UserDetails
-	Dependencies []string           `bson:"dependencies" json:"dependencies"`
-	Blocked      bool               `bson:"blocked" json:"blocked"`
+	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ProjectID    string                 `bson:"project_id" json:"projectId"`
+	Name         string                 `bson:"name" json:"name"`
+	Description  string                 `bson:"description" json:"description"`
+	Status       TaskStatus             `bson:"status" json:"status"`
+	CreatedAt    time.Time              `bson:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time              `bson:"updated_at" json:"updatedAt"`
+	UserIDs      []string               `bson:"user_ids" json:"user_ids"`
+	Users        []*UserDetails         `bson:"users" json:"users"` // List of UserDetails
+	Dependencies []string               `bson:"dependencies" json:"dependencies"`
+	Blocked      bool                   `bson:"blocked" json:"blocked"`
+	Labels       []string               `bson:"labels" json:"labels"`
+	CustomFields map[string]interface{} `bson:"custom_fields" json:"customFields"`
+}
+
+// NewTask is the body task-service expects when a task is created
+type NewTask struct {
+	ProjectID   string          `json:"projectId"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Status      TaskStatus      `json:"status"`
+	UserIDs     []string        `json:"user_ids"`
+	Labels      []string        `json:"labels"`
+	DueDate     *time.Time      `json:"dueDate,omitempty"`
+	Checklist   []ChecklistItem `json:"checklist,omitempty"`
+	// CustomFields holds the values of the task, keyed by the ID of the field in the project
+	CustomFields map[string]interface{} `json:"customFields,omitempty"`
+}
+
+type ChecklistItem struct {
+	Name string `json:"name"`
+	Done bool   `json:"done"`
+}
+
+// ProjectExport is what task-service holds about a project. Tasks and comments are kept as
+// task-service encodes them so the export carries every field.
+type ProjectExport struct {
+	Tasks     []json.RawMessage  `json:"tasks"`
+	Comments  []json.RawMessage  `json:"comments"`
+	Documents []ExportedDocument `json:"documents"`
+}
+
+type ExportedDocument struct {
+	ID         string    `json:"id"`
+	TaskID     string    `json:"taskId"`
+	FileName   string    `json:"fileName"`
+	FileType   string    `json:"fileType"`
+	UploadedAt time.Time `json:"uploadedAt"`
+}
+
+// ImportedComment is a comment of a project archive restored on a task of the import. ID and
+// ParentID are the IDs of the archive, task-service gives the comment a new one.
+type ImportedComment struct {
+	ID        string            `json:"id,omitempty"`
+	TaskID    string            `json:"taskId"`
+	ParentID  string            `json:"parentId,omitempty"`
+	AuthorID  string            `json:"authorId"`
+	Body      string            `json:"body"`
+	Mentions  []string          `json:"mentions"`
+	CreatedAt time.Time         `json:"createdAt"`
+	EditedAt  *time.Time        `json:"editedAt,omitempty"`
+	History   []CommentRevision `json:"history"`
+}
+
+type CommentRevision struct {
+	Body     string    `json:"body"`
+	EditedAt time.Time `json:"editedAt"`
 }
 
 type TasksDetails []*TaskDetails
@@ -190,3 +190,39 @@ func (client UserClient) GetByIdsWithCookies(ids []string, cookie *http.Cookie)
 
 	return users, nil
 }
+
+// GetMembers retrieves every user with the member role, the importer matches them by email
+func (client UserClient) GetMembers(ctx context.Context, cookie *http.Cookie) ([]*UserDetails, error) {
+	httpReq, err := http.NewRequest(http.MethodGet, client.address+"/members", nil)
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return nil, errors.New("error while creating the request")
+	}
+	httpReq.AddCookie(cookie)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientUser, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return nil, errors.New("error while creating the request")
+	}
+	res, err := clientUser.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return nil, errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		log.Printf("Error response body: %s", string(body))
+		return nil, fmt.Errorf("error while getting members: %s", res.Status)
+	}
+
+	var users []*UserDetails
+	if err := json.NewDecoder(res.Body).Decode(&users); err != nil {
+		log.Printf("Error decoding response body: %v", err)
+		return nil, errors.New("error decoding members")
+	}
+	return users, nil
+}
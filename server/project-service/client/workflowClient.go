@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type WorkflowClient struct {
+	address string
+}
+
+func NewWorkflowClient(address string) WorkflowClient {
+	return WorkflowClient{
+		address: strings.TrimSuffix(address, "/"),
+	}
+}
+
+// DependencyEdge is an edge of the task graph, the task From depends on the task To
+type DependencyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// GetDependencies retrieves the dependency edges between the tasks of a project
+func (client WorkflowClient) GetDependencies(ctx context.Context, projectId string) ([]DependencyEdge, error) {
+	httpReq, err := http.NewRequest(http.MethodGet, client.address+"/workflow/project/"+projectId, nil)
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return nil, errors.New("error while creating request")
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientWorkflow, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return nil, errors.New("error while creating request")
+	}
+	res, err := clientWorkflow.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return nil, errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		log.Printf("Error response body: %s", string(body))
+		return nil, fmt.Errorf("error while getting task graph: %s", res.Status)
+	}
+
+	var graph struct {
+		Edges []DependencyEdge `json:"edges"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&graph); err != nil {
+		log.Printf("Error decoding response body: %v", err)
+		return nil, errors.New("error decoding task graph")
+	}
+	if graph.Edges == nil {
+		graph.Edges = []DependencyEdge{}
+	}
+	return graph.Edges, nil
+}
+
+// AddDependency makes the task depend on the dependency, workflow-service blocks the dependency
+// and records the edge in task-service as well
+func (client WorkflowClient) AddDependency(ctx context.Context, taskId, dependencyId string) error {
+	httpReq, err := http.NewRequest(http.MethodPost, client.address+"/workflow/"+taskId+"/add/"+dependencyId, nil)
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return errors.New("error while creating request")
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientWorkflow, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return errors.New("error while creating request")
+	}
+	res, err := clientWorkflow.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("error while adding dependency: %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Command import sends a Trello board export, a CSV file or a project archive to the project
+// import endpoint.
+//
+//	go run ./cmd/import -file board.json -token $AUTH_TOKEN -dry-run
+//
+// Run it with -dry-run first to see which users and fields cannot be mapped, then again
+// without it to create the project.
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pairs collects repeated key=value flags
+type pairs map[string]string
+
+func (p pairs) String() string {
+	return fmt.Sprint(map[string]string(p))
+}
+
+func (p pairs) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	p[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	return nil
+}
+
+func main() {
+	statusMap, memberEmails := pairs{}, pairs{}
+	file := flag.String("file", "", "Trello JSON export, CSV file or project archive to import")
+	format := flag.String("format", "", "trello, csv or archive, guessed from the file extension when empty")
+	url := flag.String("url", "https://localhost/api/project-server", "address of project-service")
+	token := flag.String("token", os.Getenv("AUTH_TOKEN"), "auth_token of a manager, defaults to $AUTH_TOKEN")
+	caCert := flag.String("ca", "", "CA certificate to trust, the system roots are used when empty")
+	dryRun := flag.Bool("dry-run", false, "only report what would be imported")
+	name := flag.String("name", "", "project name, defaults to the board name")
+	endDate := flag.String("end-date", "", "project end date, defaults to the last due date")
+	minMembers := flag.String("min-members", "", "minimum number of project members")
+	maxMembers := flag.String("max-members", "", "maximum number of project members")
+	flag.Var(statusMap, "status", "list=status mapping, may be repeated")
+	flag.Var(memberEmails, "member", "username=email mapping for board members, may be repeated")
+	flag.Parse()
+
+	if *file == "" || *token == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(*file)) {
+		case ".json":
+			*format = "trello"
+		case ".csv":
+			*format = "csv"
+		case ".zip":
+			*format = "archive"
+		}
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		fail(err)
+	}
+	payload := string(data)
+	if *format == "archive" {
+		payload = base64.StdEncoding.EncodeToString(data)
+	}
+	request := map[string]interface{}{
+		"format": *format,
+		"data":   payload,
+		"dryRun": *dryRun,
+		"project": map[string]string{
+			"name":        *name,
+			"end_date":    *endDate,
+			"min_members": *minMembers,
+			"max_members": *maxMembers,
+		},
+		"statusMap":    statusMap,
+		"memberEmails": memberEmails,
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		fail(err)
+	}
+
+	httpClient, err := newClient(*caCert)
+	if err != nil {
+		fail(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(*url, "/")+"/projects/import", bytes.NewReader(body))
+	if err != nil {
+		fail(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "auth_token", Value: *token})
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		fail(err)
+	}
+	defer res.Body.Close()
+	response, err := io.ReadAll(res.Body)
+	if err != nil {
+		fail(err)
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		fail(fmt.Errorf("import failed: %s: %s", res.Status, bytes.TrimSpace(response)))
+	}
+
+	var result bytes.Buffer
+	if err := json.Indent(&result, response, "", "  "); err != nil {
+		result.Write(response)
+	}
+	fmt.Println(result.String())
+}
+
+func newClient(caCert string) (*http.Client, error) {
+	if caCert == "" {
+		return http.DefaultClient, nil
+	}
+	pem, err := os.ReadFile(caCert)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caCert)
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
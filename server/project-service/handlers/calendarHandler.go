@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"project-service/model"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// calendarProject is the part of a project that task-service puts into calendar feeds
+type calendarProject struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	EndDate string `json:"end_date"`
+	Manager string `json:"manager"`
+}
+
+func toCalendarProject(project *model.Project) calendarProject {
+	return calendarProject{
+		ID:      project.ID.Hex(),
+		Name:    project.Name,
+		EndDate: project.EndDate,
+		Manager: project.Manager,
+	}
+}
+
+// GetUserCalendarProjects lists the projects a user manages or is a member of. It is served on
+// the internal port only, calendar feeds are read by calendar clients that carry no cookie.
+func (p *ProjectsHandler) GetUserCalendarProjects(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.GetUserCalendarProjects")
+	defer span.End()
+	userId := mux.Vars(h)["userId"]
+
+	managed, err := p.repo.GetAllByManager(ctx, userId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error retrieving projects", http.StatusInternalServerError)
+		return
+	}
+	joined, err := p.repo.GetAllByMember(ctx, userId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error retrieving projects", http.StatusInternalServerError)
+		return
+	}
+
+	projects := []calendarProject{}
+	seen := map[string]bool{}
+	for _, project := range append(managed, joined...) {
+		if seen[project.ID.Hex()] {
+			continue
+		}
+		seen[project.ID.Hex()] = true
+		projects = append(projects, toCalendarProject(project))
+	}
+
+	if err := json.NewEncoder(rw).Encode(projects); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Println("Error encoding calendar projects:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully got calendar projects")
+}
+
+// GetCalendarProject returns a single project for the calendar feed of its manager
+func (p *ProjectsHandler) GetCalendarProject(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.GetCalendarProject")
+	defer span.End()
+	projectId := mux.Vars(h)["id"]
+
+	project, err := p.repo.GetById(ctx, projectId)
+	if err != nil || project == nil || project.PendingDeletion {
+		span.SetStatus(codes.Error, "Project not found")
+		http.Error(rw, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	if err := json.NewEncoder(rw).Encode(toCalendarProject(project)); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Println("Error encoding calendar project:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully got calendar project")
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"net/http"
+	"project-service/model"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func (p *ProjectsHandler) GetProjectCustomFields(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.GetProjectCustomFields")
+	defer span.End()
+	projectId := mux.Vars(h)["id"]
+
+	project, err := p.repo.GetById(ctx, projectId)
+	if err != nil || project == nil {
+		span.SetStatus(codes.Error, "Project not found")
+		http.Error(rw, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	userId, _ := h.Context().Value(KeyUser{}).(string)
+	if project.Manager != userId && !contains(project.UserIDs, userId) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	fields := model.CustomFields{}
+	for i := range project.CustomFields {
+		fields = append(fields, &project.CustomFields[i])
+	}
+	if err := fields.ToJSON(rw); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to convert to json", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully retrieved project custom fields")
+}
+
+func (p *ProjectsHandler) PostProjectCustomField(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.PostProjectCustomField")
+	defer span.End()
+	projectId := mux.Vars(h)["id"]
+
+	field := &model.CustomField{}
+	if err := field.FromJSON(h.Body); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to decode json", http.StatusBadRequest)
+		return
+	}
+	if err := field.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	project, ok := p.getManagedProject(rw, h, projectId)
+	if !ok {
+		return
+	}
+	if findCustomFieldByName(project.CustomFields, field.Name) != nil {
+		span.SetStatus(codes.Error, "Custom field already exists")
+		http.Error(rw, "Field with that name already exists in the project", http.StatusConflict)
+		return
+	}
+
+	if err := p.repo.AddCustomField(ctx, projectId, field); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error adding custom field to project", http.StatusInternalServerError)
+		p.custLogger.Error(logrus.Fields{
+			"project_id": projectId,
+			"error":      err.Error(),
+		}, "Failed to add custom field to project")
+		return
+	}
+	p.custLogger.Info(logrus.Fields{
+		"project_id": projectId,
+		"field":      field.Name,
+		"type":       field.Type,
+	}, "Custom field added to project")
+
+	rw.WriteHeader(http.StatusCreated)
+	_ = field.ToJSON(rw)
+	span.SetStatus(codes.Ok, "Successfully added custom field to project")
+}
+
+func (p *ProjectsHandler) UpdateProjectCustomField(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.UpdateProjectCustomField")
+	defer span.End()
+	vars := mux.Vars(h)
+	projectId := vars["id"]
+
+	fieldId, err := primitive.ObjectIDFromHex(vars["fieldId"])
+	if err != nil {
+		http.Error(rw, "Invalid field ID", http.StatusBadRequest)
+		return
+	}
+
+	field := &model.CustomField{}
+	if err := field.FromJSON(h.Body); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to decode json", http.StatusBadRequest)
+		return
+	}
+
+	project, ok := p.getManagedProject(rw, h, projectId)
+	if !ok {
+		return
+	}
+	existing := findCustomFieldByID(project.CustomFields, fieldId)
+	if existing == nil {
+		http.Error(rw, "Field not found", http.StatusNotFound)
+		return
+	}
+
+	// values are already stored on tasks in the shape of the original type, so it cannot change
+	if field.Type == "" {
+		field.Type = existing.Type
+	}
+	if field.Type != existing.Type {
+		http.Error(rw, "Field type cannot be changed", http.StatusBadRequest)
+		return
+	}
+	if err := field.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	field.ID = fieldId
+
+	if other := findCustomFieldByName(project.CustomFields, field.Name); other != nil && other.ID != fieldId {
+		span.SetStatus(codes.Error, "Custom field already exists")
+		http.Error(rw, "Field with that name already exists in the project", http.StatusConflict)
+		return
+	}
+
+	if err := p.repo.UpdateCustomField(ctx, projectId, field); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error updating custom field", http.StatusInternalServerError)
+		return
+	}
+
+	// tasks holding an enum value that is no longer offered have to drop it
+	if field.Type == model.CustomFieldEnum && optionsRemoved(existing.Options, field.Options) {
+		message := struct {
+			ProjectID string   `json:"projectId"`
+			FieldID   string   `json:"fieldId"`
+			Options   []string `json:"options"`
+		}{
+			ProjectID: projectId,
+			FieldID:   fieldId.Hex(),
+			Options:   field.Options,
+		}
+		if err := p.sendNotification(ctx, "project.field.options.changed", message); err != nil {
+			span.RecordError(err)
+			p.logger.Println("Error publishing custom field options change:", err)
+		}
+	}
+
+	_ = field.ToJSON(rw)
+	span.SetStatus(codes.Ok, "Successfully updated custom field")
+}
+
+func (p *ProjectsHandler) DeleteProjectCustomField(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.DeleteProjectCustomField")
+	defer span.End()
+	vars := mux.Vars(h)
+	projectId := vars["id"]
+
+	fieldId, err := primitive.ObjectIDFromHex(vars["fieldId"])
+	if err != nil {
+		http.Error(rw, "Invalid field ID", http.StatusBadRequest)
+		return
+	}
+
+	project, ok := p.getManagedProject(rw, h, projectId)
+	if !ok {
+		return
+	}
+	if findCustomFieldByID(project.CustomFields, fieldId) == nil {
+		http.Error(rw, "Field not found", http.StatusNotFound)
+		return
+	}
+
+	if err := p.repo.RemoveCustomField(ctx, projectId, fieldId); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error removing custom field", http.StatusInternalServerError)
+		return
+	}
+
+	message := struct {
+		ProjectID string `json:"projectId"`
+		FieldID   string `json:"fieldId"`
+	}{
+		ProjectID: projectId,
+		FieldID:   fieldId.Hex(),
+	}
+	if err := p.sendNotification(ctx, "project.field.deleted", message); err != nil {
+		span.RecordError(err)
+		p.logger.Println("Error publishing custom field deletion:", err)
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+	span.SetStatus(codes.Ok, "Successfully removed custom field")
+}
+
+func findCustomFieldByName(fields []model.CustomField, name string) *model.CustomField {
+	for i := range fields {
+		if strings.EqualFold(fields[i].Name, name) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+func findCustomFieldByID(fields []model.CustomField, id primitive.ObjectID) *model.CustomField {
+	for i := range fields {
+		if fields[i].ID == id {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+func optionsRemoved(before, after []string) bool {
+	for _, option := range before {
+		if !contains(after, option) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"project-service/archive"
+	"project-service/client"
+	"project-service/model"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// exportTimeout replaces the short server timeouts, the archive carries every document file
+const exportTimeout = 10 * time.Minute
+
+var exportFileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ExportProject streams the project as a zip archive in the format of the archive package.
+// Everything except the document files is collected before the first byte is written, so a
+// failing service still ends in an error status; a file that cannot be read is left out and
+// named in the manifest warnings.
+func (p *ProjectsHandler) ExportProject(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.ExportProject")
+	defer span.End()
+
+	userId, ok := h.Context().Value(KeyUser{}).(string)
+	if !ok || userId == "" {
+		span.RecordError(errors.New("User not found in context"))
+		span.SetStatus(codes.Error, "User not found in context")
+		http.Error(rw, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+		return
+	}
+
+	projectId := mux.Vars(h)["id"]
+	project, err := p.repo.GetById(ctx, projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error retrieving project", http.StatusInternalServerError)
+		return
+	}
+	if project == nil || project.PendingDeletion {
+		http.Error(rw, "Project not found", http.StatusNotFound)
+		return
+	}
+	if project.Manager != userId && !contains(project.UserIDs, userId) {
+		http.Error(rw, "You are not part of this project", http.StatusForbidden)
+		return
+	}
+
+	controller := http.NewResponseController(rw)
+	if err := controller.SetWriteDeadline(time.Now().Add(exportTimeout)); err != nil {
+		p.logger.Println("Error extending write deadline:", err)
+	}
+
+	members, err := p.exportMembers(project.Manager, project.UserIDs, cookie)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error retrieving project members", http.StatusBadGateway)
+		return
+	}
+	taskData, err := p.taskClient.GetProjectExport(ctx, projectId, cookie)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error retrieving tasks", http.StatusBadGateway)
+		return
+	}
+	edges, err := p.workflowClient.GetDependencies(ctx, projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error retrieving task dependencies", http.StatusBadGateway)
+		return
+	}
+	events, err := p.analyticClient.GetEvents(ctx, projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error retrieving project events", http.StatusBadGateway)
+		return
+	}
+
+	dependencies := make([]archive.Dependency, 0, len(edges))
+	for _, edge := range edges {
+		dependencies = append(dependencies, archive.Dependency{From: edge.From, To: edge.To})
+	}
+	manifest := archive.Manifest{
+		Format:        archive.Format,
+		SchemaVersion: archive.SchemaVersion,
+		ProjectID:     projectId,
+		ExportedAt:    time.Now().UTC(),
+		ExportedBy:    userId,
+		Counts: map[string]int{
+			"members":      len(members),
+			"tasks":        len(taskData.Tasks),
+			"dependencies": len(dependencies),
+			"comments":     len(taskData.Comments),
+			"documents":    len(taskData.Documents),
+			"events":       len(events),
+		},
+		Warnings: []string{},
+	}
+
+	fileName := exportFileNameUnsafe.ReplaceAllString(project.Name, "-")
+	if fileName == "" || fileName == "-" {
+		fileName = projectId
+	}
+	rw.Header().Set("Content-Type", "application/zip")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".zip"))
+	rw.WriteHeader(http.StatusOK)
+
+	writer := archive.NewWriter(rw)
+	err = p.writeExport(ctx, writer, exportContent{
+		project:      project,
+		members:      members,
+		tasks:        taskData,
+		dependencies: dependencies,
+		events:       events,
+		manifest:     &manifest,
+	}, cookie)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// the status is already sent, the client is left with a truncated archive
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Printf("Error writing export of project %s: %v", projectId, err)
+		return
+	}
+
+	p.custLogger.Info(logrus.Fields{
+		"project_id":  projectId,
+		"exported_by": userId,
+		"tasks":       len(taskData.Tasks),
+		"documents":   len(taskData.Documents),
+		"warnings":    len(manifest.Warnings),
+	}, "Project exported")
+	span.SetStatus(codes.Ok, "Successfully exported project")
+}
+
+type exportContent struct {
+	project      *model.Project
+	members      []archive.Member
+	tasks        *client.ProjectExport
+	dependencies []archive.Dependency
+	events       []json.RawMessage
+	manifest     *archive.Manifest
+}
+
+func (p *ProjectsHandler) writeExport(ctx context.Context, writer *archive.Writer, content exportContent, cookie *http.Cookie) error {
+	schema, err := writer.Create(archive.SchemaEntry, content.manifest.ExportedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(schema, archive.SchemaDoc); err != nil {
+		return fmt.Errorf("failed to write %s: %v", archive.SchemaEntry, err)
+	}
+
+	entries := []struct {
+		name  string
+		value interface{}
+	}{
+		{archive.ProjectEntry, content.project},
+		{archive.MembersEntry, content.members},
+		{archive.TasksEntry, content.tasks.Tasks},
+		{archive.DependenciesEntry, content.dependencies},
+		{archive.CommentsEntry, content.tasks.Comments},
+		{archive.EventsEntry, content.events},
+	}
+	for _, entry := range entries {
+		if err := writer.WriteJSON(entry.name, entry.value); err != nil {
+			return err
+		}
+	}
+
+	documents := make([]archive.Document, 0, len(content.tasks.Documents))
+	for _, doc := range content.tasks.Documents {
+		document := archive.Document{
+			ID:         doc.ID,
+			TaskID:     doc.TaskID,
+			FileName:   doc.FileName,
+			FileType:   doc.FileType,
+			UploadedAt: doc.UploadedAt,
+		}
+		if err := p.exportDocumentFile(ctx, writer, &document, cookie); err != nil {
+			var downloadErr *documentDownloadError
+			if !errors.As(err, &downloadErr) {
+				return err
+			}
+			p.logger.Printf("Error exporting document %s: %v", doc.ID, err)
+			content.manifest.Warnings = append(content.manifest.Warnings, fmt.Sprintf("the file of document %s (%s) could not be exported: %v", doc.ID, doc.FileName, err))
+		}
+		documents = append(documents, document)
+	}
+	if err := writer.WriteJSON(archive.DocumentsEntry, documents); err != nil {
+		return err
+	}
+	return writer.WriteJSON(archive.ManifestEntry, content.manifest)
+}
+
+// documentDownloadError is a document file that could not be fetched from task-service, the
+// export goes on without it
+type documentDownloadError struct {
+	err error
+}
+
+func (e *documentDownloadError) Error() string {
+	return e.err.Error()
+}
+
+// exportDocumentFile downloads the file of a document to a temporary file first, so that a
+// download failing halfway does not leave a truncated entry in the archive
+func (p *ProjectsHandler) exportDocumentFile(ctx context.Context, writer *archive.Writer, document *archive.Document, cookie *http.Cookie) error {
+	tmp, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := p.taskClient.DownloadDocument(ctx, document.ID, cookie, io.MultiWriter(tmp, hash))
+	if err != nil {
+		return &documentDownloadError{err: err}
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read temporary file: %v", err)
+	}
+
+	path := archive.FilePath(document.ID, document.FileName)
+	entry, err := writer.Create(path, document.UploadedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(entry, tmp); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	document.Path = path
+	document.Size = size
+	document.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// exportMembers lists the manager and the members of a project with their user details
+func (p *ProjectsHandler) exportMembers(managerId string, userIds []string, cookie *http.Cookie) ([]archive.Member, error) {
+	ids := []string{managerId}
+	for _, id := range userIds {
+		if !contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	users, err := p.userClient.GetByIdsWithCookies(ids, cookie)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]archive.Member, 0, len(users))
+	for _, user := range users {
+		member := archive.Member{
+			ID:        user.ID.Hex(),
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Role:      "member",
+		}
+		if member.ID == managerId {
+			member.Role = "manager"
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"project-service/client"
+	"project-service/importer"
+	"project-service/model"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	// maxImportSize leaves room for project archives, which carry the document files
+	maxImportSize = 64 << 20
+	// importTimeout replaces the short server timeouts, an import creates every task and
+	// uploads every document through task-service
+	importTimeout = 5 * time.Minute
+)
+
+type importRequest struct {
+	Format string `json:"format"`
+	// Data is the export itself, a project archive is base64 encoded
+	Data    string `json:"data"`
+	DryRun  bool   `json:"dryRun"`
+	Project struct {
+		Name       string `json:"name"`
+		EndDate    string `json:"end_date"`
+		MinMembers string `json:"min_members"`
+		MaxMembers string `json:"max_members"`
+	} `json:"project"`
+	importer.Options
+}
+
+type importResult struct {
+	ProjectID   string          `json:"projectId,omitempty"`
+	DryRun      bool            `json:"dryRun"`
+	Report      importer.Report `json:"report"`
+	FailedTasks []string        `json:"failedTasks,omitempty"`
+	// FailedDependencies names the dependencies as "task -> dependency"
+	FailedDependencies []string `json:"failedDependencies,omitempty"`
+	// FailedComments counts the comments that could not be restored, FailedDocuments names
+	// the files that could not be uploaded
+	FailedComments  int      `json:"failedComments,omitempty"`
+	FailedDocuments []string `json:"failedDocuments,omitempty"`
+}
+
+// ImportProject creates a project from a Trello board export, a CSV file or a project archive
+// written by ExportProject. With dryRun set
+// nothing is created and only the report of what would be imported is returned, including the
+// users and fields that could not be mapped.
+func (p *ProjectsHandler) ImportProject(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.ImportProject")
+	defer span.End()
+
+	userId, ok := h.Context().Value(KeyUser{}).(string)
+	if !ok || userId == "" {
+		span.RecordError(errors.New("User not found in context"))
+		span.SetStatus(codes.Error, "User not found in context")
+		http.Error(rw, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+		return
+	}
+
+	controller := http.NewResponseController(rw)
+	deadline := time.Now().Add(importTimeout)
+	if err := controller.SetReadDeadline(deadline); err != nil {
+		p.logger.Println("Error extending read deadline:", err)
+	}
+	if err := controller.SetWriteDeadline(deadline); err != nil {
+		p.logger.Println("Error extending write deadline:", err)
+	}
+
+	var request importRequest
+	if err := json.NewDecoder(http.MaxBytesReader(rw, h.Body, maxImportSize)).Decode(&request); err != nil {
+		http.Error(rw, "Invalid import request", http.StatusBadRequest)
+		return
+	}
+
+	var board *importer.Board
+	switch strings.ToLower(request.Format) {
+	case "trello":
+		board, err = importer.ParseTrello(strings.NewReader(request.Data))
+	case "csv":
+		board, err = importer.ParseCSV(strings.NewReader(request.Data))
+	case "archive":
+		var data []byte
+		data, err = base64.StdEncoding.DecodeString(request.Data)
+		if err != nil {
+			err = errors.New("invalid project archive: data is not base64 encoded")
+			break
+		}
+		board, err = importer.ParseArchive(data)
+	default:
+		err = fmt.Errorf("unknown import format %q, use trello, csv or archive", request.Format)
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	members, err := p.userClient.GetMembers(ctx, cookie)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error retrieving users", http.StatusBadGateway)
+		return
+	}
+	users := map[string]string{}
+	for _, member := range members {
+		users[strings.ToLower(member.Email)] = member.ID.Hex()
+	}
+
+	plan, err := importer.BuildPlan(board, request.Options, users)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	project, err := importedProject(request, board, plan, userId)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := importResult{DryRun: request.DryRun, Report: plan.Report}
+	if request.DryRun {
+		if err := json.NewEncoder(rw).Encode(result); err != nil {
+			span.RecordError(err)
+			p.logger.Println("Error encoding import report:", err)
+		}
+		span.SetStatus(codes.Ok, "Successfully planned import")
+		return
+	}
+
+	if err := p.repo.Insert(ctx, project); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Database error", http.StatusInternalServerError)
+		return
+	}
+	projectId := project.ID.Hex()
+	result.ProjectID = projectId
+	if err := p.sendNotification(ctx, "project.created", map[string]string{
+		"projectId": projectId,
+		"name":      project.Name,
+	}); err != nil {
+		p.logger.Println("Failed to publish project.created:", err)
+	}
+
+	if len(plan.MemberIDs) > 0 {
+		if err := p.repo.AddUsersToProject(ctx, projectId, plan.MemberIDs); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			http.Error(rw, "Error adding users to project", http.StatusInternalServerError)
+			return
+		}
+		for _, memberId := range plan.MemberIDs {
+			if err := p.sendNotification(ctx, "project.joined", map[string]interface{}{
+				"userId":      memberId,
+				"projectName": project.Name,
+				"watcherIds":  []string{},
+			}); err != nil {
+				p.logger.Println("Failed to publish project.joined:", err)
+			}
+		}
+	}
+
+	// the fields of the project were created with new IDs in the order of the plan
+	fieldIds := map[string]string{}
+	for i, field := range plan.CustomFields {
+		fieldIds[field.Ref] = project.CustomFields[i].ID.Hex()
+	}
+
+	// tasks are created one by one through task-service, the ones that fail are reported
+	// instead of undoing the whole import
+	created := map[string]string{}
+	for _, planned := range plan.Tasks {
+		task := &client.NewTask{
+			ProjectID:   projectId,
+			Name:        planned.Name,
+			Description: planned.Description,
+			Status:      client.TaskStatus(planned.Status),
+			UserIDs:     planned.UserIDs,
+			Labels:      planned.Labels,
+			DueDate:     planned.Due,
+		}
+		for _, item := range planned.Checklist {
+			task.Checklist = append(task.Checklist, client.ChecklistItem{Name: item.Name, Done: item.Done})
+		}
+		for ref, value := range planned.CustomFields {
+			if task.CustomFields == nil {
+				task.CustomFields = map[string]interface{}{}
+			}
+			task.CustomFields[fieldIds[ref]] = value
+		}
+		taskId, err := p.taskClient.CreateTask(ctx, task, cookie)
+		if err != nil {
+			span.RecordError(err)
+			p.logger.Printf("Failed to import task %q into project %s: %v", planned.Name, projectId, err)
+			result.FailedTasks = append(result.FailedTasks, planned.Name)
+			continue
+		}
+		if planned.Ref != "" {
+			created[planned.Ref] = taskId
+		}
+	}
+
+	// dependencies go through workflow-service once every task exists
+	names := map[string]string{}
+	for _, planned := range plan.Tasks {
+		names[planned.Ref] = planned.Name
+	}
+	for _, planned := range plan.Tasks {
+		for _, ref := range planned.DependsOn {
+			taskId, taskCreated := created[planned.Ref]
+			dependencyId, dependencyCreated := created[ref]
+			if taskCreated && dependencyCreated {
+				err = p.workflowClient.AddDependency(ctx, taskId, dependencyId)
+			} else {
+				err = errors.New("task was not created")
+			}
+			if err != nil {
+				span.RecordError(err)
+				p.logger.Printf("Failed to import dependency of task %q on %q: %v", planned.Name, names[ref], err)
+				result.FailedDependencies = append(result.FailedDependencies, planned.Name+" -> "+names[ref])
+			}
+		}
+	}
+
+	// comments and documents of a project archive go onto the created tasks last
+	comments := []client.ImportedComment{}
+	for _, planned := range plan.Comments {
+		taskId, taskCreated := created[planned.TaskRef]
+		if !taskCreated {
+			result.FailedComments++
+			continue
+		}
+		comment := client.ImportedComment{
+			ID:        planned.Ref,
+			TaskID:    taskId,
+			ParentID:  planned.ParentRef,
+			AuthorID:  cmp.Or(planned.AuthorID, userId),
+			Body:      planned.Body,
+			Mentions:  planned.Mentions,
+			CreatedAt: planned.CreatedAt,
+			EditedAt:  planned.EditedAt,
+		}
+		for _, revision := range planned.History {
+			comment.History = append(comment.History, client.CommentRevision{Body: revision.Body, EditedAt: revision.EditedAt})
+		}
+		comments = append(comments, comment)
+	}
+	if len(comments) > 0 {
+		if err := p.taskClient.ImportComments(ctx, projectId, comments, cookie); err != nil {
+			span.RecordError(err)
+			p.logger.Printf("Failed to import comments into project %s: %v", projectId, err)
+			result.FailedComments += len(comments)
+		}
+	}
+	for _, document := range plan.Documents {
+		taskId, taskCreated := created[document.CardRef]
+		if taskCreated {
+			err = p.taskClient.UploadDocument(ctx, taskId, document.FileName, document.Data, cookie)
+		} else {
+			err = errors.New("task was not created")
+		}
+		if err != nil {
+			span.RecordError(err)
+			p.logger.Printf("Failed to import document %q into project %s: %v", document.FileName, projectId, err)
+			result.FailedDocuments = append(result.FailedDocuments, document.FileName)
+		}
+	}
+
+	p.custLogger.Info(logrus.Fields{
+		"project_id":       projectId,
+		"imported_by":      userId,
+		"tasks":            len(plan.Tasks) - len(result.FailedTasks),
+		"failed_tasks":     len(result.FailedTasks),
+		"failed_deps":      len(result.FailedDependencies),
+		"failed_comments":  result.FailedComments,
+		"failed_documents": len(result.FailedDocuments),
+	}, "Project imported")
+
+	rw.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		span.RecordError(err)
+		p.logger.Println("Error encoding import result:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully imported project")
+}
+
+// importedProject fills in the project of an import. Settings missing from the request are
+// taken from the board: the settings of an exported project, otherwise its name, the last due
+// date as the end date and room for every member.
+func importedProject(request importRequest, board *importer.Board, plan *importer.Plan, managerId string) (*model.Project, error) {
+	project := &model.Project{
+		Name:       strings.TrimSpace(request.Project.Name),
+		EndDate:    cmp.Or(request.Project.EndDate, board.Project.EndDate),
+		MinMembers: cmp.Or(request.Project.MinMembers, board.Project.MinMembers),
+		MaxMembers: cmp.Or(request.Project.MaxMembers, board.Project.MaxMembers),
+		Manager:    managerId,
+	}
+	if project.Name == "" {
+		project.Name = board.Name
+	}
+	if project.Name == "" {
+		return nil, errors.New("project name is required")
+	}
+	if project.EndDate == "" {
+		var last *time.Time
+		for _, task := range plan.Tasks {
+			if task.Due != nil && (last == nil || task.Due.After(*last)) {
+				last = task.Due
+			}
+		}
+		if last != nil {
+			project.EndDate = last.Format(time.RFC3339)
+		}
+	}
+	if project.MinMembers == "" {
+		project.MinMembers = "1"
+	}
+	if project.MaxMembers == "" {
+		project.MaxMembers = strconv.Itoa(max(len(plan.MemberIDs), 1))
+	}
+	minMembers, err := strconv.Atoi(project.MinMembers)
+	if err != nil || minMembers < 0 {
+		return nil, errors.New("invalid minimum members value")
+	}
+	maxMembers, err := strconv.Atoi(project.MaxMembers)
+	if err != nil || maxMembers < minMembers {
+		return nil, errors.New("invalid maximum members value")
+	}
+	if len(plan.MemberIDs) > maxMembers {
+		return nil, fmt.Errorf("the board has %d members but the project allows %d", len(plan.MemberIDs), maxMembers)
+	}
+
+	for _, label := range plan.Labels {
+		projectLabel := model.Label{ID: primitive.NewObjectID(), Name: label.Name, Color: label.Color}
+		if err := projectLabel.Validate(); err != nil {
+			return nil, fmt.Errorf("label %q: %v", label.Name, err)
+		}
+		project.Labels = append(project.Labels, projectLabel)
+	}
+	for _, field := range plan.CustomFields {
+		projectField := model.CustomField{
+			ID:       primitive.NewObjectID(),
+			Name:     field.Name,
+			Type:     model.CustomFieldType(field.Type),
+			Options:  field.Options,
+			Required: field.Required,
+		}
+		if err := projectField.Validate(); err != nil {
+			return nil, fmt.Errorf("custom field %q: %v", field.Name, err)
+		}
+		project.CustomFields = append(project.CustomFields, projectField)
+	}
+	return project, nil
+}
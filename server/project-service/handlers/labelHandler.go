@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-service/model"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func (p *ProjectsHandler) GetProjectLabels(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.GetProjectLabels")
+	defer span.End()
+	projectId := mux.Vars(h)["id"]
+
+	project, err := p.repo.GetById(ctx, projectId)
+	if err != nil || project == nil {
+		span.SetStatus(codes.Error, "Project not found")
+		http.Error(rw, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	userId, _ := h.Context().Value(KeyUser{}).(string)
+	if project.Manager != userId && !contains(project.UserIDs, userId) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	labels := model.Labels{}
+	for i := range project.Labels {
+		labels = append(labels, &project.Labels[i])
+	}
+	if err := labels.ToJSON(rw); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to convert to json", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully retrieved project labels")
+}
+
+func (p *ProjectsHandler) PostProjectLabel(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.PostProjectLabel")
+	defer span.End()
+	projectId := mux.Vars(h)["id"]
+
+	label := &model.Label{}
+	if err := label.FromJSON(h.Body); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to decode json", http.StatusBadRequest)
+		return
+	}
+	if err := label.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	project, ok := p.getManagedProject(rw, h, projectId)
+	if !ok {
+		return
+	}
+	if findLabelByName(project.Labels, label.Name) != nil {
+		span.SetStatus(codes.Error, "Label already exists")
+		http.Error(rw, "Label with that name already exists in the project", http.StatusConflict)
+		return
+	}
+
+	if err := p.repo.AddLabel(ctx, projectId, label); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error adding label to project", http.StatusInternalServerError)
+		p.custLogger.Error(logrus.Fields{
+			"project_id": projectId,
+			"error":      err.Error(),
+		}, "Failed to add label to project")
+		return
+	}
+	p.custLogger.Info(logrus.Fields{
+		"project_id": projectId,
+		"label":      label.Name,
+	}, "Label added to project")
+
+	rw.WriteHeader(http.StatusCreated)
+	_ = label.ToJSON(rw)
+	span.SetStatus(codes.Ok, "Successfully added label to project")
+}
+
+func (p *ProjectsHandler) UpdateProjectLabel(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.UpdateProjectLabel")
+	defer span.End()
+	vars := mux.Vars(h)
+	projectId := vars["id"]
+
+	labelId, err := primitive.ObjectIDFromHex(vars["labelId"])
+	if err != nil {
+		http.Error(rw, "Invalid label ID", http.StatusBadRequest)
+		return
+	}
+
+	label := &model.Label{}
+	if err := label.FromJSON(h.Body); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to decode json", http.StatusBadRequest)
+		return
+	}
+	if err := label.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	label.ID = labelId
+
+	project, ok := p.getManagedProject(rw, h, projectId)
+	if !ok {
+		return
+	}
+	existing := findLabelByID(project.Labels, labelId)
+	if existing == nil {
+		http.Error(rw, "Label not found", http.StatusNotFound)
+		return
+	}
+	if other := findLabelByName(project.Labels, label.Name); other != nil && other.ID != labelId {
+		span.SetStatus(codes.Error, "Label already exists")
+		http.Error(rw, "Label with that name already exists in the project", http.StatusConflict)
+		return
+	}
+	oldName := existing.Name
+
+	if err := p.repo.UpdateLabel(ctx, projectId, label); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error updating label", http.StatusInternalServerError)
+		return
+	}
+
+	// tasks keep label names, so a rename has to be propagated to task-service
+	if oldName != label.Name {
+		message := struct {
+			ProjectID string `json:"projectId"`
+			OldName   string `json:"oldName"`
+			NewName   string `json:"newName"`
+		}{
+			ProjectID: projectId,
+			OldName:   oldName,
+			NewName:   label.Name,
+		}
+		if err := p.sendNotification(ctx, "project.label.renamed", message); err != nil {
+			span.RecordError(err)
+			p.logger.Println("Error publishing label rename:", err)
+		}
+	}
+
+	_ = label.ToJSON(rw)
+	span.SetStatus(codes.Ok, "Successfully updated label")
+}
+
+func (p *ProjectsHandler) DeleteProjectLabel(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.DeleteProjectLabel")
+	defer span.End()
+	vars := mux.Vars(h)
+	projectId := vars["id"]
+
+	labelId, err := primitive.ObjectIDFromHex(vars["labelId"])
+	if err != nil {
+		http.Error(rw, "Invalid label ID", http.StatusBadRequest)
+		return
+	}
+
+	project, ok := p.getManagedProject(rw, h, projectId)
+	if !ok {
+		return
+	}
+	existing := findLabelByID(project.Labels, labelId)
+	if existing == nil {
+		http.Error(rw, "Label not found", http.StatusNotFound)
+		return
+	}
+
+	if err := p.repo.RemoveLabel(ctx, projectId, labelId); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error removing label", http.StatusInternalServerError)
+		return
+	}
+
+	message := struct {
+		ProjectID string `json:"projectId"`
+		Name      string `json:"name"`
+	}{
+		ProjectID: projectId,
+		Name:      existing.Name,
+	}
+	if err := p.sendNotification(ctx, "project.label.deleted", message); err != nil {
+		span.RecordError(err)
+		p.logger.Println("Error publishing label deletion:", err)
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+	span.SetStatus(codes.Ok, "Successfully removed label")
+}
+
+// getManagedProject loads the project and makes sure the caller is its manager, writing the error response otherwise
+func (p *ProjectsHandler) getManagedProject(rw http.ResponseWriter, h *http.Request, projectId string) (*model.Project, bool) {
+	project, err := p.repo.GetById(h.Context(), projectId)
+	if err != nil || project == nil {
+		http.Error(rw, "Project not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	userId, ok := h.Context().Value(KeyUser{}).(string)
+	if !ok {
+		p.logger.Println(errors.New("user ID not found in context"))
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return nil, false
+	}
+	if project.Manager != userId {
+		http.Error(rw, "Only the project manager can change the project", http.StatusForbidden)
+		return nil, false
+	}
+	return project, true
+}
+
+func findLabelByName(labels []model.Label, name string) *model.Label {
+	for i := range labels {
+		if strings.EqualFold(labels[i].Name, name) {
+			return &labels[i]
+		}
+	}
+	return nil
+}
+
+func findLabelByID(labels []model.Label, id primitive.ObjectID) *model.Label {
+	for i := range labels {
+		if labels[i].ID == id {
+			return &labels[i]
+		}
+	}
+	return nil
+}
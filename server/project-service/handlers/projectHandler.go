@@ -37,12 +37,14 @@ type KeyRole struct{}
 var pendingProjectDeletion = make(map[string]map[string]bool)
 
 type ProjectsHandler struct {
-	logger     *log.Logger
-	custLogger *customLogger.Logger
-	repo       *repositories.ProjectRepo
-	tracer     trace.Tracer
-	userClient client.UserClient
-	taskClient client.TaskClient
+	logger         *log.Logger
+	custLogger     *customLogger.Logger
+	repo           *repositories.ProjectRepo
+	tracer         trace.Tracer
+	userClient     client.UserClient
+	taskClient     client.TaskClient
+	workflowClient client.WorkflowClient
+	analyticClient client.AnalyticClient
 }
 
 type Task struct {
@@ -235,8 +237,8 @@ func createTLSClient() (*http.Client, error) {
 	return c, nil
 }
 
-func NewProjectsHandler(l *log.Logger, custLogger *customLogger.Logger, r *repositories.ProjectRepo, tracer trace.Tracer, userClient client.UserClient, taskClient client.TaskClient) *ProjectsHandler {
-	return &ProjectsHandler{logger: l, custLogger: custLogger, repo: r, tracer: tracer, userClient: userClient, taskClient: taskClient}
+func NewProjectsHandler(l *log.Logger, custLogger *customLogger.Logger, r *repositories.ProjectRepo, tracer trace.Tracer, userClient client.UserClient, taskClient client.TaskClient, workflowClient client.WorkflowClient, analyticClient client.AnalyticClient) *ProjectsHandler {
+	return &ProjectsHandler{logger: l, custLogger: custLogger, repo: r, tracer: tracer, userClient: userClient, taskClient: taskClient, workflowClient: workflowClient, analyticClient: analyticClient}
 }
 
 func (p *ProjectsHandler) GetAllProjects(rw http.ResponseWriter, h *http.Request) {
@@ -472,6 +474,14 @@ func (p *ProjectsHandler) PostProject(rw http.ResponseWriter, h *http.Request) {
 		return
 	}
 
+	message := map[string]string{
+		"projectId": project.ID.Hex(),
+		"name":      project.Name,
+	}
+	if err := p.sendNotification(ctx, "project.created", message); err != nil {
+		p.logger.Println("Failed to publish project.created:", err)
+	}
+
 	// Respond with success
 	rw.WriteHeader(http.StatusCreated)
 	span.SetStatus(codes.Ok, "Successfully created project")
@@ -654,11 +664,13 @@ func (p *ProjectsHandler) AddUsersToProject(rw http.ResponseWriter, h *http.Requ
 	for _, uid := range userIds {
 		subject := "project.joined"
 		message := struct {
-			UserID      string `json:"userId"`
-			ProjectName string `json:"projectName"`
+			UserID      string   `json:"userId"`
+			ProjectName string   `json:"projectName"`
+			WatcherIDs  []string `json:"watcherIds"`
 		}{
 			UserID:      uid,
 			ProjectName: project.Name,
+			WatcherIDs:  project.Watchers,
 		}
 
 		if err := p.sendNotification(ctx, subject, message); err != nil {
@@ -778,13 +790,18 @@ func (p *ProjectsHandler) RemoveUserFromProject(rw http.ResponseWriter, h *http.
 		return
 	}
 
+	// task-service takes the user off the watchers of the project's tasks on this message
 	subject := "project.removed"
 	message := struct {
-		UserID      string `json:"userId"`
-		ProjectName string `json:"projectName"`
+		UserID      string   `json:"userId"`
+		ProjectID   string   `json:"projectId"`
+		ProjectName string   `json:"projectName"`
+		WatcherIDs  []string `json:"watcherIds"`
 	}{
 		UserID:      userId,
+		ProjectID:   projectId,
 		ProjectName: project.Name,
+		WatcherIDs:  project.Watchers,
 	}
 
 	if err := p.sendNotification(ctx, subject, message); err != nil {
@@ -907,8 +924,8 @@ func (p *ProjectsHandler) DeleteProject(rw http.ResponseWriter, h *http.Request)
 		"project_id": projectId,
 	}, "Deleting project")
 
-	err = p.repo.PendingDeletion(ctx, projectId, true)
-	//err = p.repo.DeleteProject(projectId)
+	userId, _ := h.Context().Value(KeyUser{}).(string)
+	err = p.repo.MarkDeleted(ctx, projectId, userId)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -960,11 +977,11 @@ func (p *ProjectsHandler) SubscribeToEvent(ctx context.Context) {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		log.Println("Error connecting to NATS:", err)
-		p.logger.Printf("Error connecting to NATS: ", err)
+		p.logger.Printf("Error connecting to NATS: %v", err)
 
 		return
 	}
-	defer nc.Close()
+	// the connection stays open for the subscriptions while the service runs
 
 	_, err = nc.QueueSubscribe("TasksDeleted", "tasks-deleted-queue", func(msg *nats.Msg) {
 		projectID := string(msg.Data)
@@ -1009,14 +1026,36 @@ func (p *ProjectsHandler) SubscribeToEvent(ctx context.Context) {
 		}
 
 	})
+
+	_, err = nc.QueueSubscribe("TasksRestored", "project-queue", func(msg *nats.Msg) {
+		p.HandleTasksRestored(context.Background(), string(msg.Data))
+	})
+	if err != nil {
+		span.RecordError(err)
+		p.logger.Printf("Failed to subscribe to TasksRestored event: %v", err)
+	}
+	_, err = nc.QueueSubscribe("TasksPurged", "project-queue", func(msg *nats.Msg) {
+		p.HandlePurgeConfirmed(context.Background(), string(msg.Data), "tasks")
+	})
+	if err != nil {
+		span.RecordError(err)
+		p.logger.Printf("Failed to subscribe to TasksPurged event: %v", err)
+	}
+	_, err = nc.QueueSubscribe("WorkflowsPurged", "project-queue", func(msg *nats.Msg) {
+		p.HandlePurgeConfirmed(context.Background(), string(msg.Data), "workflows")
+	})
+	if err != nil {
+		span.RecordError(err)
+		p.logger.Printf("Failed to subscribe to WorkflowsPurged event: %v", err)
+	}
 }
 
-// emit message to finally phisically delete workflows and tasks
+// emit message once the project is in the trash, its tasks and workflows stay until the project is purged
 func (p *ProjectsHandler) EmitSuccessMessage(projectID string) {
 	nc, err := Conn()
 	if err != nil {
 		log.Println("Error connecting to NATS:", err)
-		p.logger.Printf("Error connecting to NATS: ", err)
+		p.logger.Printf("Error connecting to NATS: %v", err)
 
 		return
 	}
@@ -1074,7 +1113,9 @@ func (p *ProjectsHandler) HandleTasksDeleted(ctx context.Context, projectID stri
 	p.logger.Println("a message has been sent")
 	//>>>>>>> b38a495c0faab5935c6effddd29991a392a36c70
 
-	err := p.repo.DeleteProject(ctx, projectID)
+	// the project stays in the trash until RunTrashPurge removes it
+	delete(pendingProjectDeletion, projectID)
+	err := p.repo.MoveToTrash(ctx, projectID)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -1090,7 +1131,8 @@ func (p *ProjectsHandler) HandleTasksDeleted(ctx context.Context, projectID stri
 func (p *ProjectsHandler) HandleTasksDeletedRollback(ctx context.Context, projectID string) {
 	ctx, span := p.tracer.Start(ctx, "ProjectsHandler.HandleTasksDeletedRollback")
 	defer span.End()
-	err := p.repo.PendingDeletion(ctx, projectID, false)
+	delete(pendingProjectDeletion, projectID)
+	err := p.repo.RestoreFromTrash(ctx, projectID)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -1374,6 +1416,29 @@ func (p *ProjectsHandler) CheckIfUserIsManager(rw http.ResponseWriter, h *http.R
 	span.SetStatus(codes.Ok, "Successful function")
 }
 
+// GetProjectManagerID returns the manager of a project, task-service escalates overdue tasks
+// to them
+func (p *ProjectsHandler) GetProjectManagerID(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.GetProjectManagerID")
+	defer span.End()
+	projectId := mux.Vars(h)["id"]
+
+	project, err := p.repo.GetById(ctx, projectId)
+	if err != nil || project == nil {
+		span.SetStatus(codes.Error, "Project not found")
+		http.Error(rw, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	if err := json.NewEncoder(rw).Encode(map[string]string{"managerId": project.Manager}); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Println("Error encoding project manager:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully got project manager")
+}
+
 func (p *ProjectsHandler) sendNotification(ctx context.Context, subject string, message interface{}) error {
 	_, span := p.tracer.Start(ctx, "ProjectsHandler.AddUsersToProject")
 	defer span.End()
@@ -1465,15 +1530,17 @@ func (p *ProjectsHandler) GetProjectDetailsById(rw http.ResponseWriter, h *http.
 
 	// Step 6: Construct the response with project details, user details, and tasks
 	projectDetails := client.ProjectDetails{
-		ID:         project.ID,
-		Name:       project.Name,
-		EndDate:    project.EndDate,
-		MinMembers: project.MinMembers,
-		MaxMembers: project.MaxMembers,
-		Users:      usersDetails,
-		Tasks:      tasksDetails, // Add the task details to the response
-		UserIDs:    project.UserIDs,
-		Manager:    project.Manager,
+		ID:           project.ID,
+		Name:         project.Name,
+		EndDate:      project.EndDate,
+		MinMembers:   project.MinMembers,
+		MaxMembers:   project.MaxMembers,
+		Users:        usersDetails,
+		Tasks:        tasksDetails, // Add the task details to the response
+		UserIDs:      project.UserIDs,
+		Manager:      project.Manager,
+		Labels:       project.Labels,
+		CustomFields: project.CustomFields,
 	}
 
 	// Step 7: Send the project details with users and tasks as a response
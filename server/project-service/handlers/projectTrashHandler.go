@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"project-service/model"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Purging and restoring a project are sagas like its deletion. A purge publishes ProjectPurged
+// and removes the project once task-service and workflow-service answered with TasksPurged and
+// WorkflowsPurged; until then the hourly sweep publishes it again. A restore publishes
+// ProjectRestored and keeps the project hidden until task-service answers with TasksRestored,
+// it is requested again while no answer comes.
+const (
+	trashPurgeInterval    = time.Hour
+	restoreRetryInterval  = 5 * time.Minute
+	defaultTrashRetention = 30 * 24 * time.Hour
+)
+
+// purgeServices are the services that confirm a purge before the project is removed
+var purgeServices = []string{"tasks", "workflows"}
+
+// trashedProject is a project in the trash together with the moment it is purged
+type trashedProject struct {
+	*model.Project
+	RestorableUntil time.Time `json:"restorable_until"`
+}
+
+// trashRetention is how long deleted projects stay restorable, set in days by TRASH_RETENTION_DAYS
+func trashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		return defaultTrashRetention
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetProjectTrash lists the deleted projects of the manager that can still be restored
+func (p *ProjectsHandler) GetProjectTrash(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.GetProjectTrash")
+	defer span.End()
+
+	userId, ok := h.Context().Value(KeyUser{}).(string)
+	if !ok || userId == "" {
+		span.RecordError(errors.New("User not found in context"))
+		span.SetStatus(codes.Error, "User not found in context")
+		http.Error(rw, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	projects, err := p.repo.GetTrashByManager(ctx, userId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error retrieving deleted projects", http.StatusInternalServerError)
+		return
+	}
+	retention := trashRetention()
+	trash := make([]trashedProject, 0, len(projects))
+	for _, project := range projects {
+		if project.DeletedAt == nil {
+			continue
+		}
+		trash = append(trash, trashedProject{Project: project, RestorableUntil: project.DeletedAt.Add(retention)})
+	}
+
+	if err := json.NewEncoder(rw).Encode(trash); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Println("Error encoding deleted projects:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully got deleted projects")
+}
+
+// RestoreProject brings a deleted project back together with its tasks and workflows while it
+// is in the trash. The project leaves the trash right away but only shows up again once
+// task-service confirms with TasksRestored, so the request is answered with 202 Accepted.
+func (p *ProjectsHandler) RestoreProject(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.RestoreProject")
+	defer span.End()
+	projectId := mux.Vars(h)["id"]
+
+	userId, _ := h.Context().Value(KeyUser{}).(string)
+	project, err := p.repo.GetById(ctx, projectId)
+	if err != nil || project == nil || project.DeletedAt == nil {
+		span.SetStatus(codes.Error, "Deleted project not found")
+		http.Error(rw, "Deleted project not found", http.StatusNotFound)
+		return
+	}
+	if project.Manager != userId {
+		span.SetStatus(codes.Error, "User is not the project manager")
+		http.Error(rw, "Only the project manager can restore the project", http.StatusForbidden)
+		return
+	}
+	if !project.Trashed {
+		http.Error(rw, "Project deletion or restore is still in progress", http.StatusConflict)
+		return
+	}
+	if time.Since(*project.DeletedAt) > trashRetention() {
+		http.Error(rw, "Project can no longer be restored", http.StatusGone)
+		return
+	}
+
+	started, err := p.repo.StartRestore(ctx, projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error restoring project", http.StatusInternalServerError)
+		return
+	}
+	if !started {
+		http.Error(rw, "Project deletion or restore is still in progress", http.StatusConflict)
+		return
+	}
+	if err := publishProjectEvent("ProjectRestored", projectId); err != nil {
+		if cancelErr := p.repo.CancelRestore(ctx, projectId); cancelErr != nil {
+			p.logger.Printf("Failed to move project %s back to trash: %v", projectId, cancelErr)
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Printf("Failed to publish ProjectRestored event for project ID %s: %v", projectId, err)
+		http.Error(rw, "Failed to publish event", http.StatusInternalServerError)
+		return
+	}
+
+	p.custLogger.Info(logrus.Fields{
+		"project_id":  projectId,
+		"restored_by": userId,
+	}, "Project restore started")
+	rw.WriteHeader(http.StatusAccepted)
+	span.SetStatus(codes.Ok, "Successfully started project restore")
+}
+
+// HandleTasksRestored finishes a project restore once task-service brought its tasks back
+func (p *ProjectsHandler) HandleTasksRestored(ctx context.Context, projectId string) {
+	ctx, span := p.tracer.Start(ctx, "ProjectsHandler.HandleTasksRestored")
+	defer span.End()
+
+	restored, err := p.repo.FinishRestore(ctx, projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Printf("Failed to restore project %s: %v", projectId, err)
+		return
+	}
+	if !restored {
+		span.SetStatus(codes.Ok, "Project is not being restored")
+		return
+	}
+	project, err := p.repo.GetById(ctx, projectId)
+	if err == nil && project != nil {
+		if err := p.sendNotification(ctx, "project.created", map[string]string{
+			"projectId": projectId,
+			"name":      project.Name,
+		}); err != nil {
+			p.logger.Println("Failed to publish project.created:", err)
+		}
+	}
+
+	p.custLogger.Info(logrus.Fields{"project_id": projectId}, "Project restored")
+	span.SetStatus(codes.Ok, "Successfully restored project")
+}
+
+// HandlePurgeConfirmed records that task-service or workflow-service purged a trashed project
+// and removes the project once both have
+func (p *ProjectsHandler) HandlePurgeConfirmed(ctx context.Context, projectId string, service string) {
+	ctx, span := p.tracer.Start(ctx, "ProjectsHandler.HandlePurgeConfirmed")
+	defer span.End()
+
+	confirmed, err := p.repo.ConfirmPurge(ctx, projectId, service)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Printf("Failed to record purge of project %s by %s: %v", projectId, service, err)
+		return
+	}
+	for _, required := range purgeServices {
+		if !slices.Contains(confirmed, required) {
+			span.SetStatus(codes.Ok, "Waiting for the other services to purge the project")
+			return
+		}
+	}
+	if err := p.repo.DeleteProject(ctx, projectId); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Printf("Failed to purge project %s: %v", projectId, err)
+		return
+	}
+	p.custLogger.Info(logrus.Fields{"project_id": projectId}, "Deleted project purged")
+	span.SetStatus(codes.Ok, "Successfully purged project")
+}
+
+// RunTrashPurge removes the projects that stayed in the trash past the retention window until
+// ctx is done
+func (p *ProjectsHandler) RunTrashPurge(ctx context.Context) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.purgeExpiredTrash(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *ProjectsHandler) purgeExpiredTrash(ctx context.Context) {
+	ctx, span := p.tracer.Start(ctx, "ProjectsHandler.purgeExpiredTrash")
+	defer span.End()
+
+	projects, err := p.repo.GetTrashedBefore(ctx, time.Now().Add(-trashRetention()))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Println("Error fetching expired deleted projects:", err)
+		return
+	}
+	if len(projects) == 0 {
+		span.SetStatus(codes.Ok, "No expired deleted projects")
+		return
+	}
+
+	for _, project := range projects {
+		projectId := project.ID.Hex()
+		if err := publishProjectEvent("ProjectPurged", projectId); err != nil {
+			span.RecordError(err)
+			p.logger.Printf("Failed to publish ProjectPurged event for project ID %s: %v", projectId, err)
+		}
+	}
+	span.SetStatus(codes.Ok, "Successfully requested purge of expired deleted projects")
+}
+
+// RunRestoreRetry asks task-service again for the restores it has not confirmed until ctx is
+// done
+func (p *ProjectsHandler) RunRestoreRetry(ctx context.Context) {
+	ticker := time.NewTicker(restoreRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.retryRestores(ctx)
+	}
+}
+
+func (p *ProjectsHandler) retryRestores(ctx context.Context) {
+	ctx, span := p.tracer.Start(ctx, "ProjectsHandler.retryRestores")
+	defer span.End()
+
+	projects, err := p.repo.GetRestoringBefore(ctx, time.Now().Add(-restoreRetryInterval))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Println("Error fetching projects being restored:", err)
+		return
+	}
+	for _, project := range projects {
+		projectId := project.ID.Hex()
+		if err := publishProjectEvent("ProjectRestored", projectId); err != nil {
+			span.RecordError(err)
+			p.logger.Printf("Failed to publish ProjectRestored event for project ID %s: %v", projectId, err)
+			continue
+		}
+		p.logger.Printf("Restore of project %s requested again", projectId)
+	}
+	span.SetStatus(codes.Ok, "Successfully retried project restores")
+}
+
+// publishProjectEvent publishes a project ID on its own connection, like the other events of
+// this handler
+func publishProjectEvent(subject string, projectId string) error {
+	nc, err := Conn()
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+	if err := nc.Publish(subject, []byte(projectId)); err != nil {
+		return err
+	}
+	return nc.Flush()
+}
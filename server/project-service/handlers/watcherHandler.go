@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// WatchProject subscribes the caller to the changes of every task in the project
+func (p *ProjectsHandler) WatchProject(rw http.ResponseWriter, h *http.Request) {
+	p.setWatching(rw, h, true)
+}
+
+// UnwatchProject stops the notifications WatchProject subscribed the caller to
+func (p *ProjectsHandler) UnwatchProject(rw http.ResponseWriter, h *http.Request) {
+	p.setWatching(rw, h, false)
+}
+
+func (p *ProjectsHandler) setWatching(rw http.ResponseWriter, h *http.Request, watching bool) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.setWatching")
+	defer span.End()
+	projectId := mux.Vars(h)["id"]
+
+	project, err := p.repo.GetById(ctx, projectId)
+	if err != nil || project == nil || project.PendingDeletion {
+		span.SetStatus(codes.Error, "Project not found")
+		http.Error(rw, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	userId, _ := h.Context().Value(KeyUser{}).(string)
+	if project.Manager != userId && !contains(project.UserIDs, userId) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	if err := p.repo.SetWatching(ctx, projectId, userId, watching); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Error updating project watchers", http.StatusInternalServerError)
+		return
+	}
+	p.custLogger.Info(logrus.Fields{
+		"project_id": projectId,
+		"user_id":    userId,
+		"watching":   watching,
+	}, "Project watchers updated")
+
+	rw.WriteHeader(http.StatusNoContent)
+	span.SetStatus(codes.Ok, "Successfully updated project watchers")
+}
+
+// GetProjectWatchers lists the users watching a project, task-service adds them to the
+// recipients of task notifications
+func (p *ProjectsHandler) GetProjectWatchers(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := p.tracer.Start(h.Context(), "ProjectsHandler.GetProjectWatchers")
+	defer span.End()
+	projectId := mux.Vars(h)["id"]
+
+	project, err := p.repo.GetById(ctx, projectId)
+	if err != nil || project == nil {
+		span.SetStatus(codes.Error, "Project not found")
+		http.Error(rw, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	watchers := project.Watchers
+	if watchers == nil {
+		watchers = []string{}
+	}
+	if err := json.NewEncoder(rw).Encode(watchers); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.Println("Error encoding project watchers:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully got project watchers")
+}
@@ -0,0 +1,190 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"project-service/archive"
+	"strings"
+	"time"
+)
+
+type archiveProject struct {
+	Name         string         `json:"name"`
+	EndDate      string         `json:"end_date"`
+	MinMembers   string         `json:"min_members"`
+	MaxMembers   string         `json:"max_members"`
+	Labels       []Label        `json:"labels"`
+	CustomFields []archiveField `json:"custom_fields"`
+}
+
+type archiveField struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Options  []string `json:"options"`
+	Required bool     `json:"required"`
+}
+
+type archiveTask struct {
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	Status       string                 `json:"status"`
+	UserIDs      []string               `json:"user_ids"`
+	Labels       []string               `json:"labels"`
+	DueDate      *time.Time             `json:"dueDate"`
+	Checklist    []ChecklistItem        `json:"checklist"`
+	CustomFields map[string]interface{} `json:"customFields"`
+}
+
+type archiveComment struct {
+	ID        string            `json:"id"`
+	TaskID    string            `json:"taskId"`
+	ParentID  string            `json:"parentId"`
+	AuthorID  string            `json:"authorId"`
+	Body      string            `json:"body"`
+	Mentions  []string          `json:"mentions"`
+	CreatedAt time.Time         `json:"createdAt"`
+	EditedAt  *time.Time        `json:"editedAt"`
+	History   []CommentRevision `json:"history"`
+}
+
+// ParseArchive reads a project archive written by the project export. The IDs of the exported
+// project are only used to connect its parts, users are matched again by email. The analytics
+// events are history of the exported project and are not restored.
+func ParseArchive(data []byte) (*Board, error) {
+	reader, err := archive.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var project archiveProject
+	if err := reader.ReadJSON(archive.ProjectEntry, &project); err != nil {
+		return nil, err
+	}
+	var members []archive.Member
+	if err := reader.ReadJSON(archive.MembersEntry, &members); err != nil {
+		return nil, err
+	}
+	var tasks []archiveTask
+	if err := reader.ReadJSON(archive.TasksEntry, &tasks); err != nil {
+		return nil, err
+	}
+	var dependencies []archive.Dependency
+	if err := reader.ReadJSON(archive.DependenciesEntry, &dependencies); err != nil {
+		return nil, err
+	}
+
+	board := &Board{
+		Name:   project.Name,
+		Labels: project.Labels,
+		Project: ProjectSettings{
+			EndDate:    project.EndDate,
+			MinMembers: project.MinMembers,
+			MaxMembers: project.MaxMembers,
+		},
+	}
+	for _, member := range members {
+		// whoever imports the archive becomes the manager of the new project
+		if member.Role == "manager" {
+			continue
+		}
+		board.Members = append(board.Members, Member{
+			Ref:      member.ID,
+			FullName: strings.TrimSpace(member.FirstName + " " + member.LastName),
+			Email:    member.Email,
+		})
+	}
+
+	dependsOn := map[string][]string{}
+	for _, dependency := range dependencies {
+		dependsOn[dependency.From] = append(dependsOn[dependency.From], dependency.To)
+	}
+	for _, field := range project.CustomFields {
+		board.CustomFields = append(board.CustomFields, CustomField{
+			Ref:      field.ID,
+			Name:     field.Name,
+			Type:     field.Type,
+			Options:  field.Options,
+			Required: field.Required,
+		})
+	}
+	for _, task := range tasks {
+		status, ok := normalizeStatus(task.Status)
+		if !ok {
+			board.Warnings = append(board.Warnings, fmt.Sprintf("task %q has unknown status %q and is imported as %s", task.Name, task.Status, StatusPending))
+			status = StatusPending
+		}
+		board.Cards = append(board.Cards, Card{
+			Ref:          task.ID,
+			Name:         task.Name,
+			Description:  task.Description,
+			Status:       status,
+			Members:      task.UserIDs,
+			Labels:       task.Labels,
+			Due:          task.DueDate,
+			Checklist:    task.Checklist,
+			DependsOn:    dependsOn[task.ID],
+			CustomFields: task.CustomFields,
+		})
+	}
+
+	// archives written before comments or documents were exported have no such entries
+	if reader.Has(archive.CommentsEntry) {
+		var comments []archiveComment
+		if err := reader.ReadJSON(archive.CommentsEntry, &comments); err != nil {
+			return nil, err
+		}
+		for _, comment := range comments {
+			board.Comments = append(board.Comments, Comment{
+				Ref:       comment.ID,
+				CardRef:   comment.TaskID,
+				ParentRef: comment.ParentID,
+				AuthorRef: comment.AuthorID,
+				Body:      comment.Body,
+				Mentions:  comment.Mentions,
+				CreatedAt: comment.CreatedAt,
+				EditedAt:  comment.EditedAt,
+				History:   comment.History,
+			})
+		}
+	}
+	if reader.Has(archive.DocumentsEntry) {
+		var documents []archive.Document
+		if err := reader.ReadJSON(archive.DocumentsEntry, &documents); err != nil {
+			return nil, err
+		}
+		for _, document := range documents {
+			if document.Path == "" {
+				// the export already named the missing file in the manifest warnings
+				continue
+			}
+			data, err := reader.ReadFile(document.Path)
+			if err != nil {
+				return nil, err
+			}
+			if sum := sha256.Sum256(data); document.SHA256 != "" && hex.EncodeToString(sum[:]) != document.SHA256 {
+				return nil, fmt.Errorf("invalid project archive: the checksum of %s does not match", document.Path)
+			}
+			board.Documents = append(board.Documents, Document{
+				CardRef:  document.TaskID,
+				FileName: document.FileName,
+				Data:     data,
+			})
+		}
+	}
+	if reader.Has(archive.EventsEntry) {
+		var events []json.RawMessage
+		if err := reader.ReadJSON(archive.EventsEntry, &events); err != nil {
+			return nil, err
+		}
+		if len(events) > 0 {
+			board.UnmappedFields = append(board.UnmappedFields, fmt.Sprintf("%d events", len(events)))
+		}
+	}
+	board.Warnings = append(board.Warnings, reader.Manifest.Warnings...)
+	return board, nil
+}
@@ -0,0 +1,107 @@
+// Package importer reads boards exported from other tools and maps them onto projects and
+// tasks. Parsing only depends on the export, resolving users and creating the project is left
+// to the caller.
+package importer
+
+import "time"
+
+const (
+	StatusPending    = "Pending"
+	StatusInProgress = "In Progress"
+	StatusCompleted  = "Completed"
+)
+
+// Board is an export in a format independent shape
+type Board struct {
+	Name    string
+	Lists   []string
+	Members []Member
+	Labels  []Label
+	Cards   []Card
+	// Project holds the settings of an exported project, other exports leave it empty
+	Project ProjectSettings
+	// CustomFields, Comments and Documents are only read from project archives
+	CustomFields []CustomField
+	Comments     []Comment
+	Documents    []Document
+	// UnmappedFields names the parts of the export that have no counterpart in a project
+	UnmappedFields []string
+	Warnings       []string
+}
+
+// Member is a user of the exported board. Exports do not always carry an email, the username
+// is what the caller maps to one then.
+type Member struct {
+	Ref      string
+	Username string
+	FullName string
+	Email    string
+}
+
+type Label struct {
+	Name  string
+	Color string
+}
+
+type ProjectSettings struct {
+	EndDate    string
+	MinMembers string
+	MaxMembers string
+}
+
+type Card struct {
+	// Ref identifies the card within the export, DependsOn refers to other cards by it
+	Ref         string
+	Name        string
+	Description string
+	List        string
+	// Status is set when the export names a status directly instead of a list
+	Status    string
+	Members   []string
+	Labels    []string
+	Due       *time.Time
+	Checklist []ChecklistItem
+	DependsOn []string
+	// CustomFields holds the values of the card keyed by the Ref of their field
+	CustomFields map[string]interface{}
+}
+
+type ChecklistItem struct {
+	Name string `json:"name"`
+	Done bool   `json:"done"`
+}
+
+// CustomField is a field definition of the exported project, Ref is its ID in the export
+type CustomField struct {
+	Ref      string
+	Name     string
+	Type     string
+	Options  []string
+	Required bool
+}
+
+// Comment is a comment on a card. ParentRef is the Ref of the comment it replies to, the
+// author and the mentions are member refs.
+type Comment struct {
+	Ref       string
+	CardRef   string
+	ParentRef string
+	AuthorRef string
+	Body      string
+	Mentions  []string
+	CreatedAt time.Time
+	EditedAt  *time.Time
+	History   []CommentRevision
+}
+
+type CommentRevision struct {
+	Body     string    `json:"body"`
+	EditedAt time.Time `json:"editedAt"`
+}
+
+// Document is a file attached to a card
+type Document struct {
+	CardRef  string
+	FileName string
+	Data     []byte
+}
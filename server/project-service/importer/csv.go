@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// csvColumns are the columns a CSV export may have, only name is required
+var csvColumns = []string{"name", "description", "status", "assignee email", "labels", "due"}
+
+// ParseCSV reads a CSV export with a header row. The status column holds a status or the name
+// of a list, assignees and labels may list several values separated by semicolons.
+func ParseCSV(r io.Reader) (*Board, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV export: %v", err)
+	}
+	board := &Board{}
+	columns := map[string]int{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !contains(csvColumns, column) {
+			board.UnmappedFields = append(board.UnmappedFields, fmt.Sprintf("column %q", column))
+			continue
+		}
+		columns[column] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("invalid CSV export: the name column is missing")
+	}
+	reader.FieldsPerRecord = len(header)
+
+	value := func(record []string, column string) string {
+		if i, ok := columns[column]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	emails := map[string]bool{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV export: %v", err)
+		}
+
+		card := Card{
+			Name:        value(record, "name"),
+			Description: value(record, "description"),
+			List:        value(record, "status"),
+			Labels:      splitValues(value(record, "labels")),
+			Members:     splitValues(value(record, "assignee email")),
+		}
+		if card.Name == "" {
+			board.Warnings = append(board.Warnings, fmt.Sprintf("row %d has no name and is skipped", line))
+			continue
+		}
+		if due := value(record, "due"); due != "" {
+			parsed, ok := parseDue(due)
+			if !ok {
+				board.Warnings = append(board.Warnings, fmt.Sprintf("row %d has an unreadable due date %q", line, due))
+			} else {
+				card.Due = &parsed
+			}
+		}
+		if card.List != "" && !contains(board.Lists, card.List) {
+			board.Lists = append(board.Lists, card.List)
+		}
+		for _, email := range card.Members {
+			if !emails[email] {
+				emails[email] = true
+				board.Members = append(board.Members, Member{Ref: email, Email: email})
+			}
+		}
+		for _, name := range card.Labels {
+			if !hasLabel(board.Labels, name) {
+				board.Labels = append(board.Labels, Label{Name: name, Color: defaultLabelColor})
+			}
+		}
+		board.Cards = append(board.Cards, card)
+	}
+	return board, nil
+}
+
+func splitValues(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func parseDue(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"} {
+		if due, err := time.Parse(layout, value); err == nil {
+			return due.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func hasLabel(labels []Label, name string) bool {
+	for _, label := range labels {
+		if strings.EqualFold(label.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,332 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxLabelLength = 50
+
+// Options adjust how a board is mapped onto a project
+type Options struct {
+	// StatusMap assigns a status to a list by name, the other lists are guessed from their name
+	StatusMap map[string]string `json:"statusMap"`
+	// MemberEmails gives the email of board members whose export carries none, keyed by
+	// username, full name or member ID
+	MemberEmails map[string]string `json:"memberEmails"`
+}
+
+// Plan is what an import creates, together with the report that is shown before committing
+type Plan struct {
+	Tasks        []PlannedTask
+	Labels       []Label
+	MemberIDs    []string
+	CustomFields []CustomField
+	Comments     []PlannedComment
+	Documents    []Document
+	Report       Report
+}
+
+type PlannedTask struct {
+	Ref         string
+	Name        string
+	Description string
+	Status      string
+	UserIDs     []string
+	Labels      []string
+	Due         *time.Time
+	Checklist   []ChecklistItem
+	// DependsOn lists the refs of the planned tasks this one depends on
+	DependsOn []string
+	// CustomFields holds the values of the task keyed by the Ref of their field, user values
+	// are already mapped to user IDs
+	CustomFields map[string]interface{}
+}
+
+// PlannedComment is a comment restored on a planned task, AuthorID is empty when the author
+// could not be mapped and the importing user stands in
+type PlannedComment struct {
+	Ref       string
+	TaskRef   string
+	ParentRef string
+	AuthorID  string
+	Body      string
+	Mentions  []string
+	CreatedAt time.Time
+	EditedAt  *time.Time
+	History   []CommentRevision
+}
+
+type Report struct {
+	Board          string          `json:"board"`
+	Lists          []ListMapping   `json:"lists"`
+	Members        []MemberMapping `json:"members"`
+	Labels         []string        `json:"labels"`
+	Tasks          int             `json:"tasks"`
+	ChecklistItems int             `json:"checklistItems"`
+	Dependencies   int             `json:"dependencies"`
+	CustomFields   []string        `json:"customFields"`
+	Comments       int             `json:"comments"`
+	Documents      int             `json:"documents"`
+	UnmappedUsers  []string        `json:"unmappedUsers"`
+	UnmappedFields []string        `json:"unmappedFields"`
+	Warnings       []string        `json:"warnings"`
+}
+
+type ListMapping struct {
+	List   string `json:"list"`
+	Status string `json:"status"`
+	Tasks  int    `json:"tasks"`
+}
+
+type MemberMapping struct {
+	Member string `json:"member"`
+	Email  string `json:"email,omitempty"`
+	UserID string `json:"userId,omitempty"`
+}
+
+// BuildPlan maps a board onto tasks. users maps lower case emails to the IDs of the users that
+// can join the project, board members without a match are reported and left out.
+func BuildPlan(board *Board, options Options, users map[string]string) (*Plan, error) {
+	statusMap := map[string]string{}
+	for list, status := range options.StatusMap {
+		normalized, ok := normalizeStatus(status)
+		if !ok {
+			return nil, fmt.Errorf("list %q is mapped to unknown status %q", list, status)
+		}
+		statusMap[strings.ToLower(strings.TrimSpace(list))] = normalized
+	}
+
+	plan := &Plan{
+		Report: Report{
+			Board:          board.Name,
+			Lists:          []ListMapping{},
+			Members:        []MemberMapping{},
+			Labels:         []string{},
+			CustomFields:   []string{},
+			UnmappedUsers:  []string{},
+			UnmappedFields: append([]string{}, board.UnmappedFields...),
+			Warnings:       append([]string{}, board.Warnings...),
+		},
+	}
+
+	listIndex := map[string]int{}
+	for _, list := range board.Lists {
+		status, ok := statusMap[strings.ToLower(strings.TrimSpace(list))]
+		if !ok {
+			status = guessStatus(list)
+		}
+		listIndex[list] = len(plan.Report.Lists)
+		plan.Report.Lists = append(plan.Report.Lists, ListMapping{List: list, Status: status})
+	}
+
+	userIDs := map[string]string{}
+	for _, member := range board.Members {
+		email := memberEmail(member, options.MemberEmails)
+		mapping := MemberMapping{Member: memberName(member), Email: email}
+		if userID, ok := users[strings.ToLower(email)]; ok && email != "" {
+			mapping.UserID = userID
+			userIDs[member.Ref] = userID
+			if !contains(plan.MemberIDs, userID) {
+				plan.MemberIDs = append(plan.MemberIDs, userID)
+			}
+		} else {
+			plan.Report.UnmappedUsers = append(plan.Report.UnmappedUsers, mapping.Member)
+		}
+		plan.Report.Members = append(plan.Report.Members, mapping)
+	}
+
+	labelNames := map[string]string{}
+	for _, label := range board.Labels {
+		name := strings.TrimSpace(label.Name)
+		if utf8.RuneCountInString(name) > maxLabelLength {
+			plan.Report.Warnings = append(plan.Report.Warnings, fmt.Sprintf("label %q is longer than %d characters and is skipped", name, maxLabelLength))
+			continue
+		}
+		if _, ok := labelNames[strings.ToLower(name)]; ok {
+			continue
+		}
+		labelNames[strings.ToLower(name)] = name
+		plan.Labels = append(plan.Labels, Label{Name: name, Color: label.Color})
+		plan.Report.Labels = append(plan.Report.Labels, name)
+	}
+
+	fields := map[string]CustomField{}
+	for _, field := range board.CustomFields {
+		fields[field.Ref] = field
+	}
+
+	refs := map[string]bool{}
+	for _, card := range board.Cards {
+		if card.Ref != "" {
+			refs[card.Ref] = true
+		}
+	}
+
+	for _, card := range board.Cards {
+		task := PlannedTask{
+			Ref:         card.Ref,
+			Name:        card.Name,
+			Description: card.Description,
+			Status:      card.Status,
+			Due:         card.Due,
+			Checklist:   card.Checklist,
+		}
+		if i, ok := listIndex[card.List]; ok {
+			plan.Report.Lists[i].Tasks++
+			if task.Status == "" {
+				task.Status = plan.Report.Lists[i].Status
+			}
+		}
+		if task.Status == "" {
+			task.Status = StatusPending
+		}
+		for _, ref := range card.Members {
+			if userID, ok := userIDs[ref]; ok && !contains(task.UserIDs, userID) {
+				task.UserIDs = append(task.UserIDs, userID)
+			}
+		}
+		for _, name := range card.Labels {
+			if label, ok := labelNames[strings.ToLower(strings.TrimSpace(name))]; ok && !contains(task.Labels, label) {
+				task.Labels = append(task.Labels, label)
+			}
+		}
+		for _, ref := range card.DependsOn {
+			if !refs[ref] {
+				plan.Report.Warnings = append(plan.Report.Warnings, fmt.Sprintf("task %q depends on a task that is not part of the export", card.Name))
+				continue
+			}
+			if !contains(task.DependsOn, ref) {
+				task.DependsOn = append(task.DependsOn, ref)
+			}
+		}
+		for ref, value := range card.CustomFields {
+			field, ok := fields[ref]
+			if !ok {
+				plan.Report.Warnings = append(plan.Report.Warnings, fmt.Sprintf("task %q has a value for a custom field that is not part of the export", card.Name))
+				continue
+			}
+			if field.Type == "user" {
+				userID, ok := userIDs[fmt.Sprint(value)]
+				if !ok {
+					plan.Report.Warnings = append(plan.Report.Warnings, fmt.Sprintf("the %q value of task %q is a user that could not be mapped and is left out", field.Name, card.Name))
+					continue
+				}
+				value = userID
+			}
+			if task.CustomFields == nil {
+				task.CustomFields = map[string]interface{}{}
+			}
+			task.CustomFields[ref] = value
+		}
+		plan.Report.ChecklistItems += len(task.Checklist)
+		plan.Report.Dependencies += len(task.DependsOn)
+		plan.Tasks = append(plan.Tasks, task)
+	}
+	plan.Report.Tasks = len(plan.Tasks)
+
+	// a required field stays required only when every task has a value, otherwise the tasks
+	// without one could not be created
+	for _, field := range board.CustomFields {
+		if field.Required {
+			for _, task := range plan.Tasks {
+				if _, ok := task.CustomFields[field.Ref]; !ok {
+					plan.Report.Warnings = append(plan.Report.Warnings, fmt.Sprintf("custom field %q is not set on every task and is imported as optional", field.Name))
+					field.Required = false
+					break
+				}
+			}
+		}
+		plan.CustomFields = append(plan.CustomFields, field)
+		plan.Report.CustomFields = append(plan.Report.CustomFields, field.Name)
+	}
+
+	for _, comment := range board.Comments {
+		if !refs[comment.CardRef] {
+			plan.Report.Warnings = append(plan.Report.Warnings, "a comment on a task that is not part of the export is left out")
+			continue
+		}
+		planned := PlannedComment{
+			Ref:       comment.Ref,
+			TaskRef:   comment.CardRef,
+			ParentRef: comment.ParentRef,
+			AuthorID:  userIDs[comment.AuthorRef],
+			Body:      comment.Body,
+			CreatedAt: comment.CreatedAt,
+			EditedAt:  comment.EditedAt,
+			History:   comment.History,
+		}
+		for _, ref := range comment.Mentions {
+			if userID, ok := userIDs[ref]; ok {
+				planned.Mentions = append(planned.Mentions, userID)
+			}
+		}
+		plan.Comments = append(plan.Comments, planned)
+	}
+	plan.Report.Comments = len(plan.Comments)
+
+	for _, document := range board.Documents {
+		if !refs[document.CardRef] {
+			plan.Report.Warnings = append(plan.Report.Warnings, fmt.Sprintf("document %q of a task that is not part of the export is left out", document.FileName))
+			continue
+		}
+		plan.Documents = append(plan.Documents, document)
+	}
+	plan.Report.Documents = len(plan.Documents)
+	return plan, nil
+}
+
+// normalizeStatus accepts a status in any letter case
+func normalizeStatus(status string) (string, bool) {
+	for _, known := range []string{StatusPending, StatusInProgress, StatusCompleted} {
+		if strings.EqualFold(strings.TrimSpace(status), known) {
+			return known, true
+		}
+	}
+	return "", false
+}
+
+// guessStatus derives a status from the name of a list, like "Done" or "Doing"
+func guessStatus(list string) string {
+	if status, ok := normalizeStatus(list); ok {
+		return status
+	}
+	name := strings.ToLower(list)
+	for _, word := range []string{"done", "complete", "finished", "closed"} {
+		if strings.Contains(name, word) {
+			return StatusCompleted
+		}
+	}
+	for _, word := range []string{"doing", "progress", "review", "testing", "active"} {
+		if strings.Contains(name, word) {
+			return StatusInProgress
+		}
+	}
+	return StatusPending
+}
+
+func memberEmail(member Member, emails map[string]string) string {
+	if member.Email != "" {
+		return member.Email
+	}
+	for _, key := range []string{member.Username, member.FullName, member.Ref} {
+		if email, ok := emails[key]; ok && key != "" {
+			return email
+		}
+	}
+	if strings.Contains(member.Username, "@") {
+		return member.Username
+	}
+	return ""
+}
+
+func memberName(member Member) string {
+	for _, name := range []string{member.Email, member.Username, member.FullName} {
+		if name != "" {
+			return name
+		}
+	}
+	return member.Ref
+}
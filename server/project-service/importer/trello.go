@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// trelloColors maps the color names of Trello labels onto the hex values projects use
+var trelloColors = map[string]string{
+	"green":  "#61bd4f",
+	"yellow": "#f2d600",
+	"orange": "#ff9f1a",
+	"red":    "#eb5a46",
+	"purple": "#c377e0",
+	"blue":   "#0079bf",
+	"sky":    "#00c2e0",
+	"lime":   "#51e898",
+	"pink":   "#ff78cb",
+	"black":  "#344563",
+}
+
+const defaultLabelColor = "#b3bac5"
+
+type trelloBoard struct {
+	Name  string `json:"name"`
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Members []struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		FullName string `json:"fullName"`
+		Email    string `json:"email"`
+	} `json:"members"`
+	Labels []struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	} `json:"labels"`
+	Cards []struct {
+		Name         string   `json:"name"`
+		Desc         string   `json:"desc"`
+		IDList       string   `json:"idList"`
+		IDMembers    []string `json:"idMembers"`
+		IDLabels     []string `json:"idLabels"`
+		Due          *string  `json:"due"`
+		DueComplete  bool     `json:"dueComplete"`
+		Closed       bool     `json:"closed"`
+		IDChecklists []string `json:"idChecklists"`
+		Attachments  []struct {
+			Name string `json:"name"`
+		} `json:"attachments"`
+	} `json:"cards"`
+	Checklists []struct {
+		ID         string `json:"id"`
+		Name       string `json:"name"`
+		CheckItems []struct {
+			Name  string  `json:"name"`
+			State string  `json:"state"`
+			Pos   float64 `json:"pos"`
+		} `json:"checkItems"`
+	} `json:"checklists"`
+	CustomFields []struct {
+		Name string `json:"name"`
+	} `json:"customFields"`
+}
+
+// ParseTrello reads the JSON export of a Trello board. Archived lists and cards are left out.
+func ParseTrello(r io.Reader) (*Board, error) {
+	var export trelloBoard
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("invalid Trello export: %v", err)
+	}
+
+	board := &Board{Name: strings.TrimSpace(export.Name)}
+
+	lists := map[string]string{}
+	for _, list := range export.Lists {
+		if list.Closed {
+			continue
+		}
+		lists[list.ID] = list.Name
+		board.Lists = append(board.Lists, list.Name)
+	}
+
+	for _, member := range export.Members {
+		board.Members = append(board.Members, Member{
+			Ref:      member.ID,
+			Username: member.Username,
+			FullName: member.FullName,
+			Email:    member.Email,
+		})
+	}
+
+	labels := map[string]string{}
+	for _, label := range export.Labels {
+		name := strings.TrimSpace(label.Name)
+		if name == "" {
+			// Trello allows labels that are only a color
+			name = label.Color
+		}
+		if name == "" {
+			continue
+		}
+		color, ok := trelloColors[label.Color]
+		if !ok {
+			color = defaultLabelColor
+		}
+		labels[label.ID] = name
+		board.Labels = append(board.Labels, Label{Name: name, Color: color})
+	}
+
+	checklists := map[string][]ChecklistItem{}
+	for _, checklist := range export.Checklists {
+		items := make([]ChecklistItem, 0, len(checklist.CheckItems))
+		for _, item := range checklist.CheckItems {
+			items = append(items, ChecklistItem{Name: item.Name, Done: item.State == "complete"})
+		}
+		checklists[checklist.ID] = items
+	}
+
+	archived, attachments := 0, 0
+	for _, exported := range export.Cards {
+		list, ok := lists[exported.IDList]
+		if exported.Closed || !ok {
+			archived++
+			continue
+		}
+		card := Card{
+			Name:        strings.TrimSpace(exported.Name),
+			Description: exported.Desc,
+			List:        list,
+			Members:     exported.IDMembers,
+		}
+		if exported.DueComplete {
+			card.Status = StatusCompleted
+		}
+		for _, labelID := range exported.IDLabels {
+			if name, ok := labels[labelID]; ok {
+				card.Labels = append(card.Labels, name)
+			}
+		}
+		if exported.Due != nil && *exported.Due != "" {
+			due, err := time.Parse(time.RFC3339, *exported.Due)
+			if err != nil {
+				board.Warnings = append(board.Warnings, fmt.Sprintf("card %q has an unreadable due date %q", card.Name, *exported.Due))
+			} else {
+				due = due.UTC()
+				card.Due = &due
+			}
+		}
+		for _, checklistID := range exported.IDChecklists {
+			card.Checklist = append(card.Checklist, checklists[checklistID]...)
+		}
+		attachments += len(exported.Attachments)
+		board.Cards = append(board.Cards, card)
+	}
+
+	if archived > 0 {
+		board.Warnings = append(board.Warnings, fmt.Sprintf("%d archived cards are skipped", archived))
+	}
+	if attachments > 0 {
+		board.UnmappedFields = append(board.UnmappedFields, fmt.Sprintf("attachments (%d)", attachments))
+	}
+	for _, field := range export.CustomFields {
+		board.UnmappedFields = append(board.UnmappedFields, fmt.Sprintf("custom field %q", field.Name))
+	}
+	return board, nil
+}
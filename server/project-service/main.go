@@ -28,6 +28,16 @@ func initUserClient() client.UserClient {
 func initTaskClient() client.TaskClient {
 	return client.NewTaskClient(os.Getenv("TASK_SERVICE_HOST"), os.Getenv("PORT"))
 }
+func initWorkflowClient() client.WorkflowClient {
+	address := os.Getenv("LINK_TO_WORKFLOW_SERVICE")
+	if address == "" {
+		address = "https://workflow-service:" + os.Getenv("PORT")
+	}
+	return client.NewWorkflowClient(address)
+}
+func initAnalyticClient() client.AnalyticClient {
+	return client.NewAnalyticClient(os.Getenv("LINK_TO_ANALYTIC_SERVICE"))
+}
 
 func main() {
 	fmt.Print("Hello from project-service")
@@ -67,10 +77,17 @@ func main() {
 
 	userClient := initUserClient()
 	taskClient := initTaskClient()
+	workflowClient := initWorkflowClient()
+	analyticClient := initAnalyticClient()
 
-	projectsHandler := handlers.NewProjectsHandler(logger, custLogger, store, tracer, userClient, taskClient)
+	projectsHandler := handlers.NewProjectsHandler(logger, custLogger, store, tracer, userClient, taskClient, workflowClient, analyticClient)
 	projectsHandler.SubscribeToEvent(timeoutContext)
 
+	purgeContext, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go projectsHandler.RunTrashPurge(purgeContext)
+	go projectsHandler.RunRestoreRetry(purgeContext)
+
 	router := mux.NewRouter()
 
 	router.Use(projectsHandler.MiddlewareContentTypeSet)
@@ -79,11 +96,17 @@ func main() {
 	getRouter.HandleFunc("/", projectsHandler.GetAllProjects)
 	getRouter.Handle("/projects", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"member", "manager"}, http.HandlerFunc(projectsHandler.GetAllProjectsByUser))))
 	getRouter.HandleFunc("/projects/{id}/users/{userId}/check", projectsHandler.IsUserInProject).Methods("GET")
+	getRouter.Handle("/projects/trash", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.GetProjectTrash))))
+	getRouter.Handle("/projects/{id}/export", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"member", "manager"}, http.HandlerFunc(projectsHandler.ExportProject))))
 	getRouter.Handle("/projects/{id}/manager", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.CheckIfUserIsManager)))).Methods("GET")
 
 	postRouter := router.Methods(http.MethodPost).Subrouter()
 	postRouter.HandleFunc("/", projectsHandler.PostProject)
 	postRouter.Use(projectsHandler.MiddlewarePatientDeserialization)
+	router.Handle("/projects/import", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.ImportProject)))).Methods(http.MethodPost)
+	router.Handle("/projects/{id}/watch", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"member", "manager"}, http.HandlerFunc(projectsHandler.WatchProject)))).Methods(http.MethodPost)
+	router.Handle("/projects/{id}/watch", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"member", "manager"}, http.HandlerFunc(projectsHandler.UnwatchProject)))).Methods(http.MethodDelete)
+	router.Handle("/projects/{id}/restore", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.RestoreProject)))).Methods(http.MethodPost)
 	router.Handle("/projects/{id}/addUsers", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.AddUsersToProject))))
 
 	getByIdRouter := router.Methods(http.MethodGet).Subrouter()
@@ -97,6 +120,28 @@ func main() {
 
 	deleteRouter.Handle("/projects/{id}", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.DeleteProject))))
 
+	labelRouter := router.PathPrefix("/projects/{id}/labels").Subrouter()
+	labelRouter.Handle("", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"member", "manager"}, http.HandlerFunc(projectsHandler.GetProjectLabels)))).Methods(http.MethodGet)
+	labelRouter.Handle("", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.PostProjectLabel)))).Methods(http.MethodPost)
+	labelRouter.Handle("/{labelId}", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.UpdateProjectLabel)))).Methods(http.MethodPut)
+	labelRouter.Handle("/{labelId}", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.DeleteProjectLabel)))).Methods(http.MethodDelete)
+
+	fieldRouter := router.PathPrefix("/projects/{id}/fields").Subrouter()
+	fieldRouter.Handle("", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"member", "manager"}, http.HandlerFunc(projectsHandler.GetProjectCustomFields)))).Methods(http.MethodGet)
+	fieldRouter.Handle("", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.PostProjectCustomField)))).Methods(http.MethodPost)
+	fieldRouter.Handle("/{fieldId}", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.UpdateProjectCustomField)))).Methods(http.MethodPut)
+	fieldRouter.Handle("/{fieldId}", projectsHandler.MiddlewareExtractUserFromCookie(projectsHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(projectsHandler.DeleteProjectCustomField)))).Methods(http.MethodDelete)
+
+	// the internal router answers other services without a user cookie, it is served on
+	// INTERNAL_PORT, which the api gateway does not proxy
+	internalRouter := mux.NewRouter()
+	internalRouter.Use(projectsHandler.MiddlewareContentTypeSet)
+	internalRouter.Use(handlers.ExtractTraceInfoMiddleware)
+	internalRouter.HandleFunc("/projects/{id}/watchers", projectsHandler.GetProjectWatchers).Methods(http.MethodGet)
+	internalRouter.HandleFunc("/projects/{id}/managerId", projectsHandler.GetProjectManagerID).Methods(http.MethodGet)
+	internalRouter.HandleFunc("/projects/{id}/calendar", projectsHandler.GetCalendarProject).Methods(http.MethodGet)
+	internalRouter.HandleFunc("/projects/users/{userId}/calendar", projectsHandler.GetUserCalendarProjects).Methods(http.MethodGet)
+
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
@@ -124,13 +169,36 @@ func main() {
 		}
 	}()
 
-	sigCh := make(chan os.Signal)
+	internalPort := os.Getenv("INTERNAL_PORT")
+	if len(internalPort) == 0 {
+		internalPort = "8081"
+	}
+	internalServer := http.Server{
+		Addr:         ":" + internalPort,
+		Handler:      internalRouter,
+		IdleTimeout:  120 * time.Second,
+		ReadTimeout:  1 * time.Second,
+		WriteTimeout: 1 * time.Second,
+	}
+
+	logger.Println("Internal server listening on port", internalPort)
+	go func() {
+		err := internalServer.ListenAndServeTLS("/app/cert.crt", "/app/privat.key")
+		if err != nil && err != http.ErrServerClosed {
+			logger.Fatal(err)
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt)
 	signal.Notify(sigCh, os.Kill)
 
 	sig := <-sigCh
 	logger.Println("Received terminate, graceful shutdown", sig)
 
+	if internalServer.Shutdown(timeoutContext) != nil {
+		logger.Println("Cannot gracefully shutdown the internal server")
+	}
 	if server.Shutdown(timeoutContext) != nil {
 		logger.Fatal("Cannot gracefully shutdown...")
 	}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"strings"
+)
+
+type CustomFieldType string
+
+const (
+	CustomFieldText   CustomFieldType = "text"
+	CustomFieldNumber CustomFieldType = "number"
+	CustomFieldDate   CustomFieldType = "date"
+	CustomFieldEnum   CustomFieldType = "enum"
+	CustomFieldUser   CustomFieldType = "user"
+)
+
+type CustomField struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name     string             `bson:"name" json:"name"`
+	Type     CustomFieldType    `bson:"type" json:"type"`
+	Options  []string           `bson:"options" json:"options"`
+	Required bool               `bson:"required" json:"required"`
+}
+
+type CustomFields []*CustomField
+
+func (c *CustomFields) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(c)
+}
+
+func (c *CustomField) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(c)
+}
+
+func (c *CustomField) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(c)
+}
+
+// Validate trims the field definition and checks that it describes a usable field
+func (c *CustomField) Validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return fmt.Errorf("field name cannot be empty")
+	}
+	if len(c.Name) > 50 {
+		return fmt.Errorf("field name cannot be longer than 50 characters")
+	}
+
+	switch c.Type {
+	case CustomFieldText, CustomFieldNumber, CustomFieldDate, CustomFieldUser:
+		c.Options = []string{}
+	case CustomFieldEnum:
+		options := []string{}
+		for _, option := range c.Options {
+			option = strings.TrimSpace(option)
+			if option == "" {
+				return fmt.Errorf("enum options cannot be empty")
+			}
+			for _, existing := range options {
+				if existing == option {
+					return fmt.Errorf("enum option %q is listed twice", option)
+				}
+			}
+			options = append(options, option)
+		}
+		if len(options) == 0 {
+			return fmt.Errorf("enum field needs at least one option")
+		}
+		c.Options = options
+	default:
+		return fmt.Errorf("field type must be one of text, number, date, enum or user")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type Label struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name  string             `bson:"name" json:"name"`
+	Color string             `bson:"color" json:"color"`
+}
+
+type Labels []*Label
+
+func (l *Labels) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(l)
+}
+
+func (l *Label) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(l)
+}
+
+func (l *Label) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(l)
+}
+
+// Validate trims the label name and checks that name and color are usable
+func (l *Label) Validate() error {
+	l.Name = strings.TrimSpace(l.Name)
+	if l.Name == "" {
+		return fmt.Errorf("label name cannot be empty")
+	}
+	if len(l.Name) > 50 {
+		return fmt.Errorf("label name cannot be longer than 50 characters")
+	}
+	if !labelColorPattern.MatchString(l.Color) {
+		return fmt.Errorf("label color must be a hex value like #1f77b4")
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"io"
+	"time"
 )
 
 type Project struct {
@@ -14,7 +15,15 @@ type Project struct {
 	MaxMembers      string             `bson:"max_members" json:"max_members"`
 	UserIDs         []string           `bson:"user_ids" json:"user_ids"`
 	Manager         string             `bson:"manager" json:"manager"`
+	Labels          []Label            `bson:"labels" json:"labels"`
+	CustomFields    []CustomField      `bson:"custom_fields" json:"custom_fields"`
+	Watchers        []string           `bson:"watchers,omitempty" json:"watchers,omitempty"`
 	PendingDeletion bool               `bson:"pending_deletion" json:"pending_deletion"`
+	DeletedAt       *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy       string             `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+	Trashed         bool               `bson:"trashed,omitempty" json:"trashed,omitempty"`
+	RestoringSince  *time.Time         `bson:"restoring_since,omitempty" json:"restoring_since,omitempty"`
+	PurgedBy        []string           `bson:"purged_by,omitempty" json:"-"`
 }
 
 type Projects []*Project
@@ -179,6 +179,15 @@ func (pr *ProjectRepo) Insert(ctx context.Context, project *model.Project) error
 	if project.UserIDs == nil {
 		project.UserIDs = []string{}
 	}
+	if project.Labels == nil {
+		project.Labels = []model.Label{}
+	}
+	if project.CustomFields == nil {
+		project.CustomFields = []model.CustomField{}
+	}
+	if project.ID.IsZero() {
+		project.ID = primitive.NewObjectID()
+	}
 
 	result, err := projectsCollection.InsertOne(ctx, &project)
 	if err != nil {
@@ -237,6 +246,7 @@ func (pr *ProjectRepo) AddUsersToProject(ctx context.Context, projectId string,
 	return nil
 }
 
+// RemoveUserFromProject takes a user off the members and the watchers of a project
 func (pr *ProjectRepo) RemoveUserFromProject(ctx context.Context, projectId string, userId string) error {
 	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.RemoveUserFromProject")
 	defer span.End()
@@ -259,7 +269,8 @@ func (pr *ProjectRepo) RemoveUserFromProject(ctx context.Context, projectId stri
 	_, err = collection.UpdateOne(
 		ctx,
 		bson.M{"_id": projectObjID},
-		bson.M{"$pull": bson.M{"user_ids": userObjID}},
+		// only project members can watch the project
+		bson.M{"$pull": bson.M{"user_ids": userObjID, "watchers": userId}},
 	)
 	if err != nil {
 		span.RecordError(err)
@@ -356,3 +367,188 @@ func (pr *ProjectRepo) IsUserManagerOfProject(ctx context.Context, userId string
 	span.SetStatus(codes.Ok, "Successful function")
 	return managerObjID == userObjID, nil
 }
+
+func (pr *ProjectRepo) AddLabel(ctx context.Context, projectId string, label *model.Label) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.AddLabel")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	label.ID = primitive.NewObjectID()
+	_, err = pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID},
+		bson.M{"$push": bson.M{"labels": label}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to add label to project: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully added label to project")
+	return nil
+}
+
+func (pr *ProjectRepo) UpdateLabel(ctx context.Context, projectId string, label *model.Label) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.UpdateLabel")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	_, err = pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID, "labels._id": label.ID},
+		bson.M{"$set": bson.M{
+			"labels.$.name":  label.Name,
+			"labels.$.color": label.Color,
+		}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to update label: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully updated label")
+	return nil
+}
+
+func (pr *ProjectRepo) RemoveLabel(ctx context.Context, projectId string, labelId primitive.ObjectID) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.RemoveLabel")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	_, err = pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID},
+		bson.M{"$pull": bson.M{"labels": bson.M{"_id": labelId}}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to remove label from project: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully removed label from project")
+	return nil
+}
+
+func (pr *ProjectRepo) AddCustomField(ctx context.Context, projectId string, field *model.CustomField) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.AddCustomField")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	field.ID = primitive.NewObjectID()
+	_, err = pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID},
+		bson.M{"$push": bson.M{"custom_fields": field}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to add custom field to project: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully added custom field to project")
+	return nil
+}
+
+func (pr *ProjectRepo) UpdateCustomField(ctx context.Context, projectId string, field *model.CustomField) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.UpdateCustomField")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	_, err = pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID, "custom_fields._id": field.ID},
+		bson.M{"$set": bson.M{
+			"custom_fields.$.name":     field.Name,
+			"custom_fields.$.options":  field.Options,
+			"custom_fields.$.required": field.Required,
+		}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to update custom field: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully updated custom field")
+	return nil
+}
+
+func (pr *ProjectRepo) RemoveCustomField(ctx context.Context, projectId string, fieldId primitive.ObjectID) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.RemoveCustomField")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	_, err = pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID},
+		bson.M{"$pull": bson.M{"custom_fields": bson.M{"_id": fieldId}}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to remove custom field from project: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully removed custom field from project")
+	return nil
+}
+
+// SetWatching adds the user to the watchers of a project or removes them from it
+func (pr *ProjectRepo) SetWatching(ctx context.Context, projectId string, userId string, watching bool) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.SetWatching")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	update := bson.M{"$addToSet": bson.M{"watchers": userId}}
+	if !watching {
+		update = bson.M{"$pull": bson.M{"watchers": userId}}
+	}
+	_, err = pr.getCollection().UpdateOne(ctx, bson.M{"_id": projectObjID}, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to update project watchers: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully updated project watchers")
+	return nil
+}
@@ -0,0 +1,284 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+	"project-service/model"
+	"time"
+)
+
+// MarkDeleted starts the deletion of a project and records who deleted it
+func (pr *ProjectRepo) MarkDeleted(ctx context.Context, projectId string, userId string) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.MarkDeleted")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	_, err = pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID},
+		bson.M{"$set": bson.M{"pending_deletion": true, "deleted_at": time.Now(), "deleted_by": userId}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to mark project as deleted: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully marked project as deleted")
+	return nil
+}
+
+// MoveToTrash finishes the deletion of a project, it stays restorable until it is purged
+func (pr *ProjectRepo) MoveToTrash(ctx context.Context, projectId string) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.MoveToTrash")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	_, err = pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID, "pending_deletion": true},
+		bson.M{"$set": bson.M{"trashed": true}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to move project to trash: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully moved project to trash")
+	return nil
+}
+
+// RestoreFromTrash reverts a deletion, whether it is still in progress or already finished
+func (pr *ProjectRepo) RestoreFromTrash(ctx context.Context, projectId string) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.RestoreFromTrash")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	_, err = pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID},
+		bson.M{
+			"$set":   bson.M{"pending_deletion": false},
+			"$unset": bson.M{"deleted_at": "", "deleted_by": "", "trashed": ""},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to restore project: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully restored project")
+	return nil
+}
+
+// StartRestore takes a project out of the trash while task-service brings its tasks back. It
+// reports false when the project is not in the trash.
+func (pr *ProjectRepo) StartRestore(ctx context.Context, projectId string) (bool, error) {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.StartRestore")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	result, err := pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID, "trashed": true},
+		bson.M{
+			"$set":   bson.M{"restoring_since": time.Now()},
+			"$unset": bson.M{"trashed": ""},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to start restoring project: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully started restoring project")
+	return result.ModifiedCount == 1, nil
+}
+
+// CancelRestore puts a project whose restore could not be started back into the trash
+func (pr *ProjectRepo) CancelRestore(ctx context.Context, projectId string) error {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.CancelRestore")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	_, err = pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID, "restoring_since": bson.M{"$exists": true}},
+		bson.M{
+			"$set":   bson.M{"trashed": true},
+			"$unset": bson.M{"restoring_since": ""},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to cancel restoring project: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully cancelled restoring project")
+	return nil
+}
+
+// FinishRestore brings back a project once task-service restored its tasks. It reports false
+// when the project is not being restored, so a repeated confirmation is ignored.
+func (pr *ProjectRepo) FinishRestore(ctx context.Context, projectId string) (bool, error) {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.FinishRestore")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	result, err := pr.getCollection().UpdateOne(
+		ctx,
+		bson.M{"_id": projectObjID, "restoring_since": bson.M{"$exists": true}},
+		bson.M{
+			"$set":   bson.M{"pending_deletion": false},
+			"$unset": bson.M{"deleted_at": "", "deleted_by": "", "trashed": "", "restoring_since": "", "purged_by": ""},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to restore project: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully restored project")
+	return result.ModifiedCount == 1, nil
+}
+
+// GetRestoringBefore returns the projects whose restore started before cutoff and is not
+// confirmed yet
+func (pr *ProjectRepo) GetRestoringBefore(ctx context.Context, cutoff time.Time) (model.Projects, error) {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.GetRestoringBefore")
+	defer span.End()
+
+	projects := model.Projects{}
+	cursor, err := pr.getCollection().Find(ctx, bson.M{"restoring_since": bson.M{"$lt": cutoff}})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &projects); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got projects being restored")
+	return projects, nil
+}
+
+// ConfirmPurge records that a service purged what it kept of a trashed project and returns
+// the services that have confirmed so far, none when the project is no longer in the trash
+func (pr *ProjectRepo) ConfirmPurge(ctx context.Context, projectId string, service string) ([]string, error) {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.ConfirmPurge")
+	defer span.End()
+
+	projectObjID, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("invalid project ID: %v", err)
+	}
+
+	var project model.Project
+	err = pr.getCollection().FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": projectObjID, "trashed": true},
+		bson.M{"$addToSet": bson.M{"purged_by": service}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&project)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		span.SetStatus(codes.Ok, "Project is not in the trash")
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to confirm purge of project: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully confirmed purge")
+	return project.PurgedBy, nil
+}
+
+// GetTrashByManager returns the deleted projects of a manager, most recently deleted first
+func (pr *ProjectRepo) GetTrashByManager(ctx context.Context, managerId string) (model.Projects, error) {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.GetTrashByManager")
+	defer span.End()
+
+	projects := model.Projects{}
+	cursor, err := pr.getCollection().Find(ctx,
+		bson.M{"manager": managerId, "trashed": true},
+		options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}}),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &projects); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got trashed projects")
+	return projects, nil
+}
+
+// GetTrashedBefore returns the projects that were moved to the trash before cutoff
+func (pr *ProjectRepo) GetTrashedBefore(ctx context.Context, cutoff time.Time) (model.Projects, error) {
+	ctx, span := pr.tracer.Start(ctx, "ProjectRepo.GetTrashedBefore")
+	defer span.End()
+
+	projects := model.Projects{}
+	cursor, err := pr.getCollection().Find(ctx, bson.M{"trashed": true, "deleted_at": bson.M{"$lt": cutoff}})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &projects); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got expired trashed projects")
+	return projects, nil
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// ErrUnauthorized is returned when project-service rejects the caller's token
+var ErrUnauthorized = errors.New("unauthorized")
+
+type ProjectDetails struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ProjectClient struct {
+	address string
+}
+
+func NewProjectClient(address string) ProjectClient {
+	return ProjectClient{
+		address: address,
+	}
+}
+
+// GetUserProjects retrieves the projects the owner of the cookie is a member or the manager of
+func (client ProjectClient) GetUserProjects(ctx context.Context, cookie *http.Cookie) ([]ProjectDetails, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, client.address+"/projects", nil)
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return nil, errors.New("error while creating the request")
+	}
+
+	httpReq.AddCookie(cookie)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientProject, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return nil, errors.New("error while creating the request")
+	}
+	res, err := clientProject.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return nil, errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, ErrUnauthorized
+	default:
+		log.Printf("Received non-OK status code: %d", res.StatusCode)
+		return nil, errors.New(res.Status)
+	}
+
+	var projects []ProjectDetails
+	if err := json.NewDecoder(res.Body).Decode(&projects); err != nil {
+		log.Printf("Error decoding response body: %v", err)
+		return nil, errors.New("error decoding response")
+	}
+	return projects, nil
+}
+
+func createTLSClient() (*http.Client, error) {
+	caCert, err := os.ReadFile("/app/cert.crt")
+	if err != nil {
+		return nil, err
+	}
+
+	caCertPool := x509.NewCertPool()
+	caCertPool.AppendCertsFromPEM(caCert)
+
+	tlsConfig := &tls.Config{
+		RootCAs: caCertPool,
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+	}
+
+	client := &http.Client{
+		Transport: transport,
+	}
+
+	return client, nil
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"search-service/index"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// IndexListener keeps the index up to date with the events of the other services. Every
+// replica holds its own index, so the subscriptions are not shared through a queue group.
+func (s *SearchHandler) IndexListener(nc *nats.Conn) ([]*nats.Subscription, error) {
+	handlers := map[string]func(context.Context, []byte){
+		"project.created":        s.handleProjectCreated,
+		"TasksDeletionComplete":  s.handleProjectDeleted,
+		"task.created":           s.handleTaskCreated,
+		"task.deleted":           s.handleTaskDeleted,
+		"task.comment.saved":     s.handleCommentSaved,
+		"task.document.uploaded": s.handleDocumentUploaded,
+		"task.document.removed":  s.handleDocumentRemoved,
+		"DocumentTextExtracted":  s.handleDocumentTextExtracted,
+	}
+
+	var subscriptions []*nats.Subscription
+	for subject, handler := range handlers {
+		handler := handler
+		sub, err := nc.Subscribe(subject, func(msg *nats.Msg) {
+			handler(context.Background(), msg.Data)
+		})
+		if err != nil {
+			for _, subscription := range subscriptions {
+				_ = subscription.Unsubscribe()
+			}
+			return nil, err
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions, nil
+}
+
+func (s *SearchHandler) handleProjectCreated(ctx context.Context, data []byte) {
+	_, span := s.tracer.Start(ctx, "SearchHandler.handleProjectCreated")
+	defer span.End()
+
+	var message struct {
+		ProjectID string `json:"projectId"`
+		Name      string `json:"name"`
+	}
+	if !s.decodeEvent(span, "project.created", data, &message) {
+		return
+	}
+	s.index.Put(index.Document{
+		ID:        message.ProjectID,
+		Type:      index.ProjectDocument,
+		ProjectID: message.ProjectID,
+		Title:     message.Name,
+	})
+	span.SetStatus(codes.Ok, "Successfully indexed project")
+}
+
+func (s *SearchHandler) handleProjectDeleted(ctx context.Context, data []byte) {
+	_, span := s.tracer.Start(ctx, "SearchHandler.handleProjectDeleted")
+	defer span.End()
+
+	projectID := string(data)
+	count := s.index.DeleteProject(projectID)
+	s.logger.Printf("Removed %d documents of deleted project %s", count, projectID)
+	span.SetStatus(codes.Ok, "Successfully removed project from index")
+}
+
+func (s *SearchHandler) handleTaskCreated(ctx context.Context, data []byte) {
+	_, span := s.tracer.Start(ctx, "SearchHandler.handleTaskCreated")
+	defer span.End()
+
+	var message struct {
+		TaskID      string `json:"taskId"`
+		ProjectID   string `json:"projectId"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if !s.decodeEvent(span, "task.created", data, &message) {
+		return
+	}
+	s.index.Put(index.Document{
+		ID:        message.TaskID,
+		Type:      index.TaskDocument,
+		ProjectID: message.ProjectID,
+		TaskID:    message.TaskID,
+		Title:     message.Name,
+		Body:      message.Description,
+	})
+	span.SetStatus(codes.Ok, "Successfully indexed task")
+}
+
+func (s *SearchHandler) handleTaskDeleted(ctx context.Context, data []byte) {
+	_, span := s.tracer.Start(ctx, "SearchHandler.handleTaskDeleted")
+	defer span.End()
+
+	var message struct {
+		TaskID    string `json:"taskId"`
+		ProjectID string `json:"projectId"`
+	}
+	if !s.decodeEvent(span, "task.deleted", data, &message) {
+		return
+	}
+	count := s.index.DeleteTask(message.TaskID)
+	s.logger.Printf("Removed %d documents of deleted task %s", count, message.TaskID)
+	span.SetStatus(codes.Ok, "Successfully removed task from index")
+}
+
+func (s *SearchHandler) handleCommentSaved(ctx context.Context, data []byte) {
+	_, span := s.tracer.Start(ctx, "SearchHandler.handleCommentSaved")
+	defer span.End()
+
+	var message struct {
+		CommentID string `json:"commentId"`
+		TaskID    string `json:"taskId"`
+		ProjectID string `json:"projectId"`
+		Body      string `json:"body"`
+	}
+	if !s.decodeEvent(span, "task.comment.saved", data, &message) {
+		return
+	}
+
+	// comments are listed under the name of their task
+	title := ""
+	if task, ok := s.index.Get(index.TaskDocument, message.TaskID); ok {
+		title = task.Title
+	}
+	s.index.Put(index.Document{
+		ID:        message.CommentID,
+		Type:      index.CommentDocument,
+		ProjectID: message.ProjectID,
+		TaskID:    message.TaskID,
+		Title:     title,
+		Body:      message.Body,
+	})
+	span.SetStatus(codes.Ok, "Successfully indexed comment")
+}
+
+func (s *SearchHandler) handleDocumentUploaded(ctx context.Context, data []byte) {
+	_, span := s.tracer.Start(ctx, "SearchHandler.handleDocumentUploaded")
+	defer span.End()
+
+	var message struct {
+		DocumentID string `json:"documentId"`
+		TaskID     string `json:"taskId"`
+		ProjectID  string `json:"projectId"`
+		FileName   string `json:"fileName"`
+		Text       string `json:"text"`
+	}
+	if !s.decodeEvent(span, "task.document.uploaded", data, &message) {
+		return
+	}
+	s.index.Put(index.Document{
+		ID:        message.DocumentID,
+		Type:      index.AttachedDocument,
+		ProjectID: message.ProjectID,
+		TaskID:    message.TaskID,
+		Title:     message.FileName,
+		Body:      message.Text,
+	})
+	span.SetStatus(codes.Ok, "Successfully indexed document")
+}
+
+// handleDocumentTextExtracted adds the contents of a document to its entry. The text is
+// extracted after the upload, so the document was indexed by name before.
+func (s *SearchHandler) handleDocumentTextExtracted(ctx context.Context, data []byte) {
+	_, span := s.tracer.Start(ctx, "SearchHandler.handleDocumentTextExtracted")
+	defer span.End()
+
+	var message struct {
+		DocumentID string `json:"documentId"`
+		TaskID     string `json:"taskId"`
+		ProjectID  string `json:"projectId"`
+		FileName   string `json:"fileName"`
+		Text       string `json:"text"`
+	}
+	if !s.decodeEvent(span, "DocumentTextExtracted", data, &message) {
+		return
+	}
+	s.index.Put(index.Document{
+		ID:        message.DocumentID,
+		Type:      index.AttachedDocument,
+		ProjectID: message.ProjectID,
+		TaskID:    message.TaskID,
+		Title:     message.FileName,
+		Body:      message.Text,
+	})
+	span.SetStatus(codes.Ok, "Successfully indexed document text")
+}
+
+func (s *SearchHandler) handleDocumentRemoved(ctx context.Context, data []byte) {
+	_, span := s.tracer.Start(ctx, "SearchHandler.handleDocumentRemoved")
+	defer span.End()
+
+	var message struct {
+		DocumentID string `json:"documentId"`
+	}
+	if !s.decodeEvent(span, "task.document.removed", data, &message) {
+		return
+	}
+	s.index.Delete(index.AttachedDocument, message.DocumentID)
+	span.SetStatus(codes.Ok, "Successfully removed document from index")
+}
+
+func (s *SearchHandler) decodeEvent(span trace.Span, subject string, data []byte, message interface{}) bool {
+	if err := json.Unmarshal(data, message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.Printf("Error unmarshalling %s message: %v", subject, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"search-service/client"
+	"search-service/customLogger"
+	"search-service/index"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchHandler struct {
+	logger        *log.Logger
+	index         *index.Index
+	tracer        trace.Tracer
+	projectClient client.ProjectClient
+	custLogger    *customLogger.Logger
+}
+
+func NewSearchHandler(l *log.Logger, ix *index.Index, tracer trace.Tracer, projectClient client.ProjectClient, custLogger *customLogger.Logger) *SearchHandler {
+	return &SearchHandler{l, ix, tracer, projectClient, custLogger}
+}
+
+// searchResponse adds the names of the projects in the facets to the index result
+type searchResponse struct {
+	index.Result
+	Projects map[string]string `json:"projects"`
+}
+
+// Search looks up q across the projects, tasks, comments and documents of the caller's
+// projects. Results can be narrowed down with type (comma separated) and projectId and are
+// paged with limit and offset.
+func (s *SearchHandler) Search(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := s.tracer.Start(h.Context(), "SearchHandler.Search")
+	defer span.End()
+	s.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+		return
+	}
+
+	params := h.URL.Query()
+	text := strings.TrimSpace(params.Get("q"))
+	if text == "" {
+		http.Error(rw, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	query := index.Query{
+		Text:      params.Get("q"),
+		ProjectID: params.Get("projectId"),
+		Limit:     defaultSearchLimit,
+	}
+	for _, value := range strings.Split(params.Get("type"), ",") {
+		switch documentType := index.DocumentType(strings.TrimSpace(value)); documentType {
+		case "":
+		case index.ProjectDocument, index.TaskDocument, index.CommentDocument, index.AttachedDocument:
+			query.Types = append(query.Types, documentType)
+		default:
+			http.Error(rw, fmt.Sprintf("unknown type %q", documentType), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := params.Get("limit"); value != "" {
+		query.Limit, err = strconv.Atoi(value)
+		if err != nil || query.Limit < 1 || query.Limit > maxSearchLimit {
+			http.Error(rw, fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := params.Get("offset"); value != "" {
+		query.Offset, err = strconv.Atoi(value)
+		if err != nil || query.Offset < 0 {
+			http.Error(rw, "offset must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// the index holds every project, results are limited to the ones the caller belongs to
+	projects, err := s.projectClient.GetUserProjects(ctx, cookie)
+	if errors.Is(err, client.ErrUnauthorized) {
+		http.Error(rw, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.Println("Error fetching user projects:", err)
+		http.Error(rw, "Unable to fetch user projects", http.StatusBadGateway)
+		return
+	}
+	query.ProjectIDs = map[string]bool{}
+	names := map[string]string{}
+	for _, project := range projects {
+		query.ProjectIDs[project.ID] = true
+		names[project.ID] = project.Name
+		// projects created before the index existed are picked up the first time a member searches
+		if _, ok := s.index.Get(index.ProjectDocument, project.ID); !ok {
+			s.index.Put(index.Document{ID: project.ID, Type: index.ProjectDocument, ProjectID: project.ID, Title: project.Name})
+		}
+	}
+
+	result := s.index.Search(query)
+	response := searchResponse{Result: result, Projects: map[string]string{}}
+	for projectID := range result.Facets.Projects {
+		response.Projects[projectID] = names[projectID]
+	}
+	s.custLogger.Info(logrus.Fields{"query": text, "total": result.Total}, "Search executed")
+
+	if err := json.NewEncoder(rw).Encode(response); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully searched")
+}
+
+func (s *SearchHandler) MiddlewareContentTypeSet(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, h *http.Request) {
+		rw.Header().Add("Content-Type", "application/json")
+		next.ServeHTTP(rw, h)
+	})
+}
+
+func ExtractTraceInfoMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
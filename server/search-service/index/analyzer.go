@@ -0,0 +1,76 @@
+package index
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is a normalized term together with its byte range in the original text
+type token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// foldedRunes maps letters with diacritics to their plain form, so "cas" finds "čas"
+var foldedRunes = map[rune]rune{
+	'č': 'c', 'ć': 'c', 'š': 's', 'ž': 'z', 'đ': 'd',
+	'á': 'a', 'à': 'a', 'ä': 'a', 'â': 'a',
+	'é': 'e', 'è': 'e', 'ë': 'e', 'ê': 'e',
+	'í': 'i', 'ì': 'i', 'ï': 'i', 'î': 'i',
+	'ó': 'o', 'ò': 'o', 'ö': 'o', 'ô': 'o',
+	'ú': 'u', 'ù': 'u', 'ü': 'u', 'û': 'u',
+	'ñ': 'n', 'ç': 'c',
+}
+
+// analyze splits text into lower-cased, diacritic-folded terms made of letters and digits
+func analyze(text string) []token {
+	var tokens []token
+	var term strings.Builder
+	start := -1
+
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{Term: term.String(), Start: start, End: end})
+			term.Reset()
+			start = -1
+		}
+	}
+
+	for i, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush(i)
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		r = unicode.ToLower(r)
+		if folded, ok := foldedRunes[r]; ok {
+			r = folded
+		}
+		term.WriteRune(r)
+	}
+	flush(len(text))
+	return tokens
+}
+
+// terms returns the distinct terms of a query in the order they appear
+func terms(text string) []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, t := range analyze(text) {
+		if !seen[t.Term] {
+			seen[t.Term] = true
+			result = append(result, t.Term)
+		}
+	}
+	return result
+}
+
+// isPrefixQuery tells whether the last query term is still being typed
+func isPrefixQuery(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,181 @@
+package index
+
+import (
+	"sync"
+	"time"
+)
+
+type DocumentType string
+
+const (
+	ProjectDocument  = DocumentType("project")
+	TaskDocument     = DocumentType("task")
+	CommentDocument  = DocumentType("comment")
+	AttachedDocument = DocumentType("document")
+)
+
+// Document is one searchable entity. Title holds the project or task name or the file name,
+// Body the task description, the comment text or the text extracted from a file.
+type Document struct {
+	ID        string       `json:"id"`
+	Type      DocumentType `json:"type"`
+	ProjectID string       `json:"projectId"`
+	TaskID    string       `json:"taskId,omitempty"`
+	Title     string       `json:"title"`
+	Body      string       `json:"body,omitempty"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+func (d *Document) key() string {
+	return documentKey(d.Type, d.ID)
+}
+
+func documentKey(documentType DocumentType, id string) string {
+	return string(documentType) + ":" + id
+}
+
+// posting counts the occurrences of a term in the fields of one document
+type posting struct {
+	Title int
+	Body  int
+}
+
+// Index is an in-memory inverted index over documents. It is safe for concurrent use.
+type Index struct {
+	mu        sync.RWMutex
+	documents map[string]*Document
+	postings  map[string]map[string]*posting
+	dirty     bool
+}
+
+func New() *Index {
+	return &Index{
+		documents: map[string]*Document{},
+		postings:  map[string]map[string]*posting{},
+	}
+}
+
+// Put adds a document or replaces the stored version of it
+func (ix *Index) Put(document Document) {
+	if document.UpdatedAt.IsZero() {
+		document.UpdatedAt = time.Now()
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	key := document.key()
+	if previous, ok := ix.documents[key]; ok {
+		ix.unindex(key, previous)
+		// fields an event does not carry keep their previous value
+		if document.ProjectID == "" {
+			document.ProjectID = previous.ProjectID
+		}
+		if document.TaskID == "" {
+			document.TaskID = previous.TaskID
+		}
+	}
+	ix.documents[key] = &document
+	ix.add(key, &document)
+	ix.dirty = true
+}
+
+// Get returns a copy of a stored document
+func (ix *Index) Get(documentType DocumentType, id string) (Document, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	document, ok := ix.documents[documentKey(documentType, id)]
+	if !ok {
+		return Document{}, false
+	}
+	return *document, true
+}
+
+// Delete removes a document, it is a no-op for unknown documents
+func (ix *Index) Delete(documentType DocumentType, id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(documentKey(documentType, id))
+}
+
+// DeleteProject removes a project together with its tasks, comments and documents
+func (ix *Index) DeleteProject(projectID string) int {
+	return ix.deleteWhere(func(d *Document) bool {
+		return d.ProjectID == projectID
+	})
+}
+
+// DeleteTask removes a task together with its comments and documents
+func (ix *Index) DeleteTask(taskID string) int {
+	return ix.deleteWhere(func(d *Document) bool {
+		return (d.Type == TaskDocument && d.ID == taskID) || d.TaskID == taskID
+	})
+}
+
+// Len returns the number of indexed documents
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.documents)
+}
+
+func (ix *Index) deleteWhere(match func(*Document) bool) int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	count := 0
+	for key, document := range ix.documents {
+		if match(document) {
+			ix.remove(key)
+			count++
+		}
+	}
+	return count
+}
+
+func (ix *Index) remove(key string) {
+	document, ok := ix.documents[key]
+	if !ok {
+		return
+	}
+	ix.unindex(key, document)
+	delete(ix.documents, key)
+	ix.dirty = true
+}
+
+func (ix *Index) add(key string, document *Document) {
+	for _, t := range analyze(document.Title) {
+		ix.posting(t.Term, key).Title++
+	}
+	for _, t := range analyze(document.Body) {
+		ix.posting(t.Term, key).Body++
+	}
+}
+
+func (ix *Index) posting(term, key string) *posting {
+	documents, ok := ix.postings[term]
+	if !ok {
+		documents = map[string]*posting{}
+		ix.postings[term] = documents
+	}
+	p, ok := documents[key]
+	if !ok {
+		p = &posting{}
+		documents[key] = p
+	}
+	return p
+}
+
+func (ix *Index) unindex(key string, document *Document) {
+	for _, t := range analyze(document.Title + " " + document.Body) {
+		documents, ok := ix.postings[t.Term]
+		if !ok {
+			continue
+		}
+		delete(documents, key)
+		if len(documents) == 0 {
+			delete(ix.postings, t.Term)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package index
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	titleBoost     = 2.0
+	minPrefixLen   = 2
+	snippetRunes   = 160
+	highlightOpen  = "<mark>"
+	highlightClose = "</mark>"
+)
+
+// Query describes a search. Every term of Text has to match; while the last term is still
+// being typed it also matches longer terms. Only documents of the given projects are visible.
+type Query struct {
+	Text       string
+	ProjectIDs map[string]bool
+	Types      []DocumentType
+	ProjectID  string
+	Offset     int
+	Limit      int
+}
+
+// Hit is a matching document with its score and the highlighted fragments that matched
+type Hit struct {
+	Document
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// Facets count the visible matches per document type and per project. They ignore the type
+// and project filters of the query, so they tell how many results each filter would return.
+type Facets struct {
+	Types    map[DocumentType]int `json:"types"`
+	Projects map[string]int       `json:"projects"`
+}
+
+type Result struct {
+	Total  int    `json:"total"`
+	Hits   []Hit  `json:"hits"`
+	Facets Facets `json:"facets"`
+}
+
+// Search returns the page of documents that match the query, best matches first
+func (ix *Index) Search(query Query) Result {
+	result := Result{
+		Hits: []Hit{},
+		Facets: Facets{
+			Types:    map[DocumentType]int{},
+			Projects: map[string]int{},
+		},
+	}
+
+	queryTerms := terms(query.Text)
+	if len(queryTerms) == 0 {
+		return result
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	// every query term expands to the indexed terms it matches
+	expanded := make([][]string, len(queryTerms))
+	for i, term := range queryTerms {
+		if _, ok := ix.postings[term]; ok {
+			expanded[i] = append(expanded[i], term)
+		}
+		last := i == len(queryTerms)-1
+		if last && isPrefixQuery(query.Text) && utf8.RuneCountInString(term) >= minPrefixLen {
+			for indexed := range ix.postings {
+				if indexed != term && strings.HasPrefix(indexed, term) {
+					expanded[i] = append(expanded[i], indexed)
+				}
+			}
+		}
+		if len(expanded[i]) == 0 {
+			return result
+		}
+	}
+
+	total := float64(len(ix.documents))
+	scores := map[string]float64{}
+	for i, alternatives := range expanded {
+		termScores := map[string]float64{}
+		for _, term := range alternatives {
+			documents := ix.postings[term]
+			idf := math.Log(1 + total/float64(len(documents)))
+			for key, p := range documents {
+				score := idf * (titleBoost*math.Sqrt(float64(p.Title)) + math.Sqrt(float64(p.Body)))
+				if score > termScores[key] {
+					termScores[key] = score
+				}
+			}
+		}
+		if i == 0 {
+			scores = termScores
+			continue
+		}
+		for key := range scores {
+			score, ok := termScores[key]
+			if !ok {
+				delete(scores, key)
+				continue
+			}
+			scores[key] += score
+		}
+	}
+
+	var hits []Hit
+	for key, score := range scores {
+		document := ix.documents[key]
+		if !query.ProjectIDs[document.ProjectID] {
+			continue
+		}
+		result.Facets.Types[document.Type]++
+		result.Facets.Projects[document.ProjectID]++
+
+		if len(query.Types) > 0 && !containsType(query.Types, document.Type) {
+			continue
+		}
+		if query.ProjectID != "" && document.ProjectID != query.ProjectID {
+			continue
+		}
+		hits = append(hits, Hit{Document: *document, Score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !hits[i].UpdatedAt.Equal(hits[j].UpdatedAt) {
+			return hits[i].UpdatedAt.After(hits[j].UpdatedAt)
+		}
+		return hits[i].key() < hits[j].key()
+	})
+
+	result.Total = len(hits)
+	if query.Offset >= len(hits) {
+		return result
+	}
+	end := len(hits)
+	if query.Limit > 0 && query.Offset+query.Limit < end {
+		end = query.Offset + query.Limit
+	}
+
+	matched := map[string]bool{}
+	for _, alternatives := range expanded {
+		for _, term := range alternatives {
+			matched[term] = true
+		}
+	}
+	for _, hit := range hits[query.Offset:end] {
+		hit.Highlights = map[string]string{}
+		if fragment, ok := highlight(hit.Title, matched, false); ok {
+			hit.Highlights["title"] = fragment
+		}
+		if fragment, ok := highlight(hit.Body, matched, true); ok {
+			hit.Highlights["body"] = fragment
+		}
+		hit.Body = ""
+		result.Hits = append(result.Hits, hit)
+	}
+	return result
+}
+
+// highlight wraps the matched terms of text in <mark> tags and HTML-escapes everything else.
+// A snippet is cut around the first match instead of returning the whole text.
+func highlight(text string, matched map[string]bool, snippet bool) (string, bool) {
+	var matches []token
+	for _, t := range analyze(text) {
+		if matched[t.Term] {
+			matches = append(matches, t)
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start, end := 0, len(text)
+	if snippet {
+		start, end = snippetBounds(text, matches[0].Start)
+	}
+
+	var fragment strings.Builder
+	if start > 0 {
+		fragment.WriteString("…")
+	}
+	position := start
+	for _, match := range matches {
+		if match.Start < position || match.End > end {
+			continue
+		}
+		fragment.WriteString(html.EscapeString(text[position:match.Start]))
+		fragment.WriteString(highlightOpen)
+		fragment.WriteString(html.EscapeString(text[match.Start:match.End]))
+		fragment.WriteString(highlightClose)
+		position = match.End
+	}
+	fragment.WriteString(html.EscapeString(text[position:end]))
+	if end < len(text) {
+		fragment.WriteString("…")
+	}
+	return fragment.String(), true
+}
+
+// snippetBounds returns a window of about snippetRunes runes around offset, cut on spaces
+func snippetBounds(text string, offset int) (int, int) {
+	if utf8.RuneCountInString(text) <= snippetRunes {
+		return 0, len(text)
+	}
+
+	start := offset
+	for runes := 0; start > 0 && runes < snippetRunes/3; runes++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	if start > 0 {
+		if space := strings.IndexByte(text[start:offset], ' '); space >= 0 {
+			start += space + 1
+		}
+	}
+
+	end := start
+	for runes := 0; end < len(text) && runes < snippetRunes; runes++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	if end < len(text) {
+		if space := strings.LastIndexByte(text[offset:end], ' '); space > 0 {
+			end = offset + space
+		}
+	}
+	return start, end
+}
+
+func containsType(types []DocumentType, documentType DocumentType) bool {
+	for _, t := range types {
+		if t == documentType {
+			return true
+		}
+	}
+	return false
+}
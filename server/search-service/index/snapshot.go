@@ -0,0 +1,87 @@
+package index
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Load restores the documents of a snapshot written by Save and rebuilds the postings.
+// A missing snapshot leaves the index empty.
+func (ix *Index) Load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open index snapshot: %w", err)
+	}
+	defer file.Close()
+
+	var documents []Document
+	if err := gob.NewDecoder(file).Decode(&documents); err != nil {
+		return fmt.Errorf("failed to decode index snapshot: %w", err)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for i := range documents {
+		document := &documents[i]
+		key := document.key()
+		if previous, ok := ix.documents[key]; ok {
+			ix.unindex(key, previous)
+		}
+		ix.documents[key] = document
+		ix.add(key, document)
+	}
+	return nil
+}
+
+// Save writes the documents to path if they changed since the last save. The snapshot is
+// written next to the target and renamed over it, so a crash never leaves a partial file.
+func (ix *Index) Save(path string) (bool, error) {
+	ix.mu.Lock()
+	if !ix.dirty {
+		ix.mu.Unlock()
+		return false, nil
+	}
+	documents := make([]Document, 0, len(ix.documents))
+	for _, document := range ix.documents {
+		documents = append(documents, *document)
+	}
+	ix.dirty = false
+	ix.mu.Unlock()
+
+	if err := writeSnapshot(path, documents); err != nil {
+		ix.mu.Lock()
+		ix.dirty = true
+		ix.mu.Unlock()
+		return false, err
+	}
+	return true, nil
+}
+
+func writeSnapshot(path string, documents []Document) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create index snapshot: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := gob.NewEncoder(file).Encode(documents); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode index snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write index snapshot: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace index snapshot: %w", err)
+	}
+	return nil
+}
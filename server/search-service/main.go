@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/cors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"search-service/client"
+	"search-service/customLogger"
+	"search-service/handlers"
+	"search-service/index"
+	"time"
+)
+
+const snapshotInterval = time.Minute
+
+func main() {
+	fmt.Println("Search Service is starting...")
+
+	natsURL := os.Getenv("NATS_URL")
+	if natsURL == "" {
+		natsURL = "nats://nats:4222"
+	}
+	nc, err := nats.Connect(natsURL)
+	if err != nil {
+		log.Fatalf("Error connecting to NATS: %v", err)
+	}
+	defer nc.Close()
+
+	port := os.Getenv("PORT")
+	if len(port) == 0 {
+		port = "8080"
+	}
+	indexPath := os.Getenv("SEARCH_INDEX_PATH")
+	if indexPath == "" {
+		indexPath = "/data/search-index.gob"
+	}
+
+	custLogger := customLogger.GetLogger()
+
+	timeoutContext, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	logger := log.New(os.Stdout, "[search-api] ", log.LstdFlags)
+	cfg := os.Getenv("JAEGER_ADDRESS")
+	exp, err := newExporter(cfg)
+	if err != nil {
+		log.Fatalf("Jaeger exporter initialization failed: %v", err)
+	} else {
+		log.Println("Jaeger initialization succeeded")
+	}
+	log.Printf("Using JAEGER_ADDRESS: %s", cfg)
+	tp := newTraceProvider(exp)
+	defer func() { _ = tp.Shutdown(timeoutContext) }()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tracer := tp.Tracer("search-service")
+
+	searchIndex := index.New()
+	if err := searchIndex.Load(indexPath); err != nil {
+		logger.Fatal(err)
+	}
+	logger.Printf("Loaded %d documents from %s", searchIndex.Len(), indexPath)
+
+	projectClient := client.NewProjectClient(os.Getenv("LINK_TO_PROJECT_SERVICE"))
+	searchHandler := handlers.NewSearchHandler(logger, searchIndex, tracer, projectClient, custLogger)
+
+	subscriptions, err := searchHandler.IndexListener(nc)
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to index events: %v", err)
+	}
+	defer func() {
+		for _, sub := range subscriptions {
+			_ = sub.Unsubscribe()
+		}
+	}()
+
+	snapshotContext, stopSnapshots := context.WithCancel(context.Background())
+	defer stopSnapshots()
+	go func() {
+		ticker := time.NewTicker(snapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-snapshotContext.Done():
+				return
+			case <-ticker.C:
+				if _, err := searchIndex.Save(indexPath); err != nil {
+					logger.Println("Error saving index snapshot:", err)
+				}
+			}
+		}
+	}()
+
+	router := mux.NewRouter()
+	router.Use(searchHandler.MiddlewareContentTypeSet)
+	router.Use(handlers.ExtractTraceInfoMiddleware)
+
+	router.HandleFunc("/search", searchHandler.Search).Methods(http.MethodGet)
+
+	corsHandler := cors.New(cors.Options{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"*"},
+		AllowCredentials: true,
+	})
+
+	handler := corsHandler.Handler(router)
+
+	server := http.Server{
+		Addr:         ":" + port,
+		Handler:      handler,
+		IdleTimeout:  120 * time.Second,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logger.Println("Server listening on port", port)
+		if err := server.ListenAndServeTLS("/app/cert.crt", "/app/privat.key"); err != nil && err != http.ErrServerClosed {
+			logger.Fatal(err)
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, os.Kill)
+
+	sig := <-sigCh
+	logger.Println("Received terminate signal, shutting down...", sig)
+
+	if err := server.Shutdown(timeoutContext); err != nil {
+		logger.Fatal("Could not gracefully shutdown the server", err)
+	}
+	if _, err := searchIndex.Save(indexPath); err != nil {
+		logger.Println("Error saving index snapshot:", err)
+	}
+	logger.Println("Server stopped successfully")
+}
+
+func newExporter(address string) (*jaeger.Exporter, error) {
+	if address == "" {
+		return nil, fmt.Errorf("jaeger collector endpoint address is empty")
+	}
+	exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(address)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Jaeger exporter: %w", err)
+	}
+	return exp, nil
+}
+
+func newTraceProvider(exp sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	r, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String("search-service"),
+		),
+	)
+	if err != nil {
+		log.Fatalf("failed to create resource: %v", err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(r),
+	)
+}
@@ -0,0 +1,9 @@
+package client
+
+// CalendarProjectDetails is the part of a project that is rendered into calendar feeds
+type CalendarProjectDetails struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	EndDate string `json:"end_date"`
+	Manager string `json:"manager"`
+}
@@ -0,0 +1,21 @@
+package client
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	CustomFieldText   = "text"
+	CustomFieldNumber = "number"
+	CustomFieldDate   = "date"
+	CustomFieldEnum   = "enum"
+	CustomFieldUser   = "user"
+)
+
+type CustomFieldDetails struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name     string             `bson:"name" json:"name"`
+	Type     string             `bson:"type" json:"type"`
+	Options  []string           `bson:"options" json:"options"`
+	Required bool               `bson:"required" json:"required"`
+}
@@ -0,0 +1,11 @@
+package client
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LabelDetails struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name  string             `bson:"name" json:"name"`
+	Color string             `bson:"color" json:"color"`
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"io"
+	"log"
+	"net/http"
+)
+
+// ProjectClient calls project-service. The requests of a user carry their cookie and go to
+// address, the ones without a user go to internalAddress, the port of project-service that the
+// api gateway does not proxy.
+type ProjectClient struct {
+	address         string
+	internalAddress string
+}
+
+// ErrProjectNotFound is returned when project-service does not know a project or it is being
+// deleted
+var ErrProjectNotFound = errors.New("project not found")
+
+func NewProjectClient(address, internalAddress string) ProjectClient {
+	return ProjectClient{
+		address:         address,
+		internalAddress: internalAddress,
+	}
+}
+
+// GetLabels retrieves the label registry of a project
+func (client ProjectClient) GetLabels(ctx context.Context, projectId string, cookie *http.Cookie) ([]*LabelDetails, error) {
+	var labels []*LabelDetails
+	if err := client.get(ctx, fmt.Sprintf("/projects/%s/labels", projectId), cookie, &labels); err != nil {
+		return nil, fmt.Errorf("error while getting project labels: %w", err)
+	}
+	return labels, nil
+}
+
+// GetCustomFields retrieves the custom field definitions of a project
+func (client ProjectClient) GetCustomFields(ctx context.Context, projectId string, cookie *http.Cookie) ([]*CustomFieldDetails, error) {
+	var fields []*CustomFieldDetails
+	if err := client.get(ctx, fmt.Sprintf("/projects/%s/fields", projectId), cookie, &fields); err != nil {
+		return nil, fmt.Errorf("error while getting project custom fields: %w", err)
+	}
+	return fields, nil
+}
+
+// GetWatchers retrieves the IDs of the users watching a project. The endpoint is internal, so
+// it is also called outside of a user request.
+func (client ProjectClient) GetWatchers(ctx context.Context, projectId string) ([]string, error) {
+	var watchers []string
+	if err := client.getInternal(ctx, fmt.Sprintf("/projects/%s/watchers", projectId), &watchers); err != nil {
+		return nil, fmt.Errorf("error while getting project watchers: %w", err)
+	}
+	return watchers, nil
+}
+
+// GetManagerID retrieves the manager of a project through an internal endpoint
+func (client ProjectClient) GetManagerID(ctx context.Context, projectId string) (string, error) {
+	var manager struct {
+		ManagerID string `json:"managerId"`
+	}
+	if err := client.getInternal(ctx, fmt.Sprintf("/projects/%s/managerId", projectId), &manager); err != nil {
+		return "", fmt.Errorf("error while getting project manager: %w", err)
+	}
+	return manager.ManagerID, nil
+}
+
+// GetUserCalendarProjects retrieves the projects a user manages or is a member of through an
+// internal endpoint, calendar feeds are requested without a cookie
+func (client ProjectClient) GetUserCalendarProjects(ctx context.Context, userId string) ([]*CalendarProjectDetails, error) {
+	var projects []*CalendarProjectDetails
+	if err := client.getInternal(ctx, fmt.Sprintf("/projects/users/%s/calendar", userId), &projects); err != nil {
+		return nil, fmt.Errorf("error while getting calendar projects: %w", err)
+	}
+	return projects, nil
+}
+
+// GetCalendarProject retrieves a single project for a project calendar feed through an internal
+// endpoint
+func (client ProjectClient) GetCalendarProject(ctx context.Context, projectId string) (*CalendarProjectDetails, error) {
+	var project CalendarProjectDetails
+	if err := client.getInternal(ctx, fmt.Sprintf("/projects/%s/calendar", projectId), &project); err != nil {
+		return nil, fmt.Errorf("error while getting calendar project: %w", err)
+	}
+	return &project, nil
+}
+
+func (client ProjectClient) get(ctx context.Context, path string, cookie *http.Cookie, out interface{}) error {
+	return client.request(ctx, client.address+path, cookie, out)
+}
+
+// getInternal calls an endpoint of the internal port, which takes no cookie
+func (client ProjectClient) getInternal(ctx context.Context, path string, out interface{}) error {
+	return client.request(ctx, client.internalAddress+path, nil, out)
+}
+
+func (client ProjectClient) request(ctx context.Context, url string, cookie *http.Cookie, out interface{}) error {
+	httpReq, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return errors.New("error while creating the request")
+	}
+
+	if cookie != nil {
+		httpReq.AddCookie(cookie)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	clientProject, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return errors.New("error while creating the request")
+	}
+	res, err := clientProject.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrProjectNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		log.Printf("Received non-OK status code: %d", res.StatusCode)
+		body, _ := io.ReadAll(res.Body)
+		log.Printf("Error response body: %s", string(body))
+		return errors.New(res.Status)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		log.Printf("Error decoding response body: %v", err)
+		return errors.New("error decoding response")
+	}
+	return nil
+}
@@ -10,17 +10,19 @@ import (
 )
 
 type TaskDetails struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ProjectID    string             `bson:"project_id" json:"projectId"`
-	Name         string             `bson:"name" json:"name"`
-	Description  string             `bson:"description" json:"description"`
-	Status       model.TaskStatus   `bson:"status" json:"status"`
-	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
-	UpdatedAt    time.Time          `bson:"updated_at" json:"updatedAt"`
-	UserIDs      []string           `bson:"user_ids" json:"user_ids"`
-	Users        []*UserDetails     `bson:"users" json:"users"` // List of UserDetails
-	Dependencies []string           `bson:"dependencies" json:"dependencies"`
-	Blocked      bool               `bson:"blocked" json:"blocked"`
+	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ProjectID    string                 `bson:"project_id" json:"projectId"`
+	Name         string                 `bson:"name" json:"name"`
+	Description  string                 `bson:"description" json:"description"`
+	Status       model.TaskStatus       `bson:"status" json:"status"`
+	CreatedAt    time.Time              `bson:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time              `bson:"updated_at" json:"updatedAt"`
+	UserIDs      []string               `bson:"user_ids" json:"user_ids"`
+	Users        []*UserDetails         `bson:"users" json:"users"` // List of UserDetails
+	Dependencies []string               `bson:"dependencies" json:"dependencies"`
+	Blocked      bool                   `bson:"blocked" json:"blocked"`
+	Labels       []string               `bson:"labels" json:"labels"`
+	CustomFields map[string]interface{} `bson:"custom_fields" json:"customFields"`
 }
 
 type TasksDetails []*TaskDetails
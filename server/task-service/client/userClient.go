@@ -110,6 +110,45 @@ func (client UserClient) GetByIdsWithCookies(ids []string, cookie *http.Cookie)
 	return users, nil
 }
 
+// GetMembersWithCookies retrieves all users with the member role
+func (client UserClient) GetMembersWithCookies(cookie *http.Cookie) ([]*UserDetails, error) {
+	requestURL := client.address + "/members"
+	httpReq, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		log.Printf("Error creating HTTP request: %v", err)
+		return nil, errors.New("error while creating the request")
+	}
+
+	httpReq.AddCookie(cookie)
+	otel.GetTextMapPropagator().Inject(context.Background(), propagation.HeaderCarrier(httpReq.Header))
+
+	clientTask, err := createTLSClient()
+	if err != nil {
+		log.Printf("Error creating TLS client: %v", err)
+		return nil, errors.New("error while creating the request")
+	}
+	res, err := clientTask.Do(httpReq)
+	if err != nil {
+		log.Printf("Error sending request: %v", err)
+		return nil, errors.New("error while sending the request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		log.Printf("Received non-OK status code: %d", res.StatusCode)
+		return nil, fmt.Errorf("error while getting members: %s", res.Status)
+	}
+
+	var users []*UserDetails
+	err = json.NewDecoder(res.Body).Decode(&users)
+	if err != nil {
+		log.Printf("Error decoding response body: %v", err)
+		return nil, errors.New("error decoding response for members")
+	}
+
+	return users, nil
+}
+
 func createTLSClient() (*http.Client, error) {
 	caCert, err := ioutil.ReadFile("/app/cert.crt")
 	if err != nil {
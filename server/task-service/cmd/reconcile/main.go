@@ -0,0 +1,90 @@
+// Command reconcile compares the document store with the task documents and upload sessions,
+// the same way task-service does every day, and prints what it found as JSON.
+//
+//	go run ./cmd/reconcile
+//	go run ./cmd/reconcile -repair
+//
+// It reads the configuration of task-service from the environment, MONGO_DB_URI and the
+// document store and scanner settings. Without -repair it only reports what would be
+// repaired, like the service does unless DOCUMENT_RECONCILE is set to repair.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"task--service/handlers"
+	"task--service/repositories"
+	"task--service/scanner"
+	"task--service/storage"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+func main() {
+	repair := flag.Bool("repair", false, "remove orphans and flag missing files, only report what would be repaired when not set")
+	grace := flag.Duration("grace", 24*time.Hour, "how old orphan files and documents without a task have to be to be removed")
+	timeout := flag.Duration("timeout", time.Hour, "how long the reconciliation may take")
+	flag.Parse()
+
+	if *grace < 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	logger := log.New(os.Stderr, "[task-reconcile] ", log.LstdFlags)
+	tracer := otel.Tracer("task-reconcile")
+
+	store, err := repositories.New(ctx, logger, tracer)
+	if err != nil {
+		fail(err)
+	}
+	defer store.Disconnect(context.Background())
+
+	taskDocStore, err := repositories.NewTaskDocumentRepository(ctx, logger, tracer)
+	if err != nil {
+		fail(err)
+	}
+	defer taskDocStore.Disconnect(context.Background())
+
+	uploadSessionStore, err := repositories.NewUploadSessionRepository(ctx, logger, tracer)
+	if err != nil {
+		fail(err)
+	}
+	defer uploadSessionStore.Disconnect(context.Background())
+
+	documentStore, err := storage.NewDocumentStore(logger, tracer)
+	if err != nil {
+		fail(err)
+	}
+	defer documentStore.Close()
+
+	documentScanner, err := scanner.NewScanner(logger)
+	if err != nil {
+		fail(err)
+	}
+
+	handler := handlers.NewReconcileHandler(logger, store, taskDocStore, uploadSessionStore, documentStore, documentScanner, tracer)
+	report, err := handler.ReconcileDocuments(ctx, handlers.ReconcileOptions{DryRun: !*repair, Grace: *grace})
+	if err != nil {
+		fail(err)
+	}
+
+	result, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fail(err)
+	}
+	fmt.Println(string(result))
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,74 @@
+package extract
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// readerAt reads a file at offsets by seeking it, so that a zip archive can be read from the
+// document store without loading it into memory
+type readerAt struct {
+	mu sync.Mutex
+	r  io.ReadSeeker
+}
+
+func (ra *readerAt) ReadAt(p []byte, off int64) (int, error) {
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	if _, err := ra.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(ra.r, p)
+}
+
+// docxToText reads the paragraphs of the main part of a Word document
+func docxToText(r io.ReadSeeker, size int64) (string, error) {
+	archive, err := zip.NewReader(&readerAt{r: r}, size)
+	if err != nil {
+		return "", fmt.Errorf("failed to open Word document: %w", err)
+	}
+	part, err := archive.Open("word/document.xml")
+	if err != nil {
+		return "", fmt.Errorf("failed to open Word document: %w", err)
+	}
+	defer part.Close()
+
+	decoder := xml.NewDecoder(part)
+	var text textBuilder
+	inText := false
+	for !text.full() {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read Word document: %w", err)
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.add("\t")
+			case "br", "cr":
+				text.add("\n")
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.add("\n")
+			}
+		case xml.CharData:
+			if inText {
+				text.add(string(element))
+			}
+		}
+	}
+	return text.String(), nil
+}
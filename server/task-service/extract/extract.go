@@ -0,0 +1,186 @@
+// Package extract pulls the plain text out of document files so that their contents can be
+// searched: text, Markdown, CSV and HTML files, Word documents and the text layer of PDFs.
+package extract
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// MaxTextBytes is how much text is kept of a document, the rest is cut off
+const MaxTextBytes = 100 << 10
+
+const docxType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// ErrUnsupported is returned for files whose text cannot be extracted
+var ErrUnsupported = errors.New("text cannot be extracted from this type of file")
+
+type format int
+
+const (
+	unsupported format = iota
+	plainText
+	htmlText
+	docxText
+	pdfText
+)
+
+func formatOf(contentType, fileName string) format {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+	extension := strings.ToLower(path.Ext(fileName))
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return htmlText
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json":
+		return plainText
+	case mediaType == docxType || (mediaType == "application/zip" && extension == ".docx"):
+		return docxText
+	case mediaType == "application/pdf":
+		return pdfText
+	default:
+		return unsupported
+	}
+}
+
+// Supported reports whether text can be extracted from a file of the given type and name
+func Supported(contentType, fileName string) bool {
+	return formatOf(contentType, fileName) != unsupported
+}
+
+// Text extracts the text of a file of size bytes. The result is valid UTF-8 with runs of
+// blank lines collapsed, and at most MaxTextBytes long.
+func Text(r io.ReadSeeker, size int64, contentType, fileName string) (string, error) {
+	var text string
+	var err error
+	switch formatOf(contentType, fileName) {
+	case plainText:
+		var data []byte
+		data, err = io.ReadAll(io.LimitReader(r, MaxTextBytes))
+		text = string(data)
+	case htmlText:
+		text, err = htmlToText(r)
+	case docxText:
+		text, err = docxToText(r, size)
+	case pdfText:
+		text, err = pdfToText(r, size)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, contentType)
+	}
+	if err != nil {
+		return "", err
+	}
+	return tidy(text), nil
+}
+
+// textBuilder collects text up to MaxTextBytes
+type textBuilder struct {
+	strings.Builder
+}
+
+func (b *textBuilder) full() bool {
+	return b.Len() >= MaxTextBytes
+}
+
+func (b *textBuilder) add(s string) {
+	if !b.full() {
+		b.WriteString(s)
+	}
+}
+
+// skippedElements hold no text a reader of the page sees
+var skippedElements = map[string]bool{"script": true, "style": true, "noscript": true, "template": true, "head": true}
+
+// blockElements start on a new line
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "pre": true, "blockquote": true, "section": true, "article": true,
+	"table": true, "ul": true, "ol": true, "hr": true, "title": true,
+}
+
+func htmlToText(r io.Reader) (string, error) {
+	tokenizer := html.NewTokenizer(bufio.NewReader(r))
+	var text textBuilder
+	skipping := 0
+	for !text.full() {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return "", fmt.Errorf("failed to read HTML: %w", err)
+			}
+			return text.String(), nil
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if skippedElements[string(name)] {
+				skipping++
+			} else if blockElements[string(name)] {
+				text.add("\n")
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if skippedElements[string(name)] && skipping > 0 {
+				skipping--
+			} else if blockElements[string(name)] {
+				text.add("\n")
+			}
+		case html.SelfClosingTagToken:
+			if name, _ := tokenizer.TagName(); blockElements[string(name)] {
+				text.add("\n")
+			}
+		case html.TextToken:
+			if skipping == 0 {
+				text.add(string(tokenizer.Text()))
+			}
+		}
+	}
+	return text.String(), nil
+}
+
+// tidy makes text valid UTF-8 without control characters, trims its lines and collapses runs
+// of blank lines and spaces
+func tidy(text string) string {
+	if len(text) > MaxTextBytes {
+		text = text[:MaxTextBytes]
+	}
+	text = strings.ToValidUTF8(text, "")
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r == '\r':
+			return '\n'
+		case unicode.IsControl(r) || r == utf8.RuneError:
+			return -1
+		default:
+			return r
+		}
+	}, text)
+
+	var out strings.Builder
+	blank := true
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			if !blank {
+				out.WriteByte('\n')
+			}
+			blank = true
+			continue
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+		blank = false
+	}
+	return strings.TrimSpace(out.String())
+}
@@ -0,0 +1,364 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// maxPDFSize is the largest PDF whose text is extracted, PDFs are read into memory to find
+// their content streams
+const maxPDFSize = 32 << 20
+
+// minPrintableRatio is how much of the text of a content stream has to be readable for it to
+// be kept. Fonts without a standard encoding show their glyph numbers instead of text.
+const minPrintableRatio = 0.8
+
+// PDFFirstPage extracts the text of the first content stream of a PDF that shows readable
+// text, for a PDF with a text layer that is its first page. The result is tidied like Text.
+func PDFFirstPage(r io.Reader, size int64) (string, error) {
+	text, err := pdfStreamsText(r, size, true)
+	if err != nil {
+		return "", err
+	}
+	return tidy(text), nil
+}
+
+// pdfToText extracts the text layer of a PDF: the strings its content streams show. It reads
+// the standard encodings only, fonts that map their glyphs through a CMap give no text, and
+// scanned pages have no text layer at all.
+func pdfToText(r io.Reader, size int64) (string, error) {
+	return pdfStreamsText(r, size, false)
+}
+
+// pdfStreamsText collects the text of the content streams of a PDF, or only of the first one
+// with readable text
+func pdfStreamsText(r io.Reader, size int64, firstOnly bool) (string, error) {
+	if size > maxPDFSize {
+		return "", fmt.Errorf("%w: PDF is larger than %d MB", ErrUnsupported, maxPDFSize>>20)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxPDFSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return "", fmt.Errorf("failed to read PDF: no PDF header")
+	}
+
+	var text textBuilder
+	for pos := 0; !text.full(); {
+		start := bytes.Index(data[pos:], []byte("stream"))
+		if start < 0 {
+			break
+		}
+		start += pos
+		pos = start + len("stream")
+		if start >= 3 && string(data[start-3:start]) == "end" {
+			continue
+		}
+		end := bytes.Index(data[pos:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		end += pos
+		dictStart := bytes.LastIndex(data[:start], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict := compactDict(data[dictStart:start])
+		body := bytes.TrimLeft(data[pos:end], "\r\n")
+		pos = end + len("endstream")
+
+		content, ok := streamContent(dict, body)
+		if !ok {
+			continue
+		}
+		if streamText := contentText(content); readable(streamText) {
+			text.add(streamText)
+			text.add("\n")
+			if firstOnly {
+				break
+			}
+		}
+	}
+	return text.String(), nil
+}
+
+// compactDict separates the tokens of a dictionary by single spaces, "/Filter[/FlateDecode]"
+// becomes "/Filter [ /FlateDecode ]"
+func compactDict(dict []byte) string {
+	spaced := strings.NewReplacer("/", " /", "[", " [ ", "]", " ] ", "<<", " << ", ">>", " >> ").Replace(string(dict))
+	return " " + strings.Join(strings.Fields(spaced), " ") + " "
+}
+
+// streamContent decodes a stream that may hold page content. Images, fonts, cross reference
+// and object streams and filters other than Flate are skipped.
+func streamContent(dict string, body []byte) ([]byte, bool) {
+	for _, skipped := range []string{"/Subtype /Image ", "/Type /XRef ", "/Type /ObjStm ", "/Length1 ", "/Length2 ", "/Subtype /Type1C ", "/Subtype /CIDFontType0C ", "/Subtype /OpenType "} {
+		if strings.Contains(dict, skipped) {
+			return nil, false
+		}
+	}
+	if !strings.Contains(dict, " /Filter ") {
+		return body, true
+	}
+	filter := dict[strings.Index(dict, " /Filter ")+len(" /Filter "):]
+	if !strings.HasPrefix(filter, "/FlateDecode ") && !strings.HasPrefix(filter, "[ /FlateDecode ] ") {
+		return nil, false
+	}
+	reader, err := zlib.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, false
+	}
+	defer reader.Close()
+	// a stream cut short still gives the text before the cut
+	content, _ := io.ReadAll(io.LimitReader(reader, maxPDFSize))
+	return content, len(content) > 0
+}
+
+// contentText reads the text showing operators of a content stream
+func contentText(content []byte) string {
+	var text strings.Builder
+	var operands []interface{}
+	var array []interface{}
+	inArray := false
+	lastY := 0.0
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case isPDFSpace(c):
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			s, next := literalString(content, i)
+			i = next
+			if inArray {
+				array = append(array, s)
+			} else {
+				operands = append(operands, s)
+			}
+		case c == '<' && i+1 < len(content) && content[i+1] == '<':
+			i += 2
+		case c == '>' && i+1 < len(content) && content[i+1] == '>':
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return text.String()
+			}
+			s := hexString(content[i+1 : i+end])
+			i += end + 1
+			if inArray {
+				array = append(array, s)
+			} else {
+				operands = append(operands, s)
+			}
+		case c == '[':
+			inArray, array = true, nil
+			i++
+		case c == ']':
+			inArray = false
+			operands = append(operands, array)
+			i++
+		case c == '/':
+			i++
+			for i < len(content) && !isPDFSpace(content[i]) && !isPDFDelimiter(content[i]) {
+				i++
+			}
+			operands = append(operands, nil)
+		default:
+			start := i
+			for i < len(content) && !isPDFSpace(content[i]) && !isPDFDelimiter(content[i]) {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			word := string(content[start:i])
+			if number, err := strconv.ParseFloat(word, 64); err == nil {
+				if inArray {
+					array = append(array, number)
+				} else {
+					operands = append(operands, number)
+				}
+				continue
+			}
+
+			switch word {
+			case "Tj":
+				text.WriteString(lastString(operands))
+			case "'", "\"":
+				text.WriteString("\n" + lastString(operands))
+			case "TJ":
+				if len(operands) > 0 {
+					if parts, ok := operands[len(operands)-1].([]interface{}); ok {
+						for _, part := range parts {
+							switch part := part.(type) {
+							case string:
+								text.WriteString(part)
+							case float64:
+								// a wide gap between two strings is a space between words
+								if part < -200 {
+									text.WriteString(" ")
+								}
+							}
+						}
+					}
+				}
+			case "Td", "TD":
+				if len(operands) >= 2 {
+					if y, ok := operands[len(operands)-1].(float64); ok && y != 0 {
+						text.WriteString("\n")
+					} else {
+						text.WriteString(" ")
+					}
+				}
+			case "Tm":
+				if len(operands) >= 6 {
+					if y, ok := operands[len(operands)-1].(float64); ok && y != lastY {
+						lastY = y
+						text.WriteString("\n")
+					} else {
+						text.WriteString(" ")
+					}
+				}
+			case "T*", "ET":
+				text.WriteString("\n")
+			case "ID":
+				// the binary data of an inline image runs up to EI
+				end := bytes.Index(content[i:], []byte("EI"))
+				if end < 0 {
+					return text.String()
+				}
+				i += end + 2
+			}
+			operands = operands[:0]
+		}
+	}
+	return text.String()
+}
+
+func lastString(operands []interface{}) string {
+	if len(operands) > 0 {
+		if s, ok := operands[len(operands)-1].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// literalString reads the string in parentheses that starts at i and returns the index after
+// it
+func literalString(content []byte, i int) (string, int) {
+	var s []byte
+	depth := 0
+	for i++; i < len(content); i++ {
+		c := content[i]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return decodePDFString(s), i + 1
+			}
+			depth--
+		case '\\':
+			i++
+			if i >= len(content) {
+				break
+			}
+			switch escaped := content[i]; escaped {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// a line continuation
+				if escaped == '\r' && i+1 < len(content) && content[i+1] == '\n' {
+					i++
+				}
+				continue
+			default:
+				if escaped >= '0' && escaped <= '7' {
+					value := 0
+					for digits := 0; digits < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7'; digits++ {
+						value = value*8 + int(content[i]-'0')
+						i++
+					}
+					i--
+					c = byte(value)
+				} else {
+					c = escaped
+				}
+			}
+		}
+		s = append(s, c)
+	}
+	return decodePDFString(s), i
+}
+
+func hexString(digits []byte) string {
+	clean := strings.Join(strings.Fields(string(digits)), "")
+	if len(clean)%2 == 1 {
+		clean += "0"
+	}
+	s, err := hex.DecodeString(clean)
+	if err != nil {
+		return ""
+	}
+	return decodePDFString(s)
+}
+
+// decodePDFString reads a string as UTF-16 when it has a byte order mark and as Latin-1,
+// which the standard encodings agree with for text, otherwise
+func decodePDFString(s []byte) string {
+	if len(s) >= 2 && s[0] == 0xfe && s[1] == 0xff {
+		units := make([]uint16, 0, len(s)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(s))
+	for i, b := range s {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// readable tells whether text is mostly letters, digits, punctuation and spaces
+func readable(text string) bool {
+	total, printable := 0, 0
+	for _, r := range text {
+		total++
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsPunct(r) || unicode.IsSpace(r) || unicode.IsSymbol(r) {
+			printable++
+		}
+	}
+	return total > 0 && float64(printable) >= minPrintableRatio*float64(total) && strings.TrimSpace(text) != ""
+}
@@ -3,7 +3,6 @@ module task--service
 go 1.22.2
 
 require (
-	github.com/colinmarc/hdfs v1.1.3
 	github.com/colinmarc/hdfs/v2 v2.4.0
 	github.com/eapache/go-resiliency v1.7.0
 	github.com/google/uuid v1.6.0
@@ -17,6 +16,7 @@ require (
 	go.opentelemetry.io/otel/exporters/jaeger v1.17.0
 	go.opentelemetry.io/otel/sdk v1.32.0
 	go.opentelemetry.io/otel/trace v1.32.0
+	golang.org/x/net v0.21.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 )
 
@@ -42,7 +42,6 @@ require (
 	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
 	go.opentelemetry.io/otel/metric v1.32.0 // indirect
 	golang.org/x/crypto v0.26.0 // indirect
-	golang.org/x/net v0.21.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/sys v0.27.0 // indirect
 	golang.org/x/text v0.17.0 // indirect
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icalDateTimeFormat = "20060102T150405Z"
+	icalDateFormat     = "20060102"
+	icalMaxLineOctets  = 75
+)
+
+var icalTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// icalWriter builds an iCalendar (RFC 5545) document
+type icalWriter struct {
+	buf bytes.Buffer
+}
+
+// property writes a content line whose value is already in iCalendar format
+func (w *icalWriter) property(name, value string) {
+	w.fold(name + ":" + value)
+}
+
+// text writes a content line with a free text value
+func (w *icalWriter) text(name, value string) {
+	w.property(name, icalTextEscaper.Replace(value))
+}
+
+func (w *icalWriter) dateTime(name string, value time.Time) {
+	w.property(name, value.UTC().Format(icalDateTimeFormat))
+}
+
+func (w *icalWriter) date(name string, value time.Time) {
+	w.property(name+";VALUE=DATE", value.Format(icalDateFormat))
+}
+
+// fold splits lines longer than 75 octets into continuation lines without breaking up
+// multi-byte characters
+func (w *icalWriter) fold(line string) {
+	limit := icalMaxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.buf.WriteString(line[:cut])
+		w.buf.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of a continuation line counts towards its length
+		limit = icalMaxLineOctets - 1
+	}
+	w.buf.WriteString(line)
+	w.buf.WriteString("\r\n")
+}
+
+func (w *icalWriter) Bytes() []byte {
+	return w.buf.Bytes()
+}
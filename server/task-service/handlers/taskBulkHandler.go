@@ -0,0 +1,476 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"task--service/model"
+	"task--service/repositories"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const maxBulkTasks = 500
+
+type bulkTaskAction string
+
+const (
+	bulkSetStatus    = bulkTaskAction("status")
+	bulkAddMember    = bulkTaskAction("addMember")
+	bulkRemoveMember = bulkTaskAction("removeMember")
+	bulkAddLabel     = bulkTaskAction("addLabel")
+	bulkRemoveLabel  = bulkTaskAction("removeLabel")
+	bulkDelete       = bulkTaskAction("delete")
+)
+
+const (
+	bulkItemOk      = "ok"
+	bulkItemSkipped = "skipped"
+	bulkItemFailed  = "failed"
+)
+
+// bulkTaskRequest applies one action to the listed tasks or to the tasks matching the filter
+type bulkTaskRequest struct {
+	TaskIDs []string         `json:"taskIds"`
+	Filter  *bulkTaskFilter  `json:"filter"`
+	Action  bulkTaskAction   `json:"action"`
+	Status  model.TaskStatus `json:"status"`
+	UserID  string           `json:"userId"`
+	Label   string           `json:"label"`
+}
+
+type bulkTaskFilter struct {
+	ProjectID string             `json:"projectId"`
+	Statuses  []model.TaskStatus `json:"status"`
+	Assignee  string             `json:"assignee"`
+	Labels    []string           `json:"labels"`
+	Blocked   *bool              `json:"blocked"`
+}
+
+type bulkTaskResult struct {
+	TaskID string `json:"taskId"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+type bulkTaskResponse struct {
+	Applied bool              `json:"applied"`
+	Results []*bulkTaskResult `json:"results"`
+}
+
+// bulkProjectAccess caches what the caller may do in a project during one bulk request
+type bulkProjectAccess struct {
+	member  bool
+	manager bool
+	labels  map[string]string
+	users   map[string]bool
+}
+
+// BulkUpdateTasks applies a status change, member or label change or deletion to many tasks.
+// Every item is validated before anything is written; if one item is invalid nothing is
+// applied and the per-item results tell which ones failed. Items that already are in the
+// requested state are skipped.
+func (t *TasksHandler) BulkUpdateTasks(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.BulkUpdateTasks")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	var request bulkTaskRequest
+	if err := json.NewDecoder(h.Body).Decode(&request); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := request.validate(); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Filter != nil && request.Filter.Assignee == "me" {
+		request.Filter.Assignee = userID
+	}
+
+	tasks, results, err := t.findBulkTasks(ctx, &request)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to fetch tasks", http.StatusInternalServerError)
+		return
+	}
+	if len(tasks)+len(results) > maxBulkTasks {
+		http.Error(rw, fmt.Sprintf("at most %d tasks can be changed at once", maxBulkTasks), http.StatusBadRequest)
+		return
+	}
+
+	access := map[string]*bulkProjectAccess{}
+	var targets []model.Task
+	for _, task := range tasks {
+		result := t.validateBulkItem(ctx, h, &request, &task, userID, access)
+		results = append(results, result)
+		if result.Result == bulkItemOk {
+			targets = append(targets, task)
+		}
+	}
+
+	response := bulkTaskResponse{Results: results}
+	for _, result := range results {
+		if result.Result == bulkItemFailed {
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			if err := json.NewEncoder(rw).Encode(response); err != nil {
+				t.logger.Println("Error writing response:", err)
+			}
+			span.SetStatus(codes.Error, "Bulk request has invalid items")
+			return
+		}
+	}
+
+	if err := t.applyBulkAction(ctx, &request, targets, userID, access); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Failed to apply bulk action:", err)
+		for _, result := range results {
+			if result.Result == bulkItemOk {
+				result.Result = bulkItemFailed
+				result.Error = "failed to update task"
+			}
+		}
+		rw.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(rw).Encode(response); err != nil {
+			t.logger.Println("Error writing response:", err)
+		}
+		return
+	}
+	response.Applied = true
+	t.custLogger.Info(logrus.Fields{"action": request.Action, "tasks": len(targets), "userID": userID}, "Bulk task action applied")
+
+	if err := t.emitBulkEvents(ctx, &request, targets, userID, access); err != nil {
+		span.RecordError(err)
+		t.logger.Println("Failed to emit bulk events:", err)
+	}
+
+	if err := json.NewEncoder(rw).Encode(response); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully applied bulk action")
+}
+
+func (r *bulkTaskRequest) validate() error {
+	if len(r.TaskIDs) == 0 && r.Filter == nil {
+		return errors.New("taskIds or filter is required")
+	}
+	if len(r.TaskIDs) > 0 && r.Filter != nil {
+		return errors.New("taskIds and filter cannot be combined")
+	}
+	if r.Filter != nil && r.Filter.ProjectID == "" {
+		return errors.New("filter.projectId is required")
+	}
+	if len(r.TaskIDs) > maxBulkTasks {
+		return fmt.Errorf("at most %d tasks can be changed at once", maxBulkTasks)
+	}
+
+	switch r.Action {
+	case bulkSetStatus:
+		switch r.Status {
+		case model.Pending, model.InProgress, model.Completed:
+		default:
+			return fmt.Errorf("unknown status %q", r.Status)
+		}
+	case bulkAddMember, bulkRemoveMember:
+		if r.UserID == "" {
+			return errors.New("userId is required")
+		}
+	case bulkAddLabel, bulkRemoveLabel:
+		r.Label = strings.TrimSpace(r.Label)
+		if r.Label == "" {
+			return errors.New("label is required")
+		}
+	case bulkDelete:
+	default:
+		return errors.New("action must be status, addMember, removeMember, addLabel, removeLabel or delete")
+	}
+	return nil
+}
+
+// findBulkTasks loads the targeted tasks. Listed IDs that do not exist are reported as failed.
+func (t *TasksHandler) findBulkTasks(ctx context.Context, request *bulkTaskRequest) ([]model.Task, []*bulkTaskResult, error) {
+	results := []*bulkTaskResult{}
+	if request.Filter != nil {
+		tasks, err := t.repo.FindByProject(ctx, request.Filter.ProjectID, repositories.TaskQuery{
+			Statuses: request.Filter.Statuses,
+			Assignee: request.Filter.Assignee,
+			Labels:   request.Filter.Labels,
+			Blocked:  request.Filter.Blocked,
+		})
+		return tasks, results, err
+	}
+
+	tasks, err := t.repo.FindByIDs(ctx, request.TaskIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := map[string]bool{}
+	for _, task := range tasks {
+		found[task.ID.Hex()] = true
+	}
+	seen := map[string]bool{}
+	for _, id := range request.TaskIDs {
+		if !found[id] && !seen[id] {
+			results = append(results, &bulkTaskResult{TaskID: id, Result: bulkItemFailed, Error: "task not found"})
+		}
+		seen[id] = true
+	}
+	return tasks, results, nil
+}
+
+// validateBulkItem checks one task against the same rules as the single-task endpoints
+func (t *TasksHandler) validateBulkItem(ctx context.Context, h *http.Request, request *bulkTaskRequest, task *model.Task, userID string, access map[string]*bulkProjectAccess) *bulkTaskResult {
+	result := &bulkTaskResult{TaskID: task.ID.Hex(), Result: bulkItemOk}
+	fail := func(message string) *bulkTaskResult {
+		result.Result = bulkItemFailed
+		result.Error = message
+		return result
+	}
+	skip := func(message string) *bulkTaskResult {
+		result.Result = bulkItemSkipped
+		result.Error = message
+		return result
+	}
+
+	project := t.bulkProjectAccess(ctx, h, task.ProjectID, userID, access)
+	if !project.member && !project.manager {
+		return fail("user is not part of the project")
+	}
+
+	switch request.Action {
+	case bulkSetStatus:
+		if task.Blocked {
+			return fail("task is blocked and cannot change status")
+		}
+		if !project.manager && !contains(task.UserIDs, userID) {
+			return fail("user is not assigned to the task")
+		}
+		if task.Status == request.Status {
+			return skip("task already has that status")
+		}
+	case bulkAddMember:
+		if _, ok := project.users[request.UserID]; !ok {
+			project.users[request.UserID] = t.isUserInProject(ctx, task.ProjectID, request.UserID)
+		}
+		if !project.users[request.UserID] {
+			return fail("user is not part of the project")
+		}
+		if contains(task.UserIDs, request.UserID) {
+			return skip("user is already a member of this task")
+		}
+	case bulkRemoveMember:
+		if !contains(task.UserIDs, request.UserID) {
+			return skip("user is not a member of this task")
+		}
+		if task.Status == model.Completed {
+			return fail("cannot remove member from a completed task")
+		}
+	case bulkAddLabel:
+		if _, ok := project.labels[request.Label]; !ok {
+			labels, err := t.resolveProjectLabels(ctx, h, task.ProjectID, []string{request.Label})
+			if err != nil {
+				return fail(err.Error())
+			}
+			project.labels[request.Label] = labels[0]
+		}
+		if contains(task.Labels, project.labels[request.Label]) {
+			return skip("task already has that label")
+		}
+	case bulkRemoveLabel:
+		if !contains(task.Labels, request.Label) {
+			return skip("task does not have that label")
+		}
+	case bulkDelete:
+		if !project.manager {
+			return fail("only the project manager can delete tasks")
+		}
+	}
+	return result
+}
+
+func (t *TasksHandler) bulkProjectAccess(ctx context.Context, h *http.Request, projectID, userID string, access map[string]*bulkProjectAccess) *bulkProjectAccess {
+	if project, ok := access[projectID]; ok {
+		return project
+	}
+	project := &bulkProjectAccess{labels: map[string]string{}, users: map[string]bool{}}
+	if cookie, err := h.Cookie("auth_token"); err == nil {
+		project.manager = t.isProjectManager(ctx, projectID, cookie)
+	}
+	if !project.manager {
+		project.member = t.isUserInProject(ctx, projectID, userID)
+	}
+	access[projectID] = project
+	return project
+}
+
+// applyBulkAction writes the change of all targets with a single update per action. Deleted
+// tasks go through the same saga as single deletions, so they are soft deleted first and
+// finished by workflow-service one by one.
+func (t *TasksHandler) applyBulkAction(ctx context.Context, request *bulkTaskRequest, targets []model.Task, userID string, access map[string]*bulkProjectAccess) error {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.applyBulkAction")
+	defer span.End()
+
+	if len(targets) == 0 {
+		span.SetStatus(codes.Ok, "Nothing to apply")
+		return nil
+	}
+	ids := make([]string, 0, len(targets))
+	for _, task := range targets {
+		ids = append(ids, task.ID.Hex())
+	}
+
+	var err error
+	switch request.Action {
+	case bulkSetStatus:
+		if _, err = t.repo.SetStatusMany(ctx, ids, request.Status); err != nil {
+			break
+		}
+		// completing a task unblocks the tasks listed in its dependencies
+		if request.Status == model.Completed {
+			var unblocked []string
+			for _, task := range targets {
+				unblocked = append(unblocked, task.Dependencies...)
+			}
+			if len(unblocked) > 0 {
+				_, err = t.repo.SetBlockedMany(ctx, unblocked, false)
+			}
+		}
+	case bulkAddMember:
+		_, err = t.repo.AddMemberMany(ctx, ids, request.UserID)
+	case bulkRemoveMember:
+		_, err = t.repo.RemoveMemberMany(ctx, ids, request.UserID)
+	case bulkAddLabel:
+		// the label is stored in the spelling of each project's registry
+		byLabel := map[string][]string{}
+		for _, task := range targets {
+			label := access[task.ProjectID].labels[request.Label]
+			byLabel[label] = append(byLabel[label], task.ID.Hex())
+		}
+		for label, labelIDs := range byLabel {
+			if _, err = t.repo.AddLabelMany(ctx, labelIDs, label); err != nil {
+				break
+			}
+		}
+	case bulkRemoveLabel:
+		_, err = t.repo.RemoveLabelMany(ctx, ids, request.Label)
+	case bulkDelete:
+		for i := range targets {
+			if err = t.softDeleteTask(ctx, &targets[i], userID); err != nil {
+				for j := 0; j < i; j++ {
+					t.revertTaskDeletion(ctx, &targets[j])
+				}
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+		for i := range targets {
+			if publishErr := t.publishTaskDeletion(ctx, &targets[i]); publishErr != nil {
+				span.RecordError(publishErr)
+				t.logger.Printf("Error starting deletion of task %s: %v", targets[i].ID.Hex(), publishErr)
+			}
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully applied bulk action")
+	return nil
+}
+
+// emitBulkEvents publishes the NATS messages of the single-task endpoints for every changed
+// task and stores their analytics events with one request
+func (t *TasksHandler) emitBulkEvents(ctx context.Context, request *bulkTaskRequest, targets []model.Task, userID string, access map[string]*bulkProjectAccess) error {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.emitBulkEvents")
+	defer span.End()
+
+	now := time.Now().Add(1 * time.Hour).Format(time.RFC3339)
+	var events []map[string]interface{}
+	addEvent := func(eventType string, task *model.Task, data map[string]interface{}) {
+		events = append(events, map[string]interface{}{
+			"type":      eventType,
+			"time":      now,
+			"event":     data,
+			"projectId": task.ProjectID,
+		})
+	}
+
+	for i := range targets {
+		task := &targets[i]
+		switch request.Action {
+		case bulkSetStatus:
+			t.publishEvent(ctx, "task.status.update", map[string]interface{}{
+				"taskName":   task.Name,
+				"taskStatus": request.Status,
+				"memberIds":  task.UserIDs,
+				"watcherIds": t.watcherIDs(ctx, task),
+			})
+			addEvent("TaskStatusChanged", task, map[string]interface{}{
+				"taskId":    task.ID,
+				"projectId": task.ProjectID,
+				"status":    request.Status,
+				"changedBy": userID,
+				"labels":    task.Labels,
+			})
+		case bulkAddMember, bulkRemoveMember:
+			subject, eventType := "task.joined", "MemberAddedTask"
+			if request.Action == bulkRemoveMember {
+				subject, eventType = "task.removed", "MemberRemovedTask"
+			}
+			t.publishEvent(ctx, subject, map[string]interface{}{
+				"userId":     request.UserID,
+				"taskName":   task.Name,
+				"watcherIds": t.watcherIDs(ctx, task),
+			})
+			addEvent(eventType, task, map[string]interface{}{
+				"memberId": request.UserID,
+				"taskId":   task.ID,
+			})
+		case bulkAddLabel, bulkRemoveLabel:
+			eventType, label := "TaskLabelAdded", access[task.ProjectID].labels[request.Label]
+			if request.Action == bulkRemoveLabel {
+				eventType, label = "TaskLabelRemoved", request.Label
+			}
+			addEvent(eventType, task, map[string]interface{}{
+				"taskId":    task.ID,
+				"projectId": task.ProjectID,
+				"label":     label,
+				"changedBy": userID,
+			})
+		}
+	}
+
+	if err := t.natsConn.Flush(); err != nil {
+		span.RecordError(err)
+		t.logger.Println("Error flushing NATS connection:", err)
+	}
+	if err := t.sendEventsToAnalyticsService(ctx, events); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully emitted bulk events")
+	return nil
+}
@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"task--service/client"
+	"task--service/model"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	calendarUIDDomain      = "task-service"
+	calendarRefreshPeriod  = "PT1H"
+	defaultAppLink         = "https://localhost:4200"
+	defaultPublicTasksLink = "https://localhost/api/task-server"
+)
+
+type calendarFeedRequest struct {
+	ProjectID string `json:"projectId"`
+}
+
+// createdCalendarFeed is the only response that contains the secret URL of a feed
+type createdCalendarFeed struct {
+	*model.CalendarFeed
+	URL string `json:"url"`
+}
+
+// appLink is the address of the web app that calendar entries link back to, configured with
+// LINK_TO_APP
+func appLink() string {
+	if link := os.Getenv("LINK_TO_APP"); link != "" {
+		return link
+	}
+	return defaultAppLink
+}
+
+// calendarFeedURL is the address calendar clients subscribe to. The service sits behind the API
+// gateway, so its public address is configured with PUBLIC_LINK_TO_TASK_SERVICE.
+func calendarFeedURL(token string) string {
+	link := os.Getenv("PUBLIC_LINK_TO_TASK_SERVICE")
+	if link == "" {
+		link = defaultPublicTasksLink
+	}
+	return fmt.Sprintf("%s/tasks/calendar/%s.ics", link, token)
+}
+
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateCalendarFeed issues a new secret feed URL for the caller. Without a project the feed
+// lists the caller's tasks, with one it lists every task of a project the caller manages.
+func (t *TasksHandler) CreateCalendarFeed(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.CreateCalendarFeed")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	var request calendarFeedRequest
+	if err := json.NewDecoder(h.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.ProjectID != "" {
+		cookie, err := h.Cookie("auth_token")
+		if err != nil {
+			http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+			return
+		}
+		if !t.isProjectManager(ctx, request.ProjectID, cookie) {
+			span.SetStatus(codes.Error, "User is not the project manager")
+			http.Error(rw, "Only the project manager can subscribe to the project calendar", http.StatusForbidden)
+			return
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to create calendar feed", http.StatusInternalServerError)
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	feed := &model.CalendarFeed{
+		UserID:    userID,
+		ProjectID: request.ProjectID,
+		TokenHash: hashCalendarToken(token),
+	}
+	if err := t.calendarFeedRepo.Insert(ctx, feed); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to create calendar feed", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"feedID": feed.ID.Hex(), "userID": userID, "projectID": request.ProjectID}, "Calendar feed created")
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(rw).Encode(createdCalendarFeed{CalendarFeed: feed, URL: calendarFeedURL(token)}); err != nil {
+		span.RecordError(err)
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully created calendar feed")
+}
+
+// GetCalendarFeeds lists the active feeds of the caller, their secret URLs are not shown again
+func (t *TasksHandler) GetCalendarFeeds(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetCalendarFeeds")
+	defer span.End()
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	feeds, err := t.calendarFeedRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to fetch calendar feeds", http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(rw).Encode(feeds); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched calendar feeds")
+}
+
+// RevokeCalendarFeed disables a feed of the caller, its URL stops working right away
+func (t *TasksHandler) RevokeCalendarFeed(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.RevokeCalendarFeed")
+	defer span.End()
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	feedID := mux.Vars(h)["feedId"]
+	revoked, err := t.calendarFeedRepo.Revoke(ctx, feedID, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to revoke calendar feed", http.StatusInternalServerError)
+		return
+	}
+	if !revoked {
+		http.Error(rw, "Calendar feed not found", http.StatusNotFound)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"feedID": feedID, "userID": userID}, "Calendar feed revoked")
+
+	rw.WriteHeader(http.StatusNoContent)
+	span.SetStatus(codes.Ok, "Successfully revoked calendar feed")
+}
+
+// GetCalendarFeed renders a feed for calendar clients. They send no cookie, the secret token in
+// the URL is what authenticates the request.
+func (t *TasksHandler) GetCalendarFeed(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetCalendarFeed")
+	defer span.End()
+
+	feed, err := t.calendarFeedRepo.GetActiveByTokenHash(ctx, hashCalendarToken(mux.Vars(h)["token"]))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to fetch calendar feed", http.StatusInternalServerError)
+		return
+	}
+	if feed == nil {
+		http.Error(rw, "Calendar feed not found", http.StatusNotFound)
+		return
+	}
+
+	calendarName, projects, err := t.calendarFeedProjects(ctx, feed)
+	if errors.Is(err, errCalendarFeedUnavailable) {
+		http.Error(rw, "Calendar feed not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		// an empty calendar would make clients drop every project entry, so they retry instead
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error fetching calendar projects:", err)
+		http.Error(rw, "Calendar feed is temporarily unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	tasks, err := t.repo.FindWithDueDate(ctx, feed.UserID, feed.ProjectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to fetch calendar feed", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	rw.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+	if _, err := rw.Write(renderCalendar(calendarName, projects, tasks, time.Now())); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully rendered calendar feed")
+}
+
+var errCalendarFeedUnavailable = errors.New("calendar feed no longer applies")
+
+// calendarFeedProjects returns the name of a feed and the projects whose end dates it shows. A
+// project feed stops working once its owner no longer manages the project.
+func (t *TasksHandler) calendarFeedProjects(ctx context.Context, feed *model.CalendarFeed) (string, []*client.CalendarProjectDetails, error) {
+	if feed.ProjectID == "" {
+		projects, err := t.projectClient.GetUserCalendarProjects(ctx, feed.UserID)
+		return "My tasks", projects, err
+	}
+	project, err := t.projectClient.GetCalendarProject(ctx, feed.ProjectID)
+	if err != nil {
+		return "", nil, err
+	}
+	if project.Manager != feed.UserID {
+		return "", nil, errCalendarFeedUnavailable
+	}
+	return project.Name, []*client.CalendarProjectDetails{project}, nil
+}
+
+// renderCalendar writes tasks as to-dos on their due dates and project end dates as all-day
+// events. UIDs only depend on the task or project, so clients update entries in place.
+func renderCalendar(name string, projects []*client.CalendarProjectDetails, tasks []model.Task, now time.Time) []byte {
+	app := appLink()
+	w := &icalWriter{}
+	w.property("BEGIN", "VCALENDAR")
+	w.property("VERSION", "2.0")
+	w.property("PRODID", "-//task-service//Calendar feed//EN")
+	w.property("CALSCALE", "GREGORIAN")
+	w.property("METHOD", "PUBLISH")
+	w.text("X-WR-CALNAME", name)
+	w.property("REFRESH-INTERVAL;VALUE=DURATION", calendarRefreshPeriod)
+	w.property("X-PUBLISHED-TTL", calendarRefreshPeriod)
+
+	for _, task := range tasks {
+		if task.DueDate == nil {
+			continue
+		}
+		w.property("BEGIN", "VTODO")
+		w.property("UID", fmt.Sprintf("task-%s@%s", task.ID.Hex(), calendarUIDDomain))
+		w.dateTime("DTSTAMP", task.UpdatedAt)
+		w.dateTime("CREATED", task.CreatedAt)
+		w.dateTime("LAST-MODIFIED", task.UpdatedAt)
+		w.dateTime("DUE", *task.DueDate)
+		w.text("SUMMARY", task.Name)
+		if task.Description != "" {
+			w.text("DESCRIPTION", task.Description)
+		}
+		w.property("STATUS", calendarTaskStatus(task.Status))
+		w.property("URL", fmt.Sprintf("%s/project/%s", app, task.ProjectID))
+		w.property("END", "VTODO")
+	}
+
+	for _, project := range projects {
+		endDate, ok := parseProjectEndDate(project.EndDate)
+		if !ok {
+			continue
+		}
+		w.property("BEGIN", "VEVENT")
+		w.property("UID", fmt.Sprintf("project-%s-end@%s", project.ID, calendarUIDDomain))
+		// projects keep no modification time, so the feed is stamped when it is rendered
+		w.dateTime("DTSTAMP", now)
+		w.date("DTSTART", endDate)
+		w.date("DTEND", endDate.AddDate(0, 0, 1))
+		w.text("SUMMARY", fmt.Sprintf("%s ends", project.Name))
+		w.property("TRANSP", "TRANSPARENT")
+		w.property("URL", fmt.Sprintf("%s/project/%s", app, project.ID))
+		w.property("END", "VEVENT")
+	}
+
+	w.property("END", "VCALENDAR")
+	return w.Bytes()
+}
+
+func calendarTaskStatus(status model.TaskStatus) string {
+	switch status {
+	case model.Completed:
+		return "COMPLETED"
+	case model.InProgress:
+		return "IN-PROCESS"
+	default:
+		return "NEEDS-ACTION"
+	}
+}
+
+// parseProjectEndDate reads the end date of a project, the web app stores it as an ISO
+// timestamp of midnight UTC
+func parseProjectEndDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if endDate, err := time.Parse(layout, value); err == nil {
+			return endDate.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
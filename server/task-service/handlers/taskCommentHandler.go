@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"task--service/model"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// mentionPattern matches "@jane.doe" as well as "@jane.doe@example.com"
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.+-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+type taskCommentRequest struct {
+	Body     string `json:"body"`
+	ParentID string `json:"parentId"`
+}
+
+func (t *TasksHandler) PostTaskComment(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.PostTaskComment")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	taskID := mux.Vars(h)["taskId"]
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	var request taskCommentRequest
+	if err := json.NewDecoder(h.Body).Decode(&request); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	request.Body = strings.TrimSpace(request.Body)
+	if request.Body == "" {
+		span.SetStatus(codes.Error, "Comment body is empty")
+		http.Error(rw, "Comment body cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	task, err := t.repo.GetByID(ctx, taskID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	if !t.canAccessProject(ctx, h, task.ProjectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "Only project members can comment", http.StatusForbidden)
+		return
+	}
+
+	if request.ParentID != "" {
+		parentID, err := primitive.ObjectIDFromHex(request.ParentID)
+		if err != nil {
+			http.Error(rw, "Invalid parentId format", http.StatusBadRequest)
+			return
+		}
+		parent, err := t.commentRepo.GetTaskCommentByID(ctx, parentID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			http.Error(rw, "Failed to fetch parent comment", http.StatusInternalServerError)
+			return
+		}
+		if parent == nil || parent.TaskID != taskID {
+			http.Error(rw, "Parent comment not found on this task", http.StatusBadRequest)
+			return
+		}
+	}
+
+	mentions := t.resolveMentions(ctx, h, task.ProjectID, request.Body)
+
+	comment := model.TaskComment{
+		TaskID:    taskID,
+		ProjectID: task.ProjectID,
+		ParentID:  request.ParentID,
+		AuthorID:  userID,
+		Body:      request.Body,
+		Mentions:  mentions,
+	}
+	if err := t.commentRepo.SaveTaskComment(ctx, &comment); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to save comment", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"taskID": taskID, "commentID": comment.ID.Hex()}, "Task comment created")
+
+	t.publishTaskMentioned(ctx, task, &comment, mentions)
+	t.publishCommentSaved(ctx, &comment, task)
+
+	rw.WriteHeader(http.StatusCreated)
+	if err := comment.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully created comment")
+}
+
+func (t *TasksHandler) GetTaskComments(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetTaskComments")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	taskID := mux.Vars(h)["taskId"]
+	task, err := t.repo.GetByID(ctx, taskID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	if !t.canAccessProject(ctx, h, task.ProjectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "Only project members can read comments", http.StatusForbidden)
+		return
+	}
+
+	comments, err := t.commentRepo.GetTaskCommentsByTaskID(ctx, taskID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to fetch task comments", http.StatusInternalServerError)
+		return
+	}
+
+	if err := comments.ToJSON(rw); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to encode task comments", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched task comments")
+}
+
+func (t *TasksHandler) EditTaskComment(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.EditTaskComment")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	commentID, err := primitive.ObjectIDFromHex(mux.Vars(h)["commentId"])
+	if err != nil {
+		http.Error(rw, "Invalid commentId format", http.StatusBadRequest)
+		return
+	}
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	var request taskCommentRequest
+	if err := json.NewDecoder(h.Body).Decode(&request); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	request.Body = strings.TrimSpace(request.Body)
+	if request.Body == "" {
+		http.Error(rw, "Comment body cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := t.commentRepo.GetTaskCommentByID(ctx, commentID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to fetch comment", http.StatusInternalServerError)
+		return
+	}
+	if comment == nil {
+		http.Error(rw, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if comment.AuthorID != userID {
+		span.SetStatus(codes.Error, "User is not the author of the comment")
+		http.Error(rw, "Only the author can edit a comment", http.StatusForbidden)
+		return
+	}
+	if !t.canAccessProject(ctx, h, comment.ProjectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "Only project members can comment", http.StatusForbidden)
+		return
+	}
+
+	task, err := t.repo.GetByID(ctx, comment.TaskID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	previousMentions := comment.Mentions
+	mentions := t.resolveMentions(ctx, h, comment.ProjectID, request.Body)
+
+	if err := t.commentRepo.UpdateTaskCommentBody(ctx, comment, request.Body, mentions); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to update comment", http.StatusInternalServerError)
+		return
+	}
+
+	// only users that were not already mentioned before the edit get notified
+	var newMentions []string
+	for _, id := range mentions {
+		if !contains(previousMentions, id) {
+			newMentions = append(newMentions, id)
+		}
+	}
+	t.publishTaskMentioned(ctx, task, comment, newMentions)
+	t.publishCommentSaved(ctx, comment, nil)
+
+	if err := comment.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully updated comment")
+}
+
+// parseMentions returns the distinct handles mentioned in a comment body, without the leading "@"
+func parseMentions(body string) []string {
+	var handles []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(strings.TrimRight(match[1], "."))
+		if handle != "" && !contains(handles, handle) {
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
+
+// resolveMentions maps "@handle" mentions to user IDs of project members and of the project
+// manager. A handle matches a user's full email address or the part of it before "@".
+func (t *TasksHandler) resolveMentions(ctx context.Context, h *http.Request, projectID, body string) []string {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.resolveMentions")
+	defer span.End()
+
+	handles := parseMentions(body)
+	if len(handles) == 0 {
+		return []string{}
+	}
+
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		span.RecordError(err)
+		return []string{}
+	}
+	users, err := t.userClient.GetMembersWithCookies(cookie)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error resolving mentioned users:", err)
+		return []string{}
+	}
+	// the manager is not a member of the project and has the manager role, which the members
+	// list leaves out
+	managerID, err := t.projectClient.GetManagerID(ctx, projectID)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Println("Error resolving mentioned project manager:", err)
+	} else if managerID != "" {
+		managers, err := t.userClient.GetByIdsWithCookies([]string{managerID}, cookie)
+		if err != nil {
+			span.RecordError(err)
+			t.logger.Println("Error resolving mentioned project manager:", err)
+		}
+		users = append(users, managers...)
+	}
+
+	userIDs := []string{}
+	for _, user := range users {
+		email := strings.ToLower(user.Email)
+		localPart, _, _ := strings.Cut(email, "@")
+		if !contains(handles, email) && !contains(handles, localPart) {
+			continue
+		}
+		userID := user.ID.Hex()
+		if contains(userIDs, userID) {
+			continue
+		}
+		if userID != managerID && !t.isUserInProject(ctx, projectID, userID) {
+			continue
+		}
+		userIDs = append(userIDs, userID)
+	}
+	span.SetStatus(codes.Ok, "Successfully resolved mentions")
+	return userIDs
+}
+
+func (t *TasksHandler) publishTaskMentioned(ctx context.Context, task *model.Task, comment *model.TaskComment, userIDs []string) {
+	_, span := t.tracer.Start(ctx, "TaskHandler.publishTaskMentioned")
+	defer span.End()
+
+	var recipients []string
+	for _, id := range userIDs {
+		if id != comment.AuthorID {
+			recipients = append(recipients, id)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	message := struct {
+		UserIDs     []string `json:"userIds"`
+		TaskID      string   `json:"taskId"`
+		TaskName    string   `json:"taskName"`
+		ProjectID   string   `json:"projectId"`
+		CommentID   string   `json:"commentId"`
+		MentionedBy string   `json:"mentionedBy"`
+	}{
+		UserIDs:     recipients,
+		TaskID:      task.ID.Hex(),
+		TaskName:    task.Name,
+		ProjectID:   task.ProjectID,
+		CommentID:   comment.ID.Hex(),
+		MentionedBy: comment.AuthorID,
+	}
+
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error marshalling message:", err)
+		return
+	}
+
+	if err := t.natsConn.Publish("task.mentioned", jsonMessage); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error publishing message to NATS:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully published task.mentioned")
+}
+
+// publishCommentSaved lets other services pick up the current body of a new or edited comment.
+// A new comment passes its task, so that its members and watchers are notified about it.
+func (t *TasksHandler) publishCommentSaved(ctx context.Context, comment *model.TaskComment, task *model.Task) {
+	message := map[string]interface{}{
+		"commentId": comment.ID.Hex(),
+		"taskId":    comment.TaskID,
+		"projectId": comment.ProjectID,
+		"body":      comment.Body,
+	}
+	if task != nil {
+		message["taskName"] = task.Name
+		message["authorId"] = comment.AuthorID
+		message["mentionIds"] = comment.Mentions
+		message["memberIds"] = task.UserIDs
+		message["watcherIds"] = t.watcherIDs(ctx, task)
+	}
+	t.publishEvent(ctx, "task.comment.saved", message)
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"task--service/client"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const customFieldQueryPrefix = "field."
+
+// errCustomFieldsUnavailable means the field definitions of the project could not be read, so
+// the values of a task cannot be checked
+var errCustomFieldsUnavailable = errors.New("unable to fetch project custom fields")
+
+// writeCustomFieldError answers a request whose custom field values were refused
+func writeCustomFieldError(rw http.ResponseWriter, err error) {
+	if errors.Is(err, errCustomFieldsUnavailable) {
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return
+	}
+	http.Error(rw, err.Error(), http.StatusBadRequest)
+}
+
+// UpdateTaskCustomFields sets the custom field values of a task. The body maps field IDs to
+// values; a null value clears the field.
+func (t *TasksHandler) UpdateTaskCustomFields(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.UpdateTaskCustomFields")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	taskID := mux.Vars(h)["taskId"]
+
+	var values map[string]interface{}
+	if err := json.NewDecoder(h.Body).Decode(&values); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	task, err := t.repo.GetByID(ctx, taskID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !t.canAccessProject(ctx, h, task.ProjectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	definitions, err := t.getCustomFieldDefinitions(ctx, h, task.ProjectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	set := map[string]interface{}{}
+	var unset []string
+	for fieldID, raw := range values {
+		definition := findCustomFieldDefinition(definitions, fieldID)
+		if definition == nil {
+			http.Error(rw, fmt.Sprintf("custom field %s is not defined in the project", fieldID), http.StatusBadRequest)
+			return
+		}
+		if raw == nil {
+			if definition.Required {
+				http.Error(rw, fmt.Sprintf("custom field %q is required", definition.Name), http.StatusBadRequest)
+				return
+			}
+			unset = append(unset, fieldID)
+			continue
+		}
+		value, err := t.normalizeCustomFieldValue(ctx, task.ProjectID, definition, raw)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		set[fieldID] = value
+	}
+
+	if err := t.repo.UpdateCustomFields(ctx, task, set, unset); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to update custom fields", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"taskID": taskID, "set": len(set), "cleared": len(unset)}, "Task custom fields updated")
+
+	if err := task.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully updated custom fields")
+}
+
+// HandleCustomFieldDeleted clears the values of a deleted custom field from the project's tasks
+func (t *TasksHandler) HandleCustomFieldDeleted(ctx context.Context, data []byte) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleCustomFieldDeleted")
+	defer span.End()
+
+	var message struct {
+		ProjectID string `json:"projectId"`
+		FieldID   string `json:"fieldId"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error unmarshalling project.field.deleted message:", err)
+		return
+	}
+
+	count, err := t.repo.ClearCustomFieldInProject(ctx, message.ProjectID, message.FieldID, nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Failed to clear custom field %s in project %s: %v", message.FieldID, message.ProjectID, err)
+		return
+	}
+	t.logger.Printf("Cleared custom field %s on %d tasks of project %s", message.FieldID, count, message.ProjectID)
+	span.SetStatus(codes.Ok, "Successfully cleared custom field")
+}
+
+// HandleCustomFieldOptionsChanged clears enum values that are no longer among the field's options
+func (t *TasksHandler) HandleCustomFieldOptionsChanged(ctx context.Context, data []byte) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleCustomFieldOptionsChanged")
+	defer span.End()
+
+	var message struct {
+		ProjectID string   `json:"projectId"`
+		FieldID   string   `json:"fieldId"`
+		Options   []string `json:"options"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error unmarshalling project.field.options.changed message:", err)
+		return
+	}
+	if message.Options == nil {
+		message.Options = []string{}
+	}
+
+	count, err := t.repo.ClearCustomFieldInProject(ctx, message.ProjectID, message.FieldID, message.Options)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Failed to clear stale options of custom field %s in project %s: %v", message.FieldID, message.ProjectID, err)
+		return
+	}
+	t.logger.Printf("Cleared stale options of custom field %s on %d tasks of project %s", message.FieldID, count, message.ProjectID)
+	span.SetStatus(codes.Ok, "Successfully cleared stale custom field options")
+}
+
+// validateCustomFields checks the values of a new task against the project's field definitions
+// and returns them in their stored form. It fails with errCustomFieldsUnavailable when the
+// definitions cannot be read.
+func (t *TasksHandler) validateCustomFields(ctx context.Context, h *http.Request, projectID string, values map[string]interface{}, creating bool) (map[string]interface{}, error) {
+	definitions, err := t.getCustomFieldDefinitions(ctx, h, projectID)
+	if err != nil {
+		// even without values the required fields of the project have to be known
+		return nil, err
+	}
+
+	normalized := map[string]interface{}{}
+	for fieldID, raw := range values {
+		definition := findCustomFieldDefinition(definitions, fieldID)
+		if definition == nil {
+			return nil, fmt.Errorf("custom field %s is not defined in the project", fieldID)
+		}
+		if raw == nil {
+			continue
+		}
+		value, err := t.normalizeCustomFieldValue(ctx, projectID, definition, raw)
+		if err != nil {
+			return nil, err
+		}
+		normalized[fieldID] = value
+	}
+
+	if creating {
+		for _, definition := range definitions {
+			if _, ok := normalized[definition.ID.Hex()]; definition.Required && !ok {
+				return nil, fmt.Errorf("custom field %q is required", definition.Name)
+			}
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeCustomFieldValue converts a decoded JSON value into the stored form of the field type
+func (t *TasksHandler) normalizeCustomFieldValue(ctx context.Context, projectID string, definition *client.CustomFieldDetails, raw interface{}) (interface{}, error) {
+	switch definition.Type {
+	case client.CustomFieldText:
+		value, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("custom field %q expects text", definition.Name)
+		}
+		return strings.TrimSpace(value), nil
+	case client.CustomFieldNumber:
+		switch value := raw.(type) {
+		case float64:
+			return value, nil
+		case string:
+			number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err == nil {
+				return number, nil
+			}
+		}
+		return nil, fmt.Errorf("custom field %q expects a number", definition.Name)
+	case client.CustomFieldDate:
+		value, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("custom field %q expects a date", definition.Name)
+		}
+		date, err := parseCustomFieldDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %q expects a date like 2024-05-31", definition.Name)
+		}
+		return date, nil
+	case client.CustomFieldEnum:
+		value, ok := raw.(string)
+		if !ok || !contains(definition.Options, value) {
+			return nil, fmt.Errorf("custom field %q expects one of %s", definition.Name, strings.Join(definition.Options, ", "))
+		}
+		return value, nil
+	case client.CustomFieldUser:
+		value, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("custom field %q expects a user ID", definition.Name)
+		}
+		if _, err := primitive.ObjectIDFromHex(value); err != nil {
+			return nil, fmt.Errorf("custom field %q expects a user ID", definition.Name)
+		}
+		if !t.isUserInProject(ctx, projectID, value) {
+			return nil, fmt.Errorf("custom field %q expects a member of the project", definition.Name)
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("custom field %q has unknown type %s", definition.Name, definition.Type)
+}
+
+// customFieldCondition turns a filter value into a condition on the stored field value.
+// Text matches case-insensitively on a substring and dates match the whole day.
+func customFieldCondition(definition *client.CustomFieldDetails, value string) (interface{}, error) {
+	switch definition.Type {
+	case client.CustomFieldText:
+		return primitive.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}, nil
+	case client.CustomFieldNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %q expects a number", definition.Name)
+		}
+		return number, nil
+	case client.CustomFieldDate:
+		date, err := parseCustomFieldDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %q expects a date like 2024-05-31", definition.Name)
+		}
+		day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		return bson.M{"$gte": day, "$lt": day.AddDate(0, 0, 1)}, nil
+	}
+	return value, nil
+}
+
+func (t *TasksHandler) getCustomFieldDefinitions(ctx context.Context, h *http.Request, projectID string) ([]*client.CustomFieldDetails, error) {
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		return nil, errors.New("no token found in cookie")
+	}
+	definitions, err := t.projectClient.GetCustomFields(ctx, projectID, cookie)
+	if err != nil {
+		t.logger.Println("Error fetching project custom fields:", err)
+		return nil, errCustomFieldsUnavailable
+	}
+	return definitions, nil
+}
+
+func findCustomFieldDefinition(definitions []*client.CustomFieldDetails, fieldID string) *client.CustomFieldDetails {
+	for _, definition := range definitions {
+		if definition.ID.Hex() == fieldID {
+			return definition
+		}
+	}
+	return nil
+}
+
+func parseCustomFieldDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return date.UTC(), nil
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"task--service/model"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Deleting a task is a small saga: the task and its documents are soft deleted here, then
+// workflow-service removes the task node and its DEPENDS_ON edges and answers with either
+// TaskWorkflowDeleted, after which the dependencies are cleaned up, or
+// TaskWorkflowDeletionFailed, after which the soft delete is reverted. A deletion or restore
+// that gets no answer, because a message was lost or a service restarted, is started again by a
+// sweep and given up after a few retries: a deletion is reverted, a restore goes back to the
+// trash.
+const (
+	taskDeletionStartedSubject        = "TaskDeletionStarted"
+	taskWorkflowDeletedSubject        = "TaskWorkflowDeleted"
+	taskWorkflowDeletionFailedSubject = "TaskWorkflowDeletionFailed"
+
+	taskSagaSweepInterval = 5 * time.Minute
+	taskSagaTimeout       = 5 * time.Minute
+	taskSagaMaxRetries    = 5
+	taskSagaBatchSize     = 100
+)
+
+var errTaskAlreadyDeleted = errors.New("task is already being deleted")
+
+type taskDeletionMessage struct {
+	TaskID    string `json:"taskId"`
+	ProjectID string `json:"projectId"`
+}
+
+// DeleteTask lets the project manager delete a single task. The deletion finishes
+// asynchronously, so the request is answered with 202 Accepted.
+func (t *TasksHandler) DeleteTask(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.DeleteTask")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+		return
+	}
+
+	task, err := t.repo.GetByID(ctx, mux.Vars(h)["taskId"])
+	if err != nil || task.PendingDeletion {
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !t.isProjectManager(ctx, task.ProjectID, cookie) {
+		span.SetStatus(codes.Error, "User is not the project manager")
+		http.Error(rw, "Only the project manager can delete tasks", http.StatusForbidden)
+		return
+	}
+
+	err = t.startTaskDeletion(ctx, task, userID)
+	if errors.Is(err, errTaskAlreadyDeleted) {
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error deleting task:", err)
+		http.Error(rw, "Failed to delete task", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"taskID": task.ID.Hex(), "deletedBy": userID}, "Task deletion started")
+
+	rw.WriteHeader(http.StatusAccepted)
+	span.SetStatus(codes.Ok, "Successfully started task deletion")
+}
+
+// startTaskDeletion soft deletes the task with its documents and hands it over to
+// workflow-service
+func (t *TasksHandler) startTaskDeletion(ctx context.Context, task *model.Task, userID string) error {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.startTaskDeletion")
+	defer span.End()
+
+	if err := t.softDeleteTask(ctx, task, userID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	if err := t.publishTaskDeletion(ctx, task); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully started task deletion")
+	return nil
+}
+
+// softDeleteTask hides the task and its documents, the task is left untouched on failure
+func (t *TasksHandler) softDeleteTask(ctx context.Context, task *model.Task, userID string) error {
+	marked, err := t.repo.MarkDeleted(ctx, task.ID, userID)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return errTaskAlreadyDeleted
+	}
+	if _, err := t.documentRepo.SetDeletedByTaskID(ctx, task.ID.Hex(), true); err != nil {
+		t.revertTaskDeletion(ctx, task)
+		return fmt.Errorf("failed to delete task documents: %w", err)
+	}
+	return nil
+}
+
+// publishTaskDeletion asks workflow-service to remove the node of a soft deleted task. The
+// soft delete is reverted when the request cannot be published.
+func (t *TasksHandler) publishTaskDeletion(ctx context.Context, task *model.Task) error {
+	if err := t.sendTaskDeletion(task); err != nil {
+		t.revertTaskDeletion(ctx, task)
+		return err
+	}
+	return nil
+}
+
+// sendTaskDeletion publishes TaskDeletionStarted, the sweep uses it to retry without reverting
+func (t *TasksHandler) sendTaskDeletion(task *model.Task) error {
+	message, err := json.Marshal(taskDeletionMessage{TaskID: task.ID.Hex(), ProjectID: task.ProjectID})
+	if err == nil {
+		err = t.natsConn.Publish(taskDeletionStartedSubject, message)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to publish %s: %w", taskDeletionStartedSubject, err)
+	}
+	return nil
+}
+
+// HandleTaskWorkflowDeleted finishes a task deletion once the workflow node is gone: the task
+// is dropped from the dependencies of other tasks and moved to the trash, the tasks that
+// waited for it are unblocked when nothing else holds them and the assignees are notified.
+func (t *TasksHandler) HandleTaskWorkflowDeleted(ctx context.Context, data []byte) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleTaskWorkflowDeleted")
+	defer span.End()
+
+	var message taskDeletionMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Error unmarshalling %s message: %v", taskWorkflowDeletedSubject, err)
+		return
+	}
+	task, err := t.repo.GetByID(ctx, message.TaskID)
+	if err != nil || !task.PendingDeletion {
+		span.SetStatus(codes.Error, "Task is not being deleted")
+		t.logger.Printf("Ignoring %s for task %s that is not being deleted", taskWorkflowDeletedSubject, message.TaskID)
+		return
+	}
+
+	dependencyOf, err := t.repo.RemoveDependencyReferences(ctx, message.TaskID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error removing deleted task from dependencies:", err)
+		return
+	}
+	if err := t.repo.MoveToTrash(ctx, task.ID, dependencyOf); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error moving deleted task to trash:", err)
+		return
+	}
+	unblocked, err := t.repo.RecomputeBlocked(ctx, task.Dependencies)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error recomputing blocked flags:", err)
+		return
+	}
+
+	t.publishEvent(ctx, "task.deleted", map[string]interface{}{
+		"taskId":     message.TaskID,
+		"projectId":  task.ProjectID,
+		"taskName":   task.Name,
+		"memberIds":  task.UserIDs,
+		"deletedBy":  task.DeletedBy,
+		"watcherIds": t.watcherIDs(ctx, task),
+	})
+	event := map[string]interface{}{
+		"type": "TaskDeleted",
+		"time": time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+		"event": map[string]interface{}{
+			"taskId":    task.ID,
+			"projectId": task.ProjectID,
+			"deletedBy": task.DeletedBy,
+		},
+		"projectId": task.ProjectID,
+	}
+	if err := t.sendEventToAnalyticsService(ctx, event); err != nil {
+		span.RecordError(err)
+		t.logger.Println("Error sending TaskDeleted event to analytics service:", err)
+	}
+
+	t.custLogger.Info(logrus.Fields{"taskID": message.TaskID, "unblocked": len(unblocked)}, "Task deleted")
+	span.SetStatus(codes.Ok, "Successfully deleted task")
+}
+
+// HandleTaskWorkflowDeletionFailed compensates a task deletion that workflow-service rejected
+func (t *TasksHandler) HandleTaskWorkflowDeletionFailed(ctx context.Context, data []byte) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleTaskWorkflowDeletionFailed")
+	defer span.End()
+
+	var message taskDeletionMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Error unmarshalling %s message: %v", taskWorkflowDeletionFailedSubject, err)
+		return
+	}
+	task, err := t.repo.GetByID(ctx, message.TaskID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Cannot roll back deletion of task %s: %v", message.TaskID, err)
+		return
+	}
+	t.revertTaskDeletion(ctx, task)
+	t.custLogger.Warn(logrus.Fields{"taskID": message.TaskID}, "Task deletion rolled back")
+	span.SetStatus(codes.Ok, "Successfully rolled back task deletion")
+}
+
+func (t *TasksHandler) revertTaskDeletion(ctx context.Context, task *model.Task) {
+	if _, err := t.documentRepo.SetDeletedByTaskID(ctx, task.ID.Hex(), false); err != nil {
+		t.logger.Printf("Error restoring documents of task %s: %v", task.ID.Hex(), err)
+	}
+	if err := t.repo.UnmarkDeleted(ctx, task.ID); err != nil {
+		t.logger.Printf("Error restoring task %s: %v", task.ID.Hex(), err)
+	}
+}
+
+// RunTaskSagaRecovery starts the deletions and restores that got no answer again until ctx is
+// done
+func (t *TasksHandler) RunTaskSagaRecovery(ctx context.Context) {
+	ticker := time.NewTicker(taskSagaSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		t.recoverStuckTasks(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *TasksHandler) recoverStuckTasks(ctx context.Context) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.recoverStuckTasks")
+	defer span.End()
+
+	tasks, err := t.repo.FindStuckInSaga(ctx, time.Now().Add(-taskSagaTimeout), taskSagaBatchSize)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error fetching tasks stuck in deletion or restore:", err)
+		return
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		restoring := task.RestoringSince != nil
+		if task.SagaRetries >= taskSagaMaxRetries {
+			t.giveUpTaskSaga(ctx, task, restoring)
+			continue
+		}
+		if err := t.repo.CountSagaRetry(ctx, task.ID); err != nil {
+			span.RecordError(err)
+			t.logger.Println("Error counting retry:", err)
+			continue
+		}
+		if restoring {
+			err = t.publishTaskRestore(task)
+		} else {
+			err = t.sendTaskDeletion(task)
+		}
+		if err != nil {
+			span.RecordError(err)
+			t.logger.Printf("Error retrying task %s: %v", task.ID.Hex(), err)
+			continue
+		}
+		t.custLogger.Warn(logrus.Fields{"taskID": task.ID.Hex(), "restoring": restoring, "retry": task.SagaRetries + 1}, "Task deletion or restore started again")
+	}
+	span.SetStatus(codes.Ok, "Successfully retried tasks stuck in deletion or restore")
+}
+
+// giveUpTaskSaga compensates a deletion or restore that never got an answer
+func (t *TasksHandler) giveUpTaskSaga(ctx context.Context, task *model.Task, restoring bool) {
+	if restoring {
+		if _, err := t.repo.SetTrashed(ctx, task.ID, true); err != nil {
+			t.logger.Printf("Error moving task %s back to trash: %v", task.ID.Hex(), err)
+			return
+		}
+		t.custLogger.Warn(logrus.Fields{"taskID": task.ID.Hex()}, "Task restore given up")
+		return
+	}
+	t.revertTaskDeletion(ctx, task)
+	t.custLogger.Warn(logrus.Fields{"taskID": task.ID.Hex()}, "Task deletion given up and reverted")
+}
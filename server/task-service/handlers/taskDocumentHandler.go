@@ -0,0 +1,664 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"task--service/model"
+	"task--service/repositories"
+	"task--service/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	// documentDir is the directory of the document store that uploaded files go to
+	documentDir = "/example/"
+
+	defaultMaxDocumentSize = 512 << 20
+	// documentTransferTimeout replaces the short server timeouts while a file is transferred
+	documentTransferTimeout = 30 * time.Minute
+	maxFormValueSize        = 1 << 10
+)
+
+// maxVersionAttempts is how often adding a version is retried when another upload to the same
+// document wins the race
+const maxVersionAttempts = 3
+
+var (
+	errDocumentTooLarge = errors.New("document is too large")
+	errDocumentNotFound = errors.New("task has no such document")
+	errVersionConflict  = errors.New("document is being changed by another upload")
+)
+
+// maxDocumentSize is the largest file that can be uploaded, configured in megabytes with
+// DOCUMENT_MAX_SIZE_MB
+func maxDocumentSize() int64 {
+	size, err := strconv.ParseInt(os.Getenv("DOCUMENT_MAX_SIZE_MB"), 10, 64)
+	if err != nil || size <= 0 {
+		return defaultMaxDocumentSize
+	}
+	return size << 20
+}
+
+// sizeLimitReader fails once more than limit bytes are read, unlike io.LimitReader which just
+// stops and would store a truncated file
+type sizeLimitReader struct {
+	r        io.Reader
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		l.exceeded = true
+		return n, errDocumentTooLarge
+	}
+	return n, err
+}
+
+// storedFile is a file that was streamed into the document store
+type storedFile struct {
+	path     string
+	fileName string
+	fileType string
+	size     int64
+	sha256   string
+}
+
+// storeDocumentFile streams an uploaded file into the document store, hashing it on the way.
+// The type of the file is detected from its first bytes and its name, the type the client
+// claims is not trusted. When accept is given, it can refuse the type before anything is
+// stored.
+func (h *TasksHandler) storeDocumentFile(ctx context.Context, r io.Reader, fileName string, limit int64, accept func(fileType string) error) (*storedFile, error) {
+	file := &storedFile{
+		path:     path.Join(documentDir, uuid.New().String()+"_"+fileName),
+		fileName: fileName,
+	}
+	limited := &sizeLimitReader{r: r, limit: limit}
+	buffered := bufio.NewReaderSize(limited, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if limited.exceeded {
+		return nil, errDocumentTooLarge
+	}
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	file.fileType = detectContentType(head, fileName)
+	if accept != nil {
+		if err := accept(file.fileType); err != nil {
+			return nil, err
+		}
+	}
+
+	hash := sha256.New()
+	size, err := h.documentStore.Put(ctx, file.path, io.TeeReader(buffered, hash))
+	if limited.exceeded {
+		return nil, errDocumentTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+	file.size = size
+	file.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return file, nil
+}
+
+// removeStoredFile cleans up a file whose upload did not complete
+func (h *TasksHandler) removeStoredFile(ctx context.Context, filePath string) {
+	if err := h.documentStore.Delete(ctx, filePath); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		h.logger.Printf("Error removing file %s: %v", filePath, err)
+	}
+}
+
+// saveUploadedDocument records a file that was stored for a task. It becomes a new version
+// of the document given by documentID, or else of the document of the task with the same file
+// name, and otherwise a new document.
+func (h *TasksHandler) saveUploadedDocument(ctx context.Context, task *model.Task, file *storedFile, userID string, documentID string) (*model.TaskDocument, error) {
+	version := model.DocumentVersion{
+		FileName:   file.fileName,
+		FileType:   file.fileType,
+		FilePath:   file.path,
+		Size:       file.size,
+		SHA256:     file.sha256,
+		UploadedBy: userID,
+		UploadedAt: primitive.NewDateTimeFromTime(time.Now()),
+		Status:     h.initialDocumentStatus(),
+		Preview:    initialPreviewStatus(file.fileType),
+	}
+	for attempt := 0; attempt < maxVersionAttempts; attempt++ {
+		var existing *model.TaskDocument
+		var err error
+		if documentID != "" {
+			existing, err = h.getTaskDocument(ctx, task.ID.Hex(), documentID)
+			if err == nil && existing == nil {
+				return nil, errDocumentNotFound
+			}
+		} else {
+			existing, err = h.documentRepo.GetByTaskAndName(ctx, task.ID.Hex(), file.fileName)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if existing == nil {
+			version.Number = 1
+			taskDocument := &model.TaskDocument{
+				ID:         primitive.NewObjectID(),
+				TaskID:     task.ID.Hex(),
+				FileName:   file.fileName,
+				FileType:   file.fileType,
+				FilePath:   file.path,
+				Size:       file.size,
+				SHA256:     file.sha256,
+				UploadedBy: userID,
+				Status:     version.Status,
+				Preview:    version.Preview,
+				TextStatus: model.TextPending,
+				Version:    version.Number,
+				Versions:   []model.DocumentVersion{version},
+			}
+			err := h.documentRepo.SaveTaskDocument(ctx, taskDocument)
+			if errors.Is(err, repositories.ErrDocumentNameTaken) {
+				// a concurrent upload created the document first, this one becomes its next version
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			h.custLogger.Info(logrus.Fields{
+				"documentID": taskDocument.ID.Hex(),
+				"taskID":     taskDocument.TaskID,
+				"size":       taskDocument.Size,
+			}, "Task document uploaded")
+			return taskDocument, nil
+		}
+
+		added, err := h.documentRepo.AddVersion(ctx, existing, version)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			h.custLogger.Info(logrus.Fields{
+				"documentID": existing.ID.Hex(),
+				"taskID":     existing.TaskID,
+				"version":    existing.Version,
+				"size":       existing.Size,
+			}, "Task document version uploaded")
+			return existing, nil
+		}
+	}
+	return nil, errVersionConflict
+}
+
+// getTaskDocument returns a document of the given task, or nil when the task has no such
+// document
+func (h *TasksHandler) getTaskDocument(ctx context.Context, taskID string, documentID string) (*model.TaskDocument, error) {
+	docID, err := primitive.ObjectIDFromHex(documentID)
+	if err != nil {
+		return nil, nil
+	}
+	taskDocument, err := h.documentRepo.GetTaskDocumentByID(ctx, docID)
+	if err != nil || taskDocument == nil || taskDocument.TaskID != taskID {
+		return nil, err
+	}
+	return taskDocument, nil
+}
+
+// documentAdded tells the members and watchers of a task about a new document or a new
+// version of one and records it in the analytics of the project. The file is scanned, and
+// its preview and text made, after the event is out, so that the events about them follow it.
+func (h *TasksHandler) documentAdded(ctx context.Context, task *model.Task, taskDocument *model.TaskDocument, userID string) error {
+	version := taskDocument.CurrentVersion()
+	h.publishEvent(ctx, "task.document.uploaded", map[string]interface{}{
+		"documentId":   taskDocument.ID.Hex(),
+		"taskId":       taskDocument.TaskID,
+		"projectId":    task.ProjectID,
+		"fileName":     taskDocument.FileName,
+		"fileType":     taskDocument.FileType,
+		"version":      version.Number,
+		"revertedFrom": version.RevertedFrom,
+		"taskName":     task.Name,
+		"uploadedBy":   userID,
+		"memberIds":    task.UserIDs,
+		"watcherIds":   h.watcherIDs(ctx, task),
+	})
+	h.startDocumentScan(ctx, taskDocument)
+	h.startDocumentPreview(ctx, taskDocument)
+	h.startDocumentTextExtraction(ctx, taskDocument)
+
+	currentTime := time.Now().Add(1 * time.Hour)
+	formattedTime := currentTime.Format(time.RFC3339)
+
+	event := map[string]interface{}{
+		"type": "DocumentAdded",
+		"time": formattedTime,
+		"event": map[string]interface{}{
+			"taskId":     taskDocument.TaskID,
+			"projectId":  task.ProjectID,
+			"documentId": taskDocument.ID,
+			"addedBy":    userID,
+		},
+		"projectId": task.ProjectID,
+	}
+	if version.Number > 1 {
+		event["type"] = "DocumentVersionAdded"
+		event["event"] = map[string]interface{}{
+			"taskId":       taskDocument.TaskID,
+			"projectId":    task.ProjectID,
+			"documentId":   taskDocument.ID,
+			"version":      version.Number,
+			"revertedFrom": version.RevertedFrom,
+			"addedBy":      userID,
+		}
+	}
+	return h.sendEventToAnalyticsService(ctx, event)
+}
+
+// writeSaveDocumentError answers a request whose file could not be saved as a document
+func writeSaveDocumentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errDocumentNotFound):
+		http.Error(w, "Task document not found", http.StatusNotFound)
+	case errors.Is(err, errVersionConflict):
+		http.Error(w, "Document is being changed by another upload, try again", http.StatusConflict)
+	default:
+		http.Error(w, "Unable to save task document to database", http.StatusInternalServerError)
+	}
+}
+
+// UploadTaskDocument streams the file of a multipart form with the fields taskId and file
+// straight into the document store, without buffering it in memory or on disk. The optional
+// documentId field uploads a new version of that document. The taskId field has to come
+// before the file, nothing is stored for a task the user cannot access.
+func (h *TasksHandler) UploadTaskDocument(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.UploadTaskDocument")
+	defer span.End()
+
+	id, ok := r.Context().Value(KeyId{}).(string)
+	if !ok || id == "" {
+		span.RecordError(errors.New("User ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(w, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	deadline := time.Now().Add(documentTransferTimeout)
+	if err := controller.SetReadDeadline(deadline); err != nil {
+		h.logger.Println("Error extending read deadline:", err)
+	}
+	if err := controller.SetWriteDeadline(deadline); err != nil {
+		h.logger.Println("Error extending write deadline:", err)
+	}
+
+	limit := maxDocumentSize()
+	if r.ContentLength > limit+maxFormValueSize*4 {
+		http.Error(w, fmt.Sprintf("Document is larger than %d MB", limit>>20), http.StatusRequestEntityTooLarge)
+		return
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		return
+	}
+
+	var taskId, documentId string
+	var task *model.Task
+	var file *storedFile
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			if file != nil {
+				h.removeStoredFile(ctx, file.path)
+			}
+			http.Error(w, "Unable to parse form", http.StatusBadRequest)
+			return
+		}
+
+		switch part.FormName() {
+		case "taskId", "documentId":
+			value, err := io.ReadAll(io.LimitReader(part, maxFormValueSize))
+			if err != nil {
+				span.RecordError(err)
+				if file != nil {
+					h.removeStoredFile(ctx, file.path)
+				}
+				http.Error(w, "Unable to parse form", http.StatusBadRequest)
+				return
+			}
+			if part.FormName() == "taskId" {
+				taskId = string(value)
+			} else {
+				documentId = string(value)
+			}
+		case "file":
+			if file != nil || part.FileName() == "" {
+				if file != nil {
+					h.removeStoredFile(ctx, file.path)
+				}
+				http.Error(w, "Exactly one named file is expected", http.StatusBadRequest)
+				return
+			}
+			if taskId == "" {
+				span.SetStatus(codes.Error, "missing taskId")
+				http.Error(w, "Missing taskId in form data before the file", http.StatusBadRequest)
+				return
+			}
+			task, err = h.repo.GetByID(ctx, taskId)
+			if err != nil || task == nil {
+				span.SetStatus(codes.Error, "task not found")
+				http.Error(w, "Task not found", http.StatusNotFound)
+				return
+			}
+			if !h.canAccessProject(ctx, r, task.ProjectID) {
+				span.SetStatus(codes.Error, "user is not a member of the project")
+				http.Error(w, "You are not a member of this project", http.StatusForbidden)
+				return
+			}
+			// the policy and the quota of the project are checked before anything is stored,
+			// the size against the request and, while streaming, against what is left
+			rules, err := h.documentRules(ctx, task.ProjectID)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				http.Error(w, "Failed to check the document policy", http.StatusInternalServerError)
+				return
+			}
+			limit = min(limit, rules.maxSize)
+			if r.ContentLength > limit+maxFormValueSize*4 {
+				http.Error(w, fmt.Sprintf("Document is larger than %d MB", limit>>20), http.StatusRequestEntityTooLarge)
+				return
+			}
+			remaining, err := h.checkStorageQuota(ctx, task.ProjectID, max(r.ContentLength-maxFormValueSize*4, 0))
+			if err != nil {
+				span.RecordError(err)
+				writeDocumentPolicyError(w, err)
+				return
+			}
+			accept := func(fileType string) error { return rules.check(fileType, 0) }
+			file, err = h.storeDocumentFile(ctx, part, part.FileName(), min(limit, remaining), accept)
+			if errors.Is(err, errDocumentTooLarge) && remaining < limit {
+				writeDocumentPolicyError(w, fmt.Errorf("%w: %d MB are left", errStorageQuotaExceeded, remaining>>20))
+				return
+			}
+			if errors.Is(err, errDocumentTooLarge) {
+				http.Error(w, fmt.Sprintf("Document is larger than %d MB", limit>>20), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if errors.Is(err, errTypeNotAllowed) {
+				writeDocumentPolicyError(w, err)
+				return
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				http.Error(w, fmt.Sprintf("Failed to store file: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+		part.Close()
+	}
+	if file == nil {
+		http.Error(w, "Unable to get file from form", http.StatusBadRequest)
+		return
+	}
+	if err := h.reserveStorage(ctx, task.ProjectID, file, id); err != nil {
+		h.removeStoredFile(ctx, file.path)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		writeDocumentPolicyError(w, err)
+		return
+	}
+
+	taskDocument, err := h.saveUploadedDocument(ctx, task, file, id, documentId)
+	if err != nil {
+		h.removeStoredFile(ctx, file.path)
+		h.releaseStorage(ctx, task.ProjectID, id, file.size)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		writeSaveDocumentError(w, err)
+		return
+	}
+	if err := h.documentAdded(ctx, task, taskDocument, id); err != nil {
+		http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetStatus(codes.Ok, "Successfully saved task document")
+	w.WriteHeader(http.StatusCreated)
+	if err := taskDocument.ToJSON(w); err != nil {
+		span.RecordError(err)
+		h.logger.Println("Error encoding task document:", err)
+	}
+}
+
+// GetTaskDocumentsByTaskID lists the documents of a task for a user who belongs to its project
+func (h *TasksHandler) GetTaskDocumentsByTaskID(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.GetTaskDocumentsByTaskID")
+	defer span.End()
+	// Preuzimanje taskId iz query parametra
+	vars := mux.Vars(r)
+	taskID := vars["taskId"]
+
+	h.logger.Printf("Fetching documents for taskId: %s", taskID)
+
+	task, err := h.repo.GetByID(ctx, taskID)
+	if err != nil || task == nil {
+		span.SetStatus(codes.Error, "task not found")
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !h.canAccessProject(ctx, r, task.ProjectID) {
+		span.SetStatus(codes.Error, "user is not a member of the project")
+		http.Error(w, "You are not a member of this project", http.StatusForbidden)
+		return
+	}
+
+	// Pozivanje metode iz repozitorijuma da se dobiju task dokumenti
+	documents, err := h.documentRepo.GetTaskDocumentsByTaskID(ctx, taskID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Printf("Error fetching documents for taskId %s: %v", taskID, err)
+		http.Error(w, "Unable to fetch task documents", http.StatusInternalServerError)
+		return
+	}
+
+	// Vraćanje rezultata kao JSON
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(documents)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Printf("Error encoding documents for taskId %s: %v", taskID, err)
+		http.Error(w, "Unable to encode task documents", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched documents for taskId")
+	h.logger.Printf("Successfully fetched %d documents for taskId %s", len(documents), taskID)
+}
+
+// DownloadTaskDocument streams the file of a document to a user who belongs to the project of
+// its task. Range requests are answered with the
+// requested part, and a client that already has the file gets 304 through If-None-Match.
+func (h *TasksHandler) DownloadTaskDocument(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.DownloadTaskDocument")
+	defer span.End()
+	vars := mux.Vars(r)
+	taskDocumentId, ok := vars["taskDocumentId"]
+	if !ok || taskDocumentId == "" {
+		span.RecordError(errors.New("missing taskDocumentId"))
+		span.SetStatus(codes.Error, "missing taskDocumentId")
+		http.Error(w, "Missing taskDocumentId in path parameters", http.StatusBadRequest)
+		return
+	}
+
+	taskDocument, _ := h.getAccessibleDocument(ctx, w, r, taskDocumentId)
+	if taskDocument == nil {
+		span.SetStatus(codes.Error, "task document not available")
+		return
+	}
+
+	version := taskDocument.CurrentVersion()
+	if writeUnavailableDocument(w, &version) {
+		span.SetStatus(codes.Error, "document file is not available")
+		return
+	}
+
+	file, err := h.documentStore.Get(ctx, taskDocument.FilePath)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Document file not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to get file: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(documentTransferTimeout)); err != nil {
+		h.logger.Println("Error extending write deadline:", err)
+	}
+	writeDocumentHeaders(w, taskDocument, version)
+	// ServeContent answers Range, If-Range, If-None-Match and HEAD requests
+	http.ServeContent(w, r, "", taskDocument.UploadedAt.Time(), file)
+	span.SetStatus(codes.Ok, "Successfully sent file content")
+}
+
+// writeDocumentHeaders describes a version of a document in the response. The stored file of
+// a version never changes, so its hash, or the document ID for documents uploaded before
+// hashing, is a strong ETag.
+func writeDocumentHeaders(w http.ResponseWriter, document *model.TaskDocument, version model.DocumentVersion) {
+	contentType := version.FileType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	etag := version.SHA256
+	if etag == "" {
+		etag = document.ID.Hex()
+		if version.Number > 1 {
+			etag += "-" + strconv.Itoa(version.Number)
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": version.FileName}))
+	w.Header().Set("ETag", strconv.Quote(etag))
+	w.Header().Set("Cache-Control", "private, no-cache")
+}
+
+// DeleteTaskDocument removes a document together with the files of all its versions. Only the
+// user who uploaded the document and the managers of its project can remove it.
+func (h *TasksHandler) DeleteTaskDocument(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.DeleteTaskDocument")
+	defer span.End()
+
+	userID, ok := r.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("User ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(w, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	taskDocument, task := h.getRequestDocument(ctx, w, r)
+	if taskDocument == nil {
+		span.SetStatus(codes.Error, "task document not available")
+		return
+	}
+	if !h.canRemoveDocument(ctx, r, task, taskDocument, userID) {
+		span.SetStatus(codes.Error, "user may not remove the document")
+		http.Error(w, "Only the uploader or a project manager can remove this document", http.StatusForbidden)
+		return
+	}
+
+	// the metadata goes first, so the document is never listed without its files
+	if err := h.documentRepo.DeleteTaskDocument(ctx, taskDocument.ID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, "Unable to delete task document", http.StatusInternalServerError)
+		return
+	}
+	for _, filePath := range taskDocument.FilePaths() {
+		h.removeStoredFile(ctx, filePath)
+	}
+	h.releaseDocumentStorage(ctx, task.ProjectID, taskDocument)
+	h.custLogger.Info(logrus.Fields{
+		"documentID": taskDocument.ID.Hex(),
+		"taskID":     taskDocument.TaskID,
+		"removedBy":  userID,
+	}, "Task document removed")
+
+	h.publishEvent(ctx, "task.document.removed", map[string]interface{}{
+		"documentId": taskDocument.ID.Hex(),
+		"taskId":     taskDocument.TaskID,
+		"projectId":  task.ProjectID,
+		"fileName":   taskDocument.FileName,
+		"taskName":   task.Name,
+		"removedBy":  userID,
+		"memberIds":  task.UserIDs,
+		"watcherIds": h.watcherIDs(ctx, task),
+	})
+
+	event := map[string]interface{}{
+		"type": "DocumentRemoved",
+		"time": time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+		"event": map[string]interface{}{
+			"taskId":     taskDocument.TaskID,
+			"projectId":  task.ProjectID,
+			"documentId": taskDocument.ID,
+			"removedBy":  userID,
+		},
+		"projectId": task.ProjectID,
+	}
+	if err := h.sendEventToAnalyticsService(ctx, event); err != nil {
+		span.RecordError(err)
+		h.logger.Println("Error sending DocumentRemoved event to analytics service:", err)
+	}
+
+	span.SetStatus(codes.Ok, "Successfully removed task document")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canRemoveDocument reports whether a user uploaded the first version of a document or
+// manages the project of its task
+func (h *TasksHandler) canRemoveDocument(ctx context.Context, r *http.Request, task *model.Task, taskDocument *model.TaskDocument, userID string) bool {
+	if taskDocument.AllVersions()[0].UploadedBy == userID {
+		return true
+	}
+	if role, _ := r.Context().Value(KeyRole{}).(string); role != "manager" {
+		return false
+	}
+	cookie, err := r.Cookie("auth_token")
+	if err != nil {
+		return false
+	}
+	return h.isProjectManager(ctx, task.ProjectID, cookie)
+}
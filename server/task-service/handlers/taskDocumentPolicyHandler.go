@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"task--service/model"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// sniffLen is how much of a file is read to detect its type, as much as http.DetectContentType
+// looks at
+const sniffLen = 512
+
+// defaultDeniedTypes are refused in every project unless DOCUMENT_DENIED_TYPES says otherwise
+const defaultDeniedTypes = "application/x-msdownload,application/x-executable,application/x-sharedlib"
+
+var errTypeNotAllowed = errors.New("document type is not allowed")
+
+// executableSignatures are the programs http.DetectContentType does not know about. They are
+// only looked for in binary content, a text file may well start with "MZ".
+var executableSignatures = []struct {
+	magic       []byte
+	contentType string
+}{
+	{[]byte("MZ"), "application/x-msdownload"},
+	{[]byte("\x7fELF"), "application/x-executable"},
+}
+
+// detectContentType finds the type of a file from its first bytes. Formats that are
+// containers of others, such as the zip of an office document, and plain text are refined
+// with the extension of the file name.
+func detectContentType(head []byte, fileName string) string {
+	detected := http.DetectContentType(head)
+	if mediaType(detected) == "application/octet-stream" {
+		for _, signature := range executableSignatures {
+			if bytes.HasPrefix(head, signature.magic) {
+				return signature.contentType
+			}
+		}
+	}
+	byExtension := mime.TypeByExtension(strings.ToLower(path.Ext(fileName)))
+	if byExtension == "" {
+		return detected
+	}
+	switch mediaType(detected) {
+	case "application/octet-stream":
+		// unknown binary content, only the extension tells more
+		if !strings.HasPrefix(byExtension, "text/") {
+			return byExtension
+		}
+	case "application/zip":
+		if strings.HasPrefix(mediaType(byExtension), "application/") {
+			return byExtension
+		}
+	case "text/plain":
+		if strings.HasPrefix(byExtension, "text/") || mediaType(byExtension) == "application/json" {
+			return byExtension
+		}
+	}
+	return detected
+}
+
+// mediaType strips the parameters from a content type
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+// typeMatches reports whether a content type is covered by a pattern such as "image/png",
+// "image/*" or "*/*"
+func typeMatches(pattern, contentType string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	contentType = mediaType(contentType)
+	if pattern == "*/*" || pattern == contentType {
+		return true
+	}
+	family, ok := strings.CutSuffix(pattern, "/*")
+	return ok && strings.HasPrefix(contentType, family+"/")
+}
+
+func typeListMatches(patterns []string, contentType string) bool {
+	for _, pattern := range patterns {
+		if typeMatches(pattern, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// envTypeList reads a comma separated list of types, an empty variable means the fallback
+func envTypeList(name, fallback string) []string {
+	value := os.Getenv(name)
+	if value == "" {
+		value = fallback
+	}
+	types := []string{}
+	for _, contentType := range strings.Split(value, ",") {
+		if contentType = strings.TrimSpace(contentType); contentType != "" {
+			types = append(types, contentType)
+		}
+	}
+	return types
+}
+
+// documentRules are the limits an upload to a project has to meet: the service wide ones of
+// DOCUMENT_ALLOWED_TYPES, DOCUMENT_DENIED_TYPES and DOCUMENT_MAX_SIZE_MB, capped at the size
+// the scanner accepts and narrowed by the document policy of the project
+type documentRules struct {
+	allowed [][]string
+	denied  []string
+	maxSize int64
+}
+
+func (h *TasksHandler) documentRules(ctx context.Context, projectID string) (*documentRules, error) {
+	rules := &documentRules{
+		denied:  envTypeList("DOCUMENT_DENIED_TYPES", defaultDeniedTypes),
+		maxSize: maxDocumentSize(),
+	}
+	if h.scanner != nil && h.scanner.MaxSize() > 0 {
+		rules.maxSize = min(rules.maxSize, h.scanner.MaxSize())
+	}
+	if allowed := envTypeList("DOCUMENT_ALLOWED_TYPES", ""); len(allowed) > 0 {
+		rules.allowed = append(rules.allowed, allowed)
+	}
+	policy, err := h.documentRepo.GetPolicy(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if policy != nil {
+		rules.denied = append(rules.denied, policy.DeniedTypes...)
+		if len(policy.AllowedTypes) > 0 {
+			rules.allowed = append(rules.allowed, policy.AllowedTypes)
+		}
+		if policy.MaxSizeMB > 0 {
+			rules.maxSize = min(rules.maxSize, policy.MaxSizeMB<<20)
+		}
+	}
+	return rules, nil
+}
+
+func (rules *documentRules) check(contentType string, size int64) error {
+	if size > rules.maxSize {
+		return fmt.Errorf("%w: the limit is %d MB", errDocumentTooLarge, rules.maxSize>>20)
+	}
+	if typeListMatches(rules.denied, contentType) {
+		return fmt.Errorf("%w: %s", errTypeNotAllowed, mediaType(contentType))
+	}
+	for _, allowed := range rules.allowed {
+		if !typeListMatches(allowed, contentType) {
+			return fmt.Errorf("%w: %s", errTypeNotAllowed, mediaType(contentType))
+		}
+	}
+	return nil
+}
+
+// checkDocumentPolicy checks a stored file against the rules of the project it was uploaded to
+func (h *TasksHandler) checkDocumentPolicy(ctx context.Context, projectID string, file *storedFile) error {
+	rules, err := h.documentRules(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	return rules.check(file.fileType, file.size)
+}
+
+// writeDocumentPolicyError answers an upload that the document policy or the storage quota of
+// the project refused
+func writeDocumentPolicyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errDocumentTooLarge):
+		http.Error(w, fmt.Sprintf("Document is too large, %v", err), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, errTypeNotAllowed):
+		http.Error(w, fmt.Sprintf("Document type is not allowed in this project: %v", err), http.StatusUnsupportedMediaType)
+	case errors.Is(err, errStorageQuotaExceeded):
+		http.Error(w, fmt.Sprintf("Document does not fit in the storage quota of this project, %v", err), http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, "Failed to check the document policy", http.StatusInternalServerError)
+	}
+}
+
+// GetDocumentPolicy returns the document policy of a project, an empty one when none was set
+func (h *TasksHandler) GetDocumentPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.GetDocumentPolicy")
+	defer span.End()
+
+	projectID := mux.Vars(r)["projectId"]
+	if !h.canAccessProject(ctx, r, projectID) {
+		http.Error(w, "You are not a member of this project", http.StatusForbidden)
+		return
+	}
+	policy, err := h.documentRepo.GetPolicy(ctx, projectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, "Failed to get document policy", http.StatusInternalServerError)
+		return
+	}
+	if policy == nil {
+		policy = &model.DocumentPolicy{ProjectID: projectID, AllowedTypes: []string{}, DeniedTypes: []string{}}
+	}
+	span.SetStatus(codes.Ok, "Successfully got document policy")
+	if err := policy.ToJSON(w); err != nil {
+		h.logger.Println("Error encoding document policy:", err)
+	}
+}
+
+// UpdateDocumentPolicy replaces the document policy of a project, for its managers only. The
+// policy applies to later uploads, documents that are already stored are kept.
+func (h *TasksHandler) UpdateDocumentPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.UpdateDocumentPolicy")
+	defer span.End()
+
+	userID, _ := r.Context().Value(KeyId{}).(string)
+	projectID := mux.Vars(r)["projectId"]
+	cookie, err := r.Cookie("auth_token")
+	if err != nil || !h.isProjectManager(ctx, projectID, cookie) {
+		http.Error(w, "Only the managers of the project can change its document policy", http.StatusForbidden)
+		return
+	}
+
+	var policy model.DocumentPolicy
+	if err := policy.FromJSON(r.Body); err != nil {
+		http.Error(w, "Unable to decode json", http.StatusBadRequest)
+		return
+	}
+	if policy.MaxSizeMB < 0 {
+		http.Error(w, "maxSizeMb cannot be negative", http.StatusBadRequest)
+		return
+	}
+	for _, contentType := range append(append([]string{}, policy.AllowedTypes...), policy.DeniedTypes...) {
+		if _, _, err := mime.ParseMediaType(strings.Replace(contentType, "*", "x", -1)); err != nil || !strings.Contains(contentType, "/") {
+			http.Error(w, fmt.Sprintf("%q is not a MIME type", contentType), http.StatusBadRequest)
+			return
+		}
+	}
+	if policy.AllowedTypes == nil {
+		policy.AllowedTypes = []string{}
+	}
+	if policy.DeniedTypes == nil {
+		policy.DeniedTypes = []string{}
+	}
+	policy.ProjectID = projectID
+	policy.UpdatedBy = userID
+	if err := h.documentRepo.SavePolicy(ctx, &policy); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, "Failed to save document policy", http.StatusInternalServerError)
+		return
+	}
+	h.custLogger.Info(logrus.Fields{
+		"projectID":    projectID,
+		"allowedTypes": policy.AllowedTypes,
+		"deniedTypes":  policy.DeniedTypes,
+		"maxSizeMb":    policy.MaxSizeMB,
+	}, "Document policy updated")
+
+	span.SetStatus(codes.Ok, "Successfully updated document policy")
+	if err := policy.ToJSON(w); err != nil {
+		h.logger.Println("Error encoding document policy:", err)
+	}
+}
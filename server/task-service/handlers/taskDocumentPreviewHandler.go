@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"task--service/model"
+	"task--service/preview"
+	"task--service/storage"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Images get a thumbnail, text files their first lines and PDFs the first lines of the text of
+// their first page as a preview, stored next to the file they show. A preview is rendered once
+// the file may be downloaded, right after the upload or after its scan, and previews that were
+// not rendered because the service stopped are picked up by the sweep. Other files have no
+// preview.
+const (
+	previewSuffix = ".preview"
+
+	previewSweepInterval = 10 * time.Minute
+	// previewRetryDelay is how long a preview stays pending before the sweep renders it again
+	previewRetryDelay     = 10 * time.Minute
+	previewSweepBatchSize = 50
+	previewRetryAfter     = 5
+)
+
+// initialPreviewStatus is the preview status of a newly uploaded file of the given type
+func initialPreviewStatus(fileType string) model.PreviewStatus {
+	if preview.KindOf(fileType) == preview.None {
+		return model.PreviewUnsupported
+	}
+	return model.PreviewPending
+}
+
+// startDocumentPreview renders the preview of the current file of a document in the
+// background when it is pending and the file may already be shown
+func (h *TasksHandler) startDocumentPreview(ctx context.Context, taskDocument *model.TaskDocument) {
+	version := taskDocument.CurrentVersion()
+	if version.Preview != model.PreviewPending || !version.Available() {
+		return
+	}
+	go func() {
+		if err := h.renderDocumentPreview(context.WithoutCancel(ctx), taskDocument.ID, version); err != nil {
+			h.logger.Printf("Error rendering preview of file %s of document %s, it is retried later: %v", version.FilePath, taskDocument.ID.Hex(), err)
+		}
+	}()
+}
+
+// renderDocumentPreview renders the preview of the file of a version and records it in the
+// document. Files that cannot be rendered get a failed preview, an error is only returned
+// when rendering should be tried again.
+func (h *TasksHandler) renderDocumentPreview(ctx context.Context, docID primitive.ObjectID, version model.DocumentVersion) error {
+	ctx, span := h.tracer.Start(ctx, "TaskHandler.renderDocumentPreview")
+	defer span.End()
+
+	file, err := h.documentStore.Get(ctx, version.FilePath)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	rendered, renderErr := preview.Render(file, version.Size, version.FileType)
+	file.Close()
+
+	status, previewPath := model.PreviewReady, version.FilePath+previewSuffix
+	if renderErr != nil {
+		span.RecordError(renderErr)
+		h.custLogger.Warn(logrus.Fields{
+			"documentID": docID.Hex(),
+			"filePath":   version.FilePath,
+			"fileType":   version.FileType,
+			"error":      renderErr.Error(),
+		}, "Document preview could not be rendered")
+		status, previewPath = model.PreviewFailed, ""
+	} else if _, err := h.documentStore.Put(ctx, previewPath, bytes.NewReader(rendered)); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	if err := h.documentRepo.SetPreview(ctx, docID, version.FilePath, status, previewPath); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if previewPath != "" {
+			h.removeStoredFile(ctx, previewPath)
+		}
+		return err
+	}
+	span.SetStatus(codes.Ok, "Document preview rendered")
+	return nil
+}
+
+// RunDocumentPreviewSweep renders the previews that were not rendered until ctx is done
+func (h *TasksHandler) RunDocumentPreviewSweep(ctx context.Context) {
+	ticker := time.NewTicker(previewSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		h.renderPendingPreviews(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *TasksHandler) renderPendingPreviews(ctx context.Context) {
+	ctx, span := h.tracer.Start(ctx, "TaskHandler.renderPendingPreviews")
+	defer span.End()
+
+	documents, err := h.documentRepo.FindPendingPreviews(ctx, time.Now().Add(-previewRetryDelay), previewSweepBatchSize)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Println("Error fetching documents waiting for a preview:", err)
+		return
+	}
+	for _, taskDocument := range documents {
+		rendered := map[string]bool{}
+		for _, version := range taskDocument.AllVersions() {
+			if version.Preview != model.PreviewPending || !version.Available() || rendered[version.FilePath] {
+				continue
+			}
+			rendered[version.FilePath] = true
+			if err := h.renderDocumentPreview(ctx, taskDocument.ID, version); err != nil {
+				span.RecordError(err)
+				h.logger.Printf("Error rendering preview of file %s of document %s: %v", version.FilePath, taskDocument.ID.Hex(), err)
+			}
+		}
+	}
+	span.SetStatus(codes.Ok, "Successfully rendered pending previews")
+}
+
+// GetTaskDocumentPreview sends the preview of the current version of a document: a PNG
+// thumbnail for images and the first lines as plain text for text files
+func (h *TasksHandler) GetTaskDocumentPreview(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.GetTaskDocumentPreview")
+	defer span.End()
+
+	taskDocument, _ := h.getRequestDocument(ctx, w, r)
+	if taskDocument == nil {
+		return
+	}
+	version := taskDocument.CurrentVersion()
+	if writeUnavailableDocument(w, &version) {
+		return
+	}
+	switch version.Preview {
+	case model.PreviewReady:
+	case model.PreviewPending:
+		w.Header().Set("Retry-After", strconv.Itoa(previewRetryAfter))
+		http.Error(w, "Preview is still being generated", http.StatusConflict)
+		return
+	case model.PreviewFailed:
+		http.Error(w, "Preview could not be generated for this document", http.StatusNotFound)
+		return
+	default:
+		http.Error(w, "Document has no preview", http.StatusNotFound)
+		return
+	}
+
+	file, err := h.documentStore.Get(ctx, version.PreviewPath)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Preview file not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to get preview: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", preview.ContentType(preview.KindOf(version.FileType)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if version.SHA256 != "" {
+		w.Header().Set("ETag", strconv.Quote(version.SHA256+previewSuffix))
+	}
+	http.ServeContent(w, r, "", version.UploadedAt.Time(), file)
+	span.SetStatus(codes.Ok, "Successfully sent document preview")
+}
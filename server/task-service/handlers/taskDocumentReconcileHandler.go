@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"task--service/model"
+	"task--service/repositories"
+	"task--service/scanner"
+	"task--service/storage"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Files and their metadata drift apart when a request fails between storing a file and saving
+// its document, or when a file cannot be removed with its document. The reconciliation lists
+// the document, quarantine and upload directories of the store and compares them with the
+// documents and upload sessions:
+//   - files nothing refers to are removed once they are older than the grace period, which
+//     keeps files of uploads in progress safe
+//   - versions whose file is gone are flagged as missing, so they are no longer offered for
+//     download, and unflagged when the file is back
+//   - previews that are gone are rendered again
+//   - documents of tasks that no longer exist are removed with their files
+//   - upload sessions that lost a chunk are removed
+//
+// A dry run only reports what would be repaired. The service runs it daily as set by
+// DOCUMENT_RECONCILE: report (the default), repair or off. The reconcile command runs it by
+// hand, also as a dry run unless it is given -repair.
+const (
+	reconcileInterval     = 24 * time.Hour
+	defaultReconcileGrace = 24 * time.Hour
+)
+
+// ReconcileOptions set how the reconciliation runs
+type ReconcileOptions struct {
+	// DryRun reports what would be repaired without changing anything
+	DryRun bool
+	// Grace is how old orphan files and documents without a task have to be to be removed
+	Grace time.Duration
+}
+
+// ReconcileReport lists what the reconciliation found and what it did about it
+type ReconcileReport struct {
+	DryRun               bool               `json:"dryRun"`
+	StartedAt            time.Time          `json:"startedAt"`
+	Files                int                `json:"files"`
+	Documents            int                `json:"documents"`
+	UploadSessions       int                `json:"uploadSessions"`
+	OrphanFiles          []ReconcileFinding `json:"orphanFiles"`
+	MissingFiles         []ReconcileFinding `json:"missingFiles"`
+	RestoredFiles        []ReconcileFinding `json:"restoredFiles"`
+	MissingPreviews      []ReconcileFinding `json:"missingPreviews"`
+	DocumentsWithoutTask []ReconcileFinding `json:"documentsWithoutTask"`
+	BrokenUploads        []ReconcileFinding `json:"brokenUploads"`
+}
+
+// ReconcileFinding is one inconsistency and the action taken on it
+type ReconcileFinding struct {
+	Path       string     `json:"path,omitempty"`
+	Size       int64      `json:"size,omitempty"`
+	ModTime    *time.Time `json:"modTime,omitempty"`
+	DocumentID string     `json:"documentId,omitempty"`
+	TaskID     string     `json:"taskId,omitempty"`
+	Version    int        `json:"version,omitempty"`
+	UploadID   string     `json:"uploadId,omitempty"`
+	Action     string     `json:"action"`
+}
+
+// Problems counts the inconsistencies in the report
+func (rr *ReconcileReport) Problems() int {
+	return len(rr.OrphanFiles) + len(rr.MissingFiles) + len(rr.RestoredFiles) + len(rr.MissingPreviews) +
+		len(rr.DocumentsWithoutTask) + len(rr.BrokenUploads)
+}
+
+// NewReconcileHandler creates a handler with only what the reconciliation needs, for running
+// it outside of the service
+func NewReconcileHandler(l *log.Logger, r *repositories.TaskRepository, docRepo *repositories.TaskDocumentRepository, uploadSessionRepo *repositories.UploadSessionRepository, documentStore storage.DocumentStore, documentScanner scanner.Scanner, tracer trace.Tracer) *TasksHandler {
+	return &TasksHandler{
+		logger:            l,
+		repo:              r,
+		documentRepo:      docRepo,
+		uploadSessionRepo: uploadSessionRepo,
+		documentStore:     documentStore,
+		scanner:           documentScanner,
+		tracer:            tracer,
+	}
+}
+
+// ReconcileDocuments compares the document store with the documents and upload sessions and
+// repairs what does not match, or only reports it in a dry run. It fails without repairing
+// anything when the store or the metadata cannot be read as a whole.
+func (h *TasksHandler) ReconcileDocuments(ctx context.Context, options ReconcileOptions) (*ReconcileReport, error) {
+	ctx, span := h.tracer.Start(ctx, "TaskHandler.ReconcileDocuments")
+	defer span.End()
+
+	report := &ReconcileReport{
+		DryRun:               options.DryRun,
+		StartedAt:            time.Now(),
+		OrphanFiles:          []ReconcileFinding{},
+		MissingFiles:         []ReconcileFinding{},
+		RestoredFiles:        []ReconcileFinding{},
+		MissingPreviews:      []ReconcileFinding{},
+		DocumentsWithoutTask: []ReconcileFinding{},
+		BrokenUploads:        []ReconcileFinding{},
+	}
+	cutoff := report.StartedAt.Add(-options.Grace)
+
+	// the store is listed before the metadata is read, so a file stored in between is never
+	// taken for an orphan, and a file that seems missing is looked up again before acting
+	files := map[string]storage.FileInfo{}
+	for _, dir := range []string{documentDir, quarantineDir, uploadDir} {
+		listed, err := h.documentStore.List(ctx, dir)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+		}
+		for _, file := range listed {
+			files[file.Path] = file
+		}
+	}
+	documents, err := h.documentRepo.AllDocuments(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to read documents: %w", err)
+	}
+	sessions, err := h.uploadSessionRepo.All(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to read upload sessions: %w", err)
+	}
+	taskIDs := []string{}
+	for _, taskDocument := range documents {
+		taskIDs = append(taskIDs, taskDocument.TaskID)
+	}
+	tasks, err := h.repo.ExistingIDs(ctx, taskIDs)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+	report.Files, report.Documents, report.UploadSessions = len(files), len(documents), len(sessions)
+
+	referenced := map[string]bool{}
+	for i := range documents {
+		taskDocument := &documents[i]
+		for _, filePath := range taskDocument.FilePaths() {
+			referenced[filePath] = true
+		}
+		if tasks[taskDocument.TaskID] {
+			h.reconcileDocumentFiles(ctx, taskDocument, files, options, report)
+		} else {
+			h.reconcileDocumentWithoutTask(ctx, taskDocument, cutoff, options, report)
+		}
+	}
+
+	// everything under the directory of a session belongs to it, chunks whose request failed
+	// included, and goes with the session
+	sessionDirs := map[string]bool{}
+	for i := range sessions {
+		sessionDirs[path.Join(uploadDir, sessions[i].ID.Hex())] = true
+		h.reconcileUpload(ctx, &sessions[i], files, options, report)
+	}
+
+	for filePath, file := range files {
+		if referenced[filePath] || sessionDirs[uploadSessionDir(filePath)] {
+			continue
+		}
+		finding := ReconcileFinding{Path: filePath, Size: file.Size, ModTime: &file.ModTime}
+		switch {
+		case file.ModTime.After(cutoff):
+			finding.Action = "kept until " + file.ModTime.Add(options.Grace).Format(time.RFC3339)
+		case options.DryRun:
+			finding.Action = "would remove"
+		default:
+			finding.Action = h.reconcileAction("removed", h.documentStore.Delete(ctx, filePath))
+		}
+		report.OrphanFiles = append(report.OrphanFiles, finding)
+	}
+
+	span.SetStatus(codes.Ok, "Successfully reconciled documents")
+	return report, nil
+}
+
+// uploadSessionDir returns the session directory a chunk path is in, or "" for other paths
+func uploadSessionDir(filePath string) string {
+	rest, ok := strings.CutPrefix(filePath, uploadDir)
+	if !ok {
+		return ""
+	}
+	sessionID, _, _ := strings.Cut(rest, "/")
+	return path.Join(uploadDir, sessionID)
+}
+
+// reconcileAction describes the outcome of a repair
+func (h *TasksHandler) reconcileAction(done string, err error) string {
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		h.logger.Printf("Reconciliation repair failed: %v", err)
+		return "failed: " + err.Error()
+	}
+	return done
+}
+
+// storedFileExists tells whether a file is in the store, asking the store again for files
+// the listing did not have
+func (h *TasksHandler) storedFileExists(ctx context.Context, files map[string]storage.FileInfo, filePath string) (bool, error) {
+	if _, ok := files[filePath]; ok {
+		return true, nil
+	}
+	_, err := h.documentStore.Stat(ctx, filePath)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// reconcileDocumentFiles flags the versions whose file is gone, unflags the ones whose file is
+// back and renders again the previews that are gone
+func (h *TasksHandler) reconcileDocumentFiles(ctx context.Context, taskDocument *model.TaskDocument, files map[string]storage.FileInfo, options ReconcileOptions, report *ReconcileReport) {
+	checked := map[string]bool{}
+	for _, version := range taskDocument.AllVersions() {
+		if version.FilePath == "" || checked[version.FilePath] {
+			continue
+		}
+		checked[version.FilePath] = true
+		finding := ReconcileFinding{
+			Path:       version.FilePath,
+			Size:       version.Size,
+			DocumentID: taskDocument.ID.Hex(),
+			TaskID:     taskDocument.TaskID,
+			Version:    version.Number,
+		}
+
+		exists, err := h.storedFileExists(ctx, files, version.FilePath)
+		if err != nil {
+			h.logger.Printf("Error looking up file %s of document %s: %v", version.FilePath, taskDocument.ID.Hex(), err)
+			continue
+		}
+		if !exists {
+			if version.Status == model.DocumentMissing {
+				continue
+			}
+			finding.Action = "would flag as missing"
+			if !options.DryRun {
+				finding.Action = h.reconcileAction("flagged as missing",
+					h.documentRepo.SetScanResult(ctx, taskDocument.ID, version.FilePath, model.DocumentMissing, "", ""))
+			}
+			report.MissingFiles = append(report.MissingFiles, finding)
+			continue
+		}
+
+		if version.Status == model.DocumentMissing {
+			// a file that came back is scanned again when uploads are scanned
+			status := h.initialDocumentStatus()
+			finding.Action = "would unflag"
+			if !options.DryRun {
+				finding.Action = h.reconcileAction("unflagged",
+					h.documentRepo.SetScanResult(ctx, taskDocument.ID, version.FilePath, status, "", ""))
+			}
+			report.RestoredFiles = append(report.RestoredFiles, finding)
+		}
+
+		if version.Preview != model.PreviewReady || version.PreviewPath == "" {
+			continue
+		}
+		exists, err = h.storedFileExists(ctx, files, version.PreviewPath)
+		if err != nil || exists {
+			continue
+		}
+		finding.Path = version.PreviewPath
+		finding.Size = 0
+		finding.Action = "would render again"
+		if !options.DryRun {
+			// the preview sweep renders pending previews
+			finding.Action = h.reconcileAction("queued for rendering",
+				h.documentRepo.SetPreview(ctx, taskDocument.ID, version.FilePath, model.PreviewPending, ""))
+		}
+		report.MissingPreviews = append(report.MissingPreviews, finding)
+	}
+}
+
+// reconcileDocumentWithoutTask removes a document whose task is gone, metadata first like a
+// deletion by a user. Nothing takes its files off the storage usage of a project, which is
+// unknown without the task.
+func (h *TasksHandler) reconcileDocumentWithoutTask(ctx context.Context, taskDocument *model.TaskDocument, cutoff time.Time, options ReconcileOptions, report *ReconcileReport) {
+	finding := ReconcileFinding{
+		DocumentID: taskDocument.ID.Hex(),
+		TaskID:     taskDocument.TaskID,
+		Size:       taskDocument.Size,
+	}
+	uploadedAt := taskDocument.UploadedAt.Time()
+	switch {
+	case uploadedAt.After(cutoff):
+		finding.Action = "kept until " + uploadedAt.Add(options.Grace).Format(time.RFC3339)
+	case options.DryRun:
+		finding.Action = "would remove"
+	default:
+		err := h.documentRepo.DeleteTaskDocument(ctx, taskDocument.ID)
+		if err == nil {
+			for _, filePath := range taskDocument.FilePaths() {
+				h.removeStoredFile(ctx, filePath)
+			}
+		}
+		finding.Action = h.reconcileAction("removed", err)
+	}
+	report.DocumentsWithoutTask = append(report.DocumentsWithoutTask, finding)
+}
+
+// reconcileUpload removes an upload session that lost one of its chunks, it cannot be
+// finalized anymore
+func (h *TasksHandler) reconcileUpload(ctx context.Context, session *model.UploadSession, files map[string]storage.FileInfo, options ReconcileOptions, report *ReconcileReport) {
+	if session.FinalizingUntil != nil && session.FinalizingUntil.After(time.Now()) {
+		return
+	}
+	for _, chunk := range session.Chunks {
+		exists, err := h.storedFileExists(ctx, files, chunk.Path)
+		if err != nil {
+			h.logger.Printf("Error looking up chunk %s of upload %s: %v", chunk.Path, session.ID.Hex(), err)
+			return
+		}
+		if exists {
+			continue
+		}
+		finding := ReconcileFinding{Path: chunk.Path, UploadID: session.ID.Hex(), TaskID: session.TaskID, Action: "would remove"}
+		if !options.DryRun {
+			finding.Action = h.reconcileAction("removed", h.removeUpload(ctx, session))
+		}
+		report.BrokenUploads = append(report.BrokenUploads, finding)
+		return
+	}
+}
+
+// reconcileOptionsFromEnv reads DOCUMENT_RECONCILE and DOCUMENT_RECONCILE_GRACE_HOURS. It
+// reports false when the reconciliation is turned off.
+func reconcileOptionsFromEnv() (ReconcileOptions, bool) {
+	options := ReconcileOptions{DryRun: true, Grace: defaultReconcileGrace}
+	if hours, err := strconv.Atoi(os.Getenv("DOCUMENT_RECONCILE_GRACE_HOURS")); err == nil && hours > 0 {
+		options.Grace = time.Duration(hours) * time.Hour
+	}
+	switch strings.ToLower(os.Getenv("DOCUMENT_RECONCILE")) {
+	case "off":
+		return options, false
+	case "repair":
+		options.DryRun = false
+	}
+	return options, true
+}
+
+// RunDocumentReconciliation reconciles the document store with the documents every day until
+// ctx is done
+func (h *TasksHandler) RunDocumentReconciliation(ctx context.Context) {
+	options, enabled := reconcileOptionsFromEnv()
+	if !enabled {
+		return
+	}
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		report, err := h.ReconcileDocuments(ctx, options)
+		if err != nil {
+			h.logger.Println("Error reconciling documents:", err)
+		} else {
+			fields := logrus.Fields{
+				"dryRun":               report.DryRun,
+				"files":                report.Files,
+				"documents":            report.Documents,
+				"orphanFiles":          len(report.OrphanFiles),
+				"missingFiles":         len(report.MissingFiles),
+				"restoredFiles":        len(report.RestoredFiles),
+				"missingPreviews":      len(report.MissingPreviews),
+				"documentsWithoutTask": len(report.DocumentsWithoutTask),
+				"brokenUploads":        len(report.BrokenUploads),
+			}
+			if report.Problems() > 0 {
+				h.custLogger.Warn(fields, "Documents reconciled, inconsistencies found")
+			} else {
+				h.custLogger.Info(fields, "Documents reconciled")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"task--service/model"
+	"task--service/scanner"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Uploaded files stay pending until the scanner cleared them and cannot be downloaded before.
+// Each file is scanned right after its upload, and files whose scan did not finish, because
+// the scanner was unreachable or the service stopped, are picked up again by the sweep.
+// Infected files are moved to the quarantine directory, where they are kept for inspection
+// until their document is removed. Uploads are limited to the size the scanner accepts, files
+// stored before that are marked unscannable and stay blocked instead of being retried.
+const (
+	quarantineDir = "/quarantine/"
+
+	scanSweepInterval = 10 * time.Minute
+	// scanRetryDelay is how long a file stays pending before the sweep scans it again
+	scanRetryDelay     = 10 * time.Minute
+	scanSweepBatchSize = 50
+	scanRetryAfter     = 30
+)
+
+// initialDocumentStatus is the status of a newly uploaded file, which has none when uploads
+// are not scanned
+func (h *TasksHandler) initialDocumentStatus() model.DocumentStatus {
+	if h.scanner == nil {
+		return ""
+	}
+	return model.DocumentPending
+}
+
+// startDocumentScan scans the current file of a document in the background when it is pending
+func (h *TasksHandler) startDocumentScan(ctx context.Context, taskDocument *model.TaskDocument) {
+	version := taskDocument.CurrentVersion()
+	if h.scanner == nil || version.Status != model.DocumentPending {
+		return
+	}
+	go func() {
+		if err := h.scanDocumentFile(context.WithoutCancel(ctx), taskDocument.ID, version); err != nil {
+			h.logger.Printf("Error scanning file %s of document %s, it is retried later: %v", version.FilePath, taskDocument.ID.Hex(), err)
+		}
+	}()
+}
+
+// scanDocumentFile scans the stored file of a version and records the verdict in the
+// document. The preview and the text of a clean file are made next.
+func (h *TasksHandler) scanDocumentFile(ctx context.Context, docID primitive.ObjectID, version model.DocumentVersion) error {
+	ctx, span := h.tracer.Start(ctx, "TaskHandler.scanDocumentFile")
+	defer span.End()
+
+	filePath := version.FilePath
+	var result *scanner.Result
+	err := scanner.ErrTooLarge
+	if maxSize := h.scanner.MaxSize(); maxSize == 0 || version.Size <= maxSize {
+		file, getErr := h.documentStore.Get(ctx, filePath)
+		if getErr != nil {
+			span.RecordError(getErr)
+			span.SetStatus(codes.Error, getErr.Error())
+			return getErr
+		}
+		result, err = h.scanner.Scan(ctx, file)
+		file.Close()
+	}
+	if errors.Is(err, scanner.ErrTooLarge) {
+		if err := h.documentRepo.SetScanResult(ctx, docID, filePath, model.DocumentUnscannable, "", ""); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		h.custLogger.Warn(logrus.Fields{
+			"documentID": docID.Hex(),
+			"filePath":   filePath,
+			"size":       version.Size,
+			"scanner":    h.scanner.Name(),
+		}, "Document file is too large to be scanned and stays blocked")
+		span.SetStatus(codes.Ok, "Document file is too large to be scanned")
+		return nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("%s scan failed: %w", h.scanner.Name(), err)
+	}
+
+	if !result.Infected {
+		if err := h.documentRepo.SetScanResult(ctx, docID, filePath, model.DocumentClean, "", ""); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		span.SetStatus(codes.Ok, "Document file is clean")
+		if version.Preview == model.PreviewPending {
+			if err := h.renderDocumentPreview(ctx, docID, version); err != nil {
+				h.logger.Printf("Error rendering preview of file %s of document %s, it is retried later: %v", filePath, docID.Hex(), err)
+			}
+		}
+		if err := h.extractDocumentText(ctx, docID, version); err != nil {
+			h.logger.Printf("Error extracting text of file %s of document %s, it is retried later: %v", filePath, docID.Hex(), err)
+		}
+		return nil
+	}
+
+	quarantined, err := h.quarantineFile(ctx, filePath)
+	if err != nil {
+		// the file is still blocked from downloads where it is
+		h.logger.Printf("Error moving infected file %s to quarantine: %v", filePath, err)
+		quarantined = ""
+	}
+	if err := h.documentRepo.SetScanResult(ctx, docID, filePath, model.DocumentInfected, result.Signature, quarantined); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	h.custLogger.Warn(logrus.Fields{
+		"documentID": docID.Hex(),
+		"filePath":   filePath,
+		"quarantine": quarantined,
+		"signature":  result.Signature,
+		"scanner":    h.scanner.Name(),
+	}, "Infected document file quarantined")
+	span.SetStatus(codes.Ok, "Document file is infected")
+	return nil
+}
+
+// quarantineFile moves a file into the quarantine directory and returns its new path
+func (h *TasksHandler) quarantineFile(ctx context.Context, filePath string) (string, error) {
+	quarantined := path.Join(quarantineDir, path.Base(filePath))
+	file, err := h.documentStore.Get(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+	_, err = h.documentStore.Put(ctx, quarantined, file)
+	file.Close()
+	if err != nil {
+		return "", err
+	}
+	if err := h.documentStore.Delete(ctx, filePath); err != nil {
+		h.removeStoredFile(ctx, quarantined)
+		return "", err
+	}
+	return quarantined, nil
+}
+
+// RunDocumentScanSweep scans again the files whose scan did not finish until ctx is done. It
+// does nothing when uploads are not scanned.
+func (h *TasksHandler) RunDocumentScanSweep(ctx context.Context) {
+	if h.scanner == nil {
+		return
+	}
+	ticker := time.NewTicker(scanSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		h.scanPendingDocuments(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *TasksHandler) scanPendingDocuments(ctx context.Context) {
+	ctx, span := h.tracer.Start(ctx, "TaskHandler.scanPendingDocuments")
+	defer span.End()
+
+	documents, err := h.documentRepo.FindPendingScans(ctx, time.Now().Add(-scanRetryDelay), scanSweepBatchSize)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Println("Error fetching documents waiting for a scan:", err)
+		return
+	}
+	for _, taskDocument := range documents {
+		if err := h.documentRepo.RecordScanAttempt(ctx, taskDocument.ID); err != nil {
+			span.RecordError(err)
+			h.logger.Println("Error recording scan attempt:", err)
+		}
+		scanned := map[string]bool{}
+		for _, version := range taskDocument.AllVersions() {
+			if version.Status != model.DocumentPending || scanned[version.FilePath] {
+				continue
+			}
+			scanned[version.FilePath] = true
+			if err := h.scanDocumentFile(ctx, taskDocument.ID, version); err != nil {
+				span.RecordError(err)
+				h.logger.Printf("Error scanning file %s of document %s: %v", version.FilePath, taskDocument.ID.Hex(), err)
+			}
+		}
+	}
+	span.SetStatus(codes.Ok, "Successfully scanned pending documents")
+}
+
+// writeUnavailableDocument refuses the download of a file that is not known to be clean. It
+// reports whether the request was answered.
+func writeUnavailableDocument(w http.ResponseWriter, version *model.DocumentVersion) bool {
+	switch {
+	case version.Available():
+		return false
+	case version.Status == model.DocumentInfected:
+		http.Error(w, "Document is quarantined because malware was found in it", http.StatusForbidden)
+	case version.Status == model.DocumentUnscannable:
+		http.Error(w, "Document is too large to be scanned for malware and cannot be downloaded", http.StatusForbidden)
+	case version.Status == model.DocumentMissing:
+		http.Error(w, "Document file is missing from the document store", http.StatusNotFound)
+	default:
+		w.Header().Set("Retry-After", strconv.Itoa(scanRetryAfter))
+		http.Error(w, "Document is still being scanned for malware", http.StatusConflict)
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"task--service/extract"
+	"task--service/model"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// The text of the current file of a document is extracted once the file may be downloaded,
+// like its preview, and published as DocumentTextExtracted for the services that index
+// document contents. Text that was not extracted because the service stopped is picked up by
+// the sweep.
+const (
+	textSweepInterval = 10 * time.Minute
+	// textRetryDelay is how long text stays pending before the sweep extracts it again
+	textRetryDelay     = 10 * time.Minute
+	textSweepBatchSize = 50
+)
+
+// startDocumentTextExtraction extracts the text of the current file of a document in the
+// background when it is pending and the file may already be read
+func (h *TasksHandler) startDocumentTextExtraction(ctx context.Context, taskDocument *model.TaskDocument) {
+	version := taskDocument.CurrentVersion()
+	if taskDocument.TextStatus != model.TextPending || !version.Available() {
+		return
+	}
+	go func() {
+		if err := h.extractDocumentText(context.WithoutCancel(ctx), taskDocument.ID, version); err != nil {
+			h.logger.Printf("Error extracting text of file %s of document %s, it is retried later: %v", version.FilePath, taskDocument.ID.Hex(), err)
+		}
+	}()
+}
+
+// extractDocumentText extracts the text of the file of a version and records it in the
+// document when the file is still its current one. Files whose text cannot be read are marked
+// as such, an error is only returned when extraction should be tried again.
+func (h *TasksHandler) extractDocumentText(ctx context.Context, docID primitive.ObjectID, version model.DocumentVersion) error {
+	ctx, span := h.tracer.Start(ctx, "TaskHandler.extractDocumentText")
+	defer span.End()
+
+	if !extract.Supported(version.FileType, version.FileName) {
+		_, err := h.documentRepo.SetText(ctx, docID, version.FilePath, model.TextUnsupported, "")
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	file, err := h.documentStore.Get(ctx, version.FilePath)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	size := version.Size
+	if size <= 0 {
+		// documents uploaded before sizes were recorded
+		if size, err = file.Seek(0, io.SeekEnd); err == nil {
+			_, err = file.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			file.Close()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+	}
+	text, extractErr := extract.Text(file, size, version.FileType, version.FileName)
+	file.Close()
+
+	status := model.TextExtracted
+	if extractErr != nil {
+		span.RecordError(extractErr)
+		h.custLogger.Warn(logrus.Fields{
+			"documentID": docID.Hex(),
+			"filePath":   version.FilePath,
+			"fileType":   version.FileType,
+			"error":      extractErr.Error(),
+		}, "Document text could not be extracted")
+		status, text = model.TextFailed, ""
+		if errors.Is(extractErr, extract.ErrUnsupported) {
+			status = model.TextUnsupported
+		}
+	}
+	stored, err := h.documentRepo.SetText(ctx, docID, version.FilePath, status, text)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	if stored && status == model.TextExtracted {
+		h.publishDocumentText(ctx, docID, version, text)
+	}
+	span.SetStatus(codes.Ok, "Document text extracted")
+	return nil
+}
+
+// publishDocumentText tells the services that index documents about the text of one
+func (h *TasksHandler) publishDocumentText(ctx context.Context, docID primitive.ObjectID, version model.DocumentVersion, text string) {
+	taskDocument, err := h.documentRepo.GetTaskDocumentByID(ctx, docID)
+	if err != nil || taskDocument == nil {
+		h.logger.Printf("Error fetching document %s for its text: %v", docID.Hex(), err)
+		return
+	}
+	task, err := h.repo.GetByID(ctx, taskDocument.TaskID)
+	if err != nil || task == nil {
+		h.logger.Printf("Error fetching task of document %s for its text: %v", docID.Hex(), err)
+		return
+	}
+	h.publishEvent(ctx, "DocumentTextExtracted", map[string]interface{}{
+		"documentId": docID.Hex(),
+		"taskId":     taskDocument.TaskID,
+		"projectId":  task.ProjectID,
+		"fileName":   taskDocument.FileName,
+		"fileType":   version.FileType,
+		"version":    version.Number,
+		"text":       text,
+	})
+}
+
+// RunDocumentTextSweep extracts the text that was not extracted until ctx is done
+func (h *TasksHandler) RunDocumentTextSweep(ctx context.Context) {
+	ticker := time.NewTicker(textSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		h.extractPendingTexts(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *TasksHandler) extractPendingTexts(ctx context.Context) {
+	ctx, span := h.tracer.Start(ctx, "TaskHandler.extractPendingTexts")
+	defer span.End()
+
+	documents, err := h.documentRepo.FindPendingTexts(ctx, time.Now().Add(-textRetryDelay), textSweepBatchSize)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Println("Error fetching documents waiting for their text:", err)
+		return
+	}
+	for _, taskDocument := range documents {
+		version := taskDocument.CurrentVersion()
+		if err := h.extractDocumentText(ctx, taskDocument.ID, version); err != nil {
+			span.RecordError(err)
+			h.logger.Printf("Error extracting text of file %s of document %s: %v", version.FilePath, taskDocument.ID.Hex(), err)
+		}
+	}
+	span.SetStatus(codes.Ok, "Successfully extracted pending texts")
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"task--service/model"
+	"task--service/storage"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// getRequestDocument loads the document named by documentId in the path of the request
+func (h *TasksHandler) getRequestDocument(ctx context.Context, w http.ResponseWriter, r *http.Request) (*model.TaskDocument, *model.Task) {
+	return h.getAccessibleDocument(ctx, w, r, mux.Vars(r)["documentId"])
+}
+
+// getAccessibleDocument loads a document together with its task, for a user who belongs to
+// the project of the task. Documents of other projects are reported as forbidden.
+func (h *TasksHandler) getAccessibleDocument(ctx context.Context, w http.ResponseWriter, r *http.Request, documentID string) (*model.TaskDocument, *model.Task) {
+	docID, err := primitive.ObjectIDFromHex(documentID)
+	if err != nil {
+		http.Error(w, "Invalid documentId format", http.StatusBadRequest)
+		return nil, nil
+	}
+	taskDocument, err := h.documentRepo.GetTaskDocumentByID(ctx, docID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to fetch task document: %v", err), http.StatusInternalServerError)
+		return nil, nil
+	}
+	if taskDocument == nil {
+		http.Error(w, "Task document not found", http.StatusNotFound)
+		return nil, nil
+	}
+	task, err := h.repo.GetByID(ctx, taskDocument.TaskID)
+	if err != nil || task == nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return nil, nil
+	}
+	if !h.canAccessProject(ctx, r, task.ProjectID) {
+		http.Error(w, "You are not a member of this project", http.StatusForbidden)
+		return nil, nil
+	}
+	return taskDocument, task
+}
+
+// requestVersion returns the version of a document named in the path of the request
+func requestVersion(w http.ResponseWriter, r *http.Request, taskDocument *model.TaskDocument) *model.DocumentVersion {
+	number, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil {
+		http.Error(w, "Version must be a number", http.StatusBadRequest)
+		return nil
+	}
+	version := taskDocument.GetVersion(number)
+	if version == nil {
+		http.Error(w, fmt.Sprintf("Document has no version %d", number), http.StatusNotFound)
+		return nil
+	}
+	return version
+}
+
+// GetTaskDocumentVersions lists the versions of a document with who uploaded them and when,
+// newest first
+func (h *TasksHandler) GetTaskDocumentVersions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.GetTaskDocumentVersions")
+	defer span.End()
+
+	taskDocument, _ := h.getRequestDocument(ctx, w, r)
+	if taskDocument == nil {
+		return
+	}
+	all := taskDocument.AllVersions()
+	versions := make([]model.DocumentVersion, 0, len(all))
+	for i := len(all) - 1; i >= 0; i-- {
+		versions = append(versions, all[i])
+	}
+
+	span.SetStatus(codes.Ok, "Successfully fetched document versions")
+	if err := json.NewEncoder(w).Encode(versions); err != nil {
+		span.RecordError(err)
+		h.logger.Println("Error encoding document versions:", err)
+	}
+}
+
+// DownloadTaskDocumentVersion streams the file of one version of a document, with the same
+// Range and caching support as the download of the current version
+func (h *TasksHandler) DownloadTaskDocumentVersion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.DownloadTaskDocumentVersion")
+	defer span.End()
+
+	taskDocument, _ := h.getRequestDocument(ctx, w, r)
+	if taskDocument == nil {
+		return
+	}
+	version := requestVersion(w, r, taskDocument)
+	if version == nil {
+		return
+	}
+
+	if writeUnavailableDocument(w, version) {
+		return
+	}
+
+	file, err := h.documentStore.Get(ctx, version.FilePath)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Document file not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to get file: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(documentTransferTimeout)); err != nil {
+		h.logger.Println("Error extending write deadline:", err)
+	}
+	writeDocumentHeaders(w, taskDocument, *version)
+	http.ServeContent(w, r, "", version.UploadedAt.Time(), file)
+	span.SetStatus(codes.Ok, "Successfully sent file content")
+}
+
+// RevertTaskDocument makes an earlier version current again. The revert is added as a new
+// version that shares the file of the earlier one, so the history is kept.
+func (h *TasksHandler) RevertTaskDocument(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.RevertTaskDocument")
+	defer span.End()
+
+	userID, ok := r.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("User ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(w, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	taskDocument, task := h.getRequestDocument(ctx, w, r)
+	if taskDocument == nil {
+		return
+	}
+	target := requestVersion(w, r, taskDocument)
+	if target == nil {
+		return
+	}
+	if target.Number == taskDocument.CurrentVersion().Number {
+		http.Error(w, fmt.Sprintf("Version %d is already the current version", target.Number), http.StatusConflict)
+		return
+	}
+	if !target.Available() {
+		http.Error(w, fmt.Sprintf("Version %d is %s and cannot be made current", target.Number, target.Status), http.StatusConflict)
+		return
+	}
+
+	version := *target
+	version.UploadedBy = userID
+	version.UploadedAt = primitive.NewDateTimeFromTime(time.Now())
+	version.RevertedFrom = target.Number
+	added, err := h.documentRepo.AddVersion(ctx, taskDocument, version)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, "Failed to revert task document", http.StatusInternalServerError)
+		return
+	}
+	if !added {
+		http.Error(w, "Document was changed by another upload, try again", http.StatusConflict)
+		return
+	}
+	h.custLogger.Info(logrus.Fields{
+		"documentID":   taskDocument.ID.Hex(),
+		"taskID":       taskDocument.TaskID,
+		"version":      taskDocument.Version,
+		"revertedFrom": target.Number,
+	}, "Task document reverted")
+
+	if err := h.documentAdded(ctx, task, taskDocument, userID); err != nil {
+		http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetStatus(codes.Ok, "Successfully reverted task document")
+	if err := taskDocument.ToJSON(w); err != nil {
+		span.RecordError(err)
+		h.logger.Println("Error encoding task document:", err)
+	}
+}
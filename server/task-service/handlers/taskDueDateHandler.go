@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"task--service/model"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	dueDateSchedulerInterval  = time.Minute
+	dueDateSchedulerBatchSize = 100
+	dueDateSchedulerLease     = "due-date-scheduler"
+	// the lease outlives a missed tick, so another replica only takes over when the holder is gone
+	dueDateSchedulerLeaseTTL = 2 * dueDateSchedulerInterval
+	defaultReminderHours     = 24
+	maxReminderOffsets       = 5
+	maxReminderHours         = 30 * 24
+)
+
+type dueDateRequest struct {
+	DueDate *time.Time `json:"dueDate"`
+}
+
+// defaultReminderOffsets are the reminders of users without a preference, configured with
+// REMINDER_DEFAULT_HOURS as a comma separated list of hours before the due date
+func defaultReminderOffsets() []int {
+	var offsets []int
+	for _, value := range strings.Split(os.Getenv("REMINDER_DEFAULT_HOURS"), ",") {
+		hours, err := strconv.Atoi(strings.TrimSpace(value))
+		if err == nil && hours > 0 && hours <= maxReminderHours {
+			offsets = append(offsets, hours)
+		}
+	}
+	if len(offsets) == 0 {
+		return []int{defaultReminderHours}
+	}
+	return offsets
+}
+
+// UpdateTaskDueDate lets the project manager set or clear the due date of a task
+func (t *TasksHandler) UpdateTaskDueDate(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.UpdateTaskDueDate")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+		return
+	}
+
+	var request dueDateRequest
+	if err := json.NewDecoder(h.Body).Decode(&request); err != nil {
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	taskID := mux.Vars(h)["taskId"]
+	task, err := t.repo.GetByID(ctx, taskID)
+	if err != nil || task.PendingDeletion {
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !t.isProjectManager(ctx, task.ProjectID, cookie) {
+		span.SetStatus(codes.Error, "User is not the project manager")
+		http.Error(rw, "Only the project manager can change the due date", http.StatusForbidden)
+		return
+	}
+
+	if request.DueDate != nil {
+		dueDate := request.DueDate.UTC()
+		request.DueDate = &dueDate
+	}
+	if err := t.repo.UpdateDueDate(ctx, task, request.DueDate); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to update due date", http.StatusInternalServerError)
+		return
+	}
+
+	t.publishEvent(ctx, "task.due.changed", map[string]interface{}{
+		"taskId":     taskID,
+		"taskName":   task.Name,
+		"projectId":  task.ProjectID,
+		"dueDate":    request.DueDate,
+		"changedBy":  userID,
+		"memberIds":  task.UserIDs,
+		"watcherIds": t.watcherIDs(ctx, task),
+	})
+	t.custLogger.Info(logrus.Fields{"taskID": taskID, "dueDate": request.DueDate, "changedBy": userID}, "Task due date updated")
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(task); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error encoding task:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully updated due date")
+}
+
+// GetReminderPreference returns how many hours before a due date the caller is reminded
+func (t *TasksHandler) GetReminderPreference(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetReminderPreference")
+	defer span.End()
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	preference, err := t.reminderRepo.GetPreference(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to get reminder preference", http.StatusInternalServerError)
+		return
+	}
+	if preference == nil {
+		preference = &model.ReminderPreference{UserID: userID, OffsetsHours: defaultReminderOffsets()}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := preference.ToJSON(rw); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error encoding reminder preference:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully got reminder preference")
+}
+
+// UpdateReminderPreference replaces the reminder offsets of the caller. An empty list turns
+// the reminders off.
+func (t *TasksHandler) UpdateReminderPreference(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.UpdateReminderPreference")
+	defer span.End()
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	preference := &model.ReminderPreference{}
+	if err := preference.FromJSON(h.Body); err != nil {
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(preference.OffsetsHours) > maxReminderOffsets {
+		http.Error(rw, fmt.Sprintf("At most %d reminders are allowed", maxReminderOffsets), http.StatusBadRequest)
+		return
+	}
+	for _, hours := range preference.OffsetsHours {
+		if hours <= 0 || hours > maxReminderHours {
+			http.Error(rw, fmt.Sprintf("Reminders must be between 1 and %d hours before the due date", maxReminderHours), http.StatusBadRequest)
+			return
+		}
+	}
+	slices.Sort(preference.OffsetsHours)
+	preference.OffsetsHours = slices.Compact(preference.OffsetsHours)
+	if preference.OffsetsHours == nil {
+		preference.OffsetsHours = []int{}
+	}
+	preference.UserID = userID
+
+	if err := t.reminderRepo.SavePreference(ctx, preference); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to save reminder preference", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := preference.ToJSON(rw); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error encoding reminder preference:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully saved reminder preference")
+}
+
+// RunDueDateScheduler reminds assignees of upcoming due dates and escalates overdue tasks until
+// ctx is done. Only the replica holding the scheduler lease does the work, and every reminder
+// and escalation is recorded on the task before it is published, so neither a restart nor a
+// second replica sends it again.
+func (t *TasksHandler) RunDueDateScheduler(ctx context.Context) {
+	ticker := time.NewTicker(dueDateSchedulerInterval)
+	defer ticker.Stop()
+	owner := primitive.NewObjectID().Hex()
+
+	for {
+		t.processDueDates(ctx, owner)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *TasksHandler) processDueDates(ctx context.Context, owner string) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.processDueDates")
+	defer span.End()
+
+	acquired, err := t.reminderRepo.AcquireLease(ctx, dueDateSchedulerLease, owner, dueDateSchedulerLeaseTTL)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error acquiring due date scheduler lease:", err)
+		return
+	}
+	if !acquired {
+		span.SetStatus(codes.Ok, "Due date scheduler runs on another replica")
+		return
+	}
+
+	now := time.Now()
+	t.sendDueReminders(ctx, now)
+	t.escalateOverdueTasks(ctx, now)
+	span.SetStatus(codes.Ok, "Successfully processed due dates")
+}
+
+// sendDueReminders reminds every assignee once for each of their offsets that has been reached
+func (t *TasksHandler) sendDueReminders(ctx context.Context, now time.Time) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.sendDueReminders")
+	defer span.End()
+
+	// the whole window is read in pages, tasks whose reminders were all sent would otherwise
+	// keep the later ones out of a single batch
+	defaults := defaultReminderOffsets()
+	var after *model.Task
+	for {
+		tasks, err := t.repo.FindDueBetween(ctx, now, now.Add(maxReminderHours*time.Hour), after, dueDateSchedulerBatchSize)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			t.logger.Println("Error fetching tasks due soon:", err)
+			return
+		}
+		for i := range tasks {
+			t.sendTaskReminders(ctx, &tasks[i], now, defaults)
+		}
+		if len(tasks) < dueDateSchedulerBatchSize {
+			break
+		}
+		after = &tasks[len(tasks)-1]
+	}
+	span.SetStatus(codes.Ok, "Successfully sent due date reminders")
+}
+
+// sendTaskReminders reminds the assignees of a task whose offsets have been reached and who
+// were not reminded for them yet
+func (t *TasksHandler) sendTaskReminders(ctx context.Context, task *model.Task, now time.Time, defaults []int) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.sendTaskReminders")
+	defer span.End()
+
+	if len(task.UserIDs) == 0 {
+		return
+	}
+	preferences, err := t.reminderRepo.GetPreferences(ctx, task.UserIDs)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Printf("Error fetching reminder preferences for task %s: %v", task.ID.Hex(), err)
+		return
+	}
+
+	var keys, userIDs []string
+	for _, userID := range task.UserIDs {
+		offsets, ok := preferences[userID]
+		if !ok {
+			offsets = defaults
+		}
+		for _, hours := range offsets {
+			key := fmt.Sprintf("%s:%d", userID, hours)
+			if task.DueDate.Sub(now) > time.Duration(hours)*time.Hour || slices.Contains(task.RemindersSent, key) {
+				continue
+			}
+			keys = append(keys, key)
+			if !slices.Contains(userIDs, userID) {
+				userIDs = append(userIDs, userID)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	marked, err := t.repo.MarkRemindersSent(ctx, task.ID, keys)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Println("Error recording due date reminders:", err)
+		return
+	}
+	if !marked {
+		return
+	}
+	t.publishEvent(ctx, "task.due.reminder", map[string]interface{}{
+		"taskId":    task.ID.Hex(),
+		"taskName":  task.Name,
+		"projectId": task.ProjectID,
+		"dueDate":   task.DueDate,
+		"userIds":   userIDs,
+	})
+	span.SetStatus(codes.Ok, "Successfully sent task reminders")
+}
+
+// escalateOverdueTasks flags the tasks that passed their due date and notifies the assignees,
+// the watchers and the project manager
+func (t *TasksHandler) escalateOverdueTasks(ctx context.Context, now time.Time) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.escalateOverdueTasks")
+	defer span.End()
+
+	tasks, err := t.repo.FindNewlyOverdue(ctx, now, dueDateSchedulerBatchSize)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error fetching overdue tasks:", err)
+		return
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		marked, err := t.repo.MarkOverdue(ctx, task.ID)
+		if err != nil {
+			span.RecordError(err)
+			t.logger.Println("Error marking task as overdue:", err)
+			continue
+		}
+		if !marked {
+			continue
+		}
+
+		managerID, err := t.projectClient.GetManagerID(ctx, task.ProjectID)
+		if err != nil {
+			// the assignees are still notified when project-service is unavailable
+			span.RecordError(err)
+			t.logger.Printf("Error fetching manager of project %s: %v", task.ProjectID, err)
+		}
+		t.publishEvent(ctx, "task.overdue", map[string]interface{}{
+			"taskId":     task.ID.Hex(),
+			"taskName":   task.Name,
+			"projectId":  task.ProjectID,
+			"dueDate":    task.DueDate,
+			"memberIds":  task.UserIDs,
+			"managerId":  managerID,
+			"watcherIds": t.watcherIDs(ctx, task),
+		})
+		t.custLogger.Info(logrus.Fields{"taskID": task.ID.Hex(), "dueDate": task.DueDate}, "Task is overdue")
+	}
+	span.SetStatus(codes.Ok, "Successfully escalated overdue tasks")
+}
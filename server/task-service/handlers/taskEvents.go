@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// publishEvent publishes a domain event for other services, such as the search index.
+// Failures are only logged, the change that caused the event has already been stored.
+func (t *TasksHandler) publishEvent(ctx context.Context, subject string, message interface{}) {
+	_, span := t.tracer.Start(ctx, "TaskHandler.publishEvent")
+	defer span.End()
+
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Error marshalling %s message: %v", subject, err)
+		return
+	}
+
+	if err := t.natsConn.Publish(subject, jsonMessage); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Error publishing %s to NATS: %v", subject, err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully published "+subject)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"task--service/model"
+	"task--service/repositories"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// projectExport is everything task-service holds about a project, project-service bundles it
+// into the project export archive. The document files themselves are downloaded one by one.
+type projectExport struct {
+	Tasks     []model.Task         `json:"tasks"`
+	Comments  []*model.TaskComment `json:"comments"`
+	Documents []model.TaskDocument `json:"documents"`
+}
+
+// GetProjectExport returns the tasks of a project together with their comments and the
+// metadata of their documents
+func (t *TasksHandler) GetProjectExport(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetProjectExport")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	projectID := mux.Vars(h)["projectId"]
+	if !t.canAccessProject(ctx, h, projectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "You are not a member of this project", http.StatusForbidden)
+		return
+	}
+
+	tasks, err := t.repo.FindByProject(ctx, projectID, repositories.TaskQuery{})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to fetch tasks", http.StatusInternalServerError)
+		return
+	}
+
+	export := projectExport{
+		Tasks:     tasks,
+		Comments:  []*model.TaskComment{},
+		Documents: []model.TaskDocument{},
+	}
+	for _, task := range tasks {
+		taskID := task.ID.Hex()
+		comments, err := t.commentRepo.GetTaskCommentsByTaskID(ctx, taskID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			http.Error(rw, "Failed to fetch comments", http.StatusInternalServerError)
+			return
+		}
+		export.Comments = append(export.Comments, comments...)
+
+		documents, err := t.documentRepo.GetTaskDocumentsByTaskID(ctx, taskID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			http.Error(rw, "Failed to fetch documents", http.StatusInternalServerError)
+			return
+		}
+		export.Documents = append(export.Documents, documents...)
+	}
+
+	t.custLogger.Info(logrus.Fields{
+		"projectID": projectID,
+		"tasks":     len(export.Tasks),
+		"comments":  len(export.Comments),
+		"documents": len(export.Documents),
+	}, "Project export data collected")
+
+	if err := json.NewEncoder(rw).Encode(export); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Failed to encode project export:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully exported project tasks")
+}
+
+// ImportProjectComments restores the comments of a project archive into a project created by
+// the import. The comments name the new tasks, but keep the comment IDs of the archive so that
+// replies can be connected to their parents; every comment gets a new ID. Authors and mentions
+// that are not part of the project are replaced by the importing manager and dropped.
+func (t *TasksHandler) ImportProjectComments(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.ImportProjectComments")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+		return
+	}
+	projectID := mux.Vars(h)["projectId"]
+	if !t.isProjectManager(ctx, projectID, cookie) {
+		span.SetStatus(codes.Error, "User is not the manager of the project")
+		http.Error(rw, "Only the manager of the project can import comments", http.StatusForbidden)
+		return
+	}
+
+	var comments []*model.TaskComment
+	if err := json.NewDecoder(h.Body).Decode(&comments); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// parents are created before their replies, so the new ID of a parent is known in time
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	tasks := map[string]*model.Task{}
+	members := map[string]bool{userID: true}
+	isMember := func(id string) bool {
+		if _, checked := members[id]; !checked {
+			members[id] = t.isUserInProject(ctx, projectID, id)
+		}
+		return members[id]
+	}
+	newIDs := map[string]string{}
+	for _, comment := range comments {
+		task, found := tasks[comment.TaskID]
+		if !found {
+			task, err = t.repo.GetByID(ctx, comment.TaskID)
+			if err != nil || task == nil || task.ProjectID != projectID {
+				http.Error(rw, "Task "+comment.TaskID+" is not part of the project", http.StatusBadRequest)
+				return
+			}
+			tasks[comment.TaskID] = task
+		}
+		comment.Body = strings.TrimSpace(comment.Body)
+		if comment.Body == "" {
+			http.Error(rw, "Comment body cannot be empty", http.StatusBadRequest)
+			return
+		}
+
+		archivedID := comment.ID
+		comment.ID = primitive.NewObjectID()
+		if !archivedID.IsZero() {
+			newIDs[archivedID.Hex()] = comment.ID.Hex()
+		}
+		comment.ProjectID = projectID
+		comment.ParentID = newIDs[comment.ParentID]
+		if comment.CreatedAt.IsZero() {
+			comment.CreatedAt = time.Now()
+		}
+		if !isMember(comment.AuthorID) {
+			comment.AuthorID = userID
+		}
+		mentions := []string{}
+		for _, mention := range comment.Mentions {
+			if isMember(mention) {
+				mentions = append(mentions, mention)
+			}
+		}
+		comment.Mentions = mentions
+	}
+
+	if err := t.commentRepo.ImportTaskComments(ctx, comments); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to save comments", http.StatusInternalServerError)
+		return
+	}
+	for _, comment := range comments {
+		// without the task nobody is notified, the comments are only picked up for search
+		t.publishCommentSaved(ctx, comment, nil)
+	}
+	t.custLogger.Info(logrus.Fields{
+		"projectID":   projectID,
+		"comments":    len(comments),
+		"imported_by": userID,
+	}, "Project comments imported")
+
+	rw.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(rw).Encode(map[string]int{"imported": len(comments)}); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully imported comments")
+}
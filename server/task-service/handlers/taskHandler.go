@@ -8,14 +8,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/colinmarc/hdfs/v2"
 	"github.com/eapache/go-resiliency/retrier"
-	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/nats-io/nats.go"
 	"github.com/sirupsen/logrus"
 	"github.com/sony/gobreaker"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
@@ -25,7 +22,6 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
@@ -34,32 +30,52 @@ import (
 	"task--service/domain"
 	"task--service/model"
 	"task--service/repositories"
+	"task--service/scanner"
+	"task--service/storage"
 	"time"
 )
 
 type TasksHandler struct {
-	logger       *log.Logger
-	repo         *repositories.TaskRepository
-	documentRepo *repositories.TaskDocumentRepository
-	natsConn     *nats.Conn
-	tracer       trace.Tracer
-	userClient   client.UserClient
-	custLogger   *customLogger.Logger
+	logger            *log.Logger
+	repo              *repositories.TaskRepository
+	documentRepo      *repositories.TaskDocumentRepository
+	commentRepo       *repositories.TaskCommentRepository
+	workLogRepo       *repositories.WorkLogRepository
+	templateRepo      *repositories.TaskTemplateRepository
+	reminderRepo      *repositories.ReminderRepository
+	calendarFeedRepo  *repositories.CalendarFeedRepository
+	uploadSessionRepo *repositories.UploadSessionRepository
+	documentStore     storage.DocumentStore
+	scanner           scanner.Scanner
+	natsConn          *nats.Conn
+	tracer            trace.Tracer
+	userClient        client.UserClient
+	projectClient     client.ProjectClient
+	custLogger        *customLogger.Logger
 }
 
 type KeyTask struct{}
 type KeyId struct{}
 type KeyRole struct{}
 
-func NewTasksHandler(l *log.Logger, r *repositories.TaskRepository, docRepo *repositories.TaskDocumentRepository, natsConn *nats.Conn, tracer trace.Tracer, userClient client.UserClient, custLogger *customLogger.Logger) *TasksHandler {
+func NewTasksHandler(l *log.Logger, r *repositories.TaskRepository, docRepo *repositories.TaskDocumentRepository, commentRepo *repositories.TaskCommentRepository, workLogRepo *repositories.WorkLogRepository, templateRepo *repositories.TaskTemplateRepository, reminderRepo *repositories.ReminderRepository, calendarFeedRepo *repositories.CalendarFeedRepository, uploadSessionRepo *repositories.UploadSessionRepository, documentStore storage.DocumentStore, documentScanner scanner.Scanner, natsConn *nats.Conn, tracer trace.Tracer, userClient client.UserClient, projectClient client.ProjectClient, custLogger *customLogger.Logger) *TasksHandler {
 	return &TasksHandler{
-		logger:       l,
-		repo:         r,
-		documentRepo: docRepo,
-		natsConn:     natsConn,
-		tracer:       tracer,
-		userClient:   userClient,
-		custLogger:   custLogger,
+		logger:            l,
+		repo:              r,
+		documentRepo:      docRepo,
+		commentRepo:       commentRepo,
+		workLogRepo:       workLogRepo,
+		templateRepo:      templateRepo,
+		reminderRepo:      reminderRepo,
+		calendarFeedRepo:  calendarFeedRepo,
+		uploadSessionRepo: uploadSessionRepo,
+		documentStore:     documentStore,
+		scanner:           documentScanner,
+		natsConn:          natsConn,
+		tracer:            tracer,
+		userClient:        userClient,
+		projectClient:     projectClient,
+		custLogger:        custLogger,
 	}
 }
 
@@ -87,41 +103,41 @@ func (t *TasksHandler) PostTask(rw http.ResponseWriter, h *http.Request) {
 		return
 	}
 	t.custLogger.Info(logrus.Fields{"taskID": task.ID}, "Task data retrieved successfully")
+	clearServerFields(task)
 
-	// Ubacivanje Task-a u repozitorijum
-	err := t.repo.Insert(ctx, task)
+	if len(task.Labels) > 0 {
+		labels, err := t.resolveProjectLabels(ctx, h, task.ProjectID, task.Labels)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		task.Labels = labels
+	}
 
+	customFields, err := t.validateCustomFields(ctx, h, task.ProjectID, task.CustomFields, true)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		errMsg := "Unable to create task: " + err.Error()
-		t.logger.Printf(errMsg)
-		t.custLogger.Error(logrus.Fields{"taskID": task.ID}, errMsg)
-		http.Error(rw, "Unable to create task", http.StatusInternalServerError)
+		writeCustomFieldError(rw, err)
 		return
 	}
-	span.SetStatus(codes.Ok, "Successfully created task")
-	t.custLogger.Info(logrus.Fields{"taskID": task.ID}, "Task created successfully")
-	t.custLogger.Info(logrus.Fields{"projectID": task.ProjectID}, "ProjectID")
-
-	currentTime := time.Now().Add(1 * time.Hour)
-	formattedTime := currentTime.Format(time.RFC3339)
+	task.CustomFields = customFields
 
-	event := map[string]interface{}{
-		"type": "TaskCreated",
-		"time": formattedTime,
-		"event": map[string]interface{}{
-			"taskId":    task.ID,
-			"projectId": task.ProjectID,
-		},
-		"projectId": task.ProjectID,
-	}
-
-	// Send the event to the analytic service
-	if err := t.sendEventToAnalyticsService(ctx, event); err != nil {
+	// Ubacivanje Task-a u repozitorijum
+	err = t.createTask(ctx, task)
+	if errors.Is(err, errTaskCreatedEvent) {
 		http.Error(rw, "Failed to send event to analytics service", http.StatusInternalServerError)
 		return
 	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to create task", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully created task")
 
 	taskIDStr := task.ID.Hex()
 	// Slanje odgovora
@@ -141,6 +157,74 @@ func (t *TasksHandler) PostTask(rw http.ResponseWriter, h *http.Request) {
 	t.custLogger.Info(nil, "Task creation response sent successfully")
 }
 
+// clearServerFields drops what a client sent for the fields only the server sets: a new task
+// starts outside of the trash, without watchers, which users add through WatchTask, without
+// reminders and not as an occurrence of a recurring task
+func clearServerFields(task *model.Task) {
+	task.PendingDeletion = false
+	task.Trashed = false
+	task.DeletedAt = nil
+	task.DeletedBy = ""
+	task.Watchers = nil
+	task.Unwatchers = nil
+	task.Overdue = false
+	task.RemindersSent = nil
+	task.TemplateID = ""
+}
+
+// errTaskCreatedEvent means the task was stored but analytics did not accept its TaskCreated event
+var errTaskCreatedEvent = errors.New("failed to send TaskCreated event")
+
+// createTask stores a validated task and reports it to analytics. It is shared by PostTask
+// and the recurring task scheduler, so both produce the same kind of task.
+func (t *TasksHandler) createTask(ctx context.Context, task *model.Task) error {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.createTask")
+	defer span.End()
+
+	if err := t.repo.Insert(ctx, task); err != nil {
+		if !errors.Is(err, repositories.ErrOccurrenceExists) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			errMsg := "Unable to create task: " + err.Error()
+			t.logger.Print(errMsg)
+			t.custLogger.Error(logrus.Fields{"taskID": task.ID}, errMsg)
+		}
+		return err
+	}
+	t.custLogger.Info(logrus.Fields{"taskID": task.ID}, "Task created successfully")
+	t.custLogger.Info(logrus.Fields{"projectID": task.ProjectID}, "ProjectID")
+
+	t.publishEvent(ctx, "task.created", map[string]string{
+		"taskId":      task.ID.Hex(),
+		"projectId":   task.ProjectID,
+		"name":        task.Name,
+		"description": task.Description,
+	})
+
+	currentTime := time.Now().Add(1 * time.Hour)
+	formattedTime := currentTime.Format(time.RFC3339)
+
+	event := map[string]interface{}{
+		"type": "TaskCreated",
+		"time": formattedTime,
+		"event": map[string]interface{}{
+			"taskId":    task.ID,
+			"projectId": task.ProjectID,
+			"labels":    task.Labels,
+		},
+		"projectId": task.ProjectID,
+	}
+
+	// Send the event to the analytic service
+	if err := t.sendEventToAnalyticsService(ctx, event); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("%w: %v", errTaskCreatedEvent, err)
+	}
+	span.SetStatus(codes.Ok, "Successfully created task")
+	return nil
+}
+
 func (t *TasksHandler) GetAllTask(rw http.ResponseWriter, h *http.Request) {
 	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetAllTask")
 	defer span.End()
@@ -187,8 +271,16 @@ func (t *TasksHandler) GetAllTasksByProjectId(rw http.ResponseWriter, h *http.Re
 	projectID := vars["projectId"]
 	t.custLogger.Info(logrus.Fields{"projectID": projectID}, "Extracted project ID from request")
 
-	// Preuzimanje zadataka za dati projectID
-	tasks, err := t.repo.GetAllByProjectId(ctx, projectID)
+	// Preuzimanje zadataka za dati projectID, opciono filtriranih po labelama (?labels=bug,frontend)
+	// i custom poljima (?field.<fieldId>=value&sort=-field.<fieldId>)
+	query, err := t.parseTaskQuery(ctx, h, projectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tasks, err := t.repo.FindByProject(ctx, projectID, query)
 
 	//http.Error(rw, "Service unavailable for testing", http.StatusServiceUnavailable)
 	//return
@@ -259,6 +351,9 @@ func (t *TasksHandler) GetAllTasksDetailsByProjectId(rw http.ResponseWriter, h *
 
 	// Step 5: Fetch user details for each task
 	for _, task := range tasks {
+		if task.PendingDeletion {
+			continue
+		}
 		t.custLogger.Info(logrus.Fields{"taskID": task.ID}, "Fetching user details for task")
 		usersDetails, err := t.userClient.GetByIdsWithCookies(task.UserIDs, cookie)
 		if err != nil {
@@ -283,6 +378,8 @@ func (t *TasksHandler) GetAllTasksDetailsByProjectId(rw http.ResponseWriter, h *
 			Users:        usersDetails,
 			Dependencies: task.Dependencies,
 			Blocked:      task.Blocked,
+			Labels:       task.Labels,
+			CustomFields: task.CustomFields,
 		}
 
 		// Add the task with user details to the result slice
@@ -315,33 +412,7 @@ func (t *TasksHandler) HandleProjectDeleted(ctx context.Context, projectID strin
 		_ = t.natsConn.Publish("TaskDeletionFailed", []byte(projectID))
 	}
 	t.logger.Printf("Successfully deleted all tasks for project %s", projectID)
-
-	err = t.natsConn.Publish("TasksDeleted", []byte(projectID))
-	if err != nil {
-		t.logger.Printf("Failed to publish TasksDeleted event for project %s: %v", projectID, err)
-	}
-
-	//_ = t.natsConn.Publish("TaskDeletionFailed", []byte(projectID)) // uncomment this, and comment out the code above to test 'rollback' function
-
-	span.SetStatus(codes.Ok, "Successfully deleted all tasks")
-}
-
-func (t *TasksHandler) DeletedTasks(ctx context.Context, projectID string) {
-	_, span := t.tracer.Start(context.Background(), "TaskHandler.HandleProjectDeleted")
-	defer span.End()
-
-	//	err := t.repo.DeleteAllTasksByProjectId(projectID)
-	//=======
-	err := t.repo.DeleteAllTasksByProjectId(ctx, projectID)
-	//>>>>>>> b38a495c0faab5935c6effddd29991a392a36c70
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		t.logger.Printf("Failed to delete tasks for project %s: %v", projectID, err)
-
-		_ = t.natsConn.Publish("TaskDeletionFailed", []byte(projectID))
-	}
-	t.logger.Printf("Successfully deleted all tasks for project %s", projectID)
+	t.pauseProjectTaskTemplates(ctx, projectID)
 
 	err = t.natsConn.Publish("TasksDeleted", []byte(projectID))
 	if err != nil {
@@ -689,11 +760,13 @@ func (t *TasksHandler) LogTaskMemberChange(rw http.ResponseWriter, h *http.Reque
 		subject := "task.joined"
 
 		message := struct {
-			UserID   string `json:"userId"`
-			TaskName string `json:"taskName"`
+			UserID     string   `json:"userId"`
+			TaskName   string   `json:"taskName"`
+			WatcherIDs []string `json:"watcherIds"`
 		}{
-			UserID:   userID,
-			TaskName: task.Name,
+			UserID:     userID,
+			TaskName:   task.Name,
+			WatcherIDs: t.watcherIDs(ctx, task),
 		}
 
 		jsonMessage, err := json.Marshal(message)
@@ -745,11 +818,13 @@ func (t *TasksHandler) LogTaskMemberChange(rw http.ResponseWriter, h *http.Reque
 		subject := "task.removed"
 
 		message := struct {
-			UserID   string `json:"userId"`
-			TaskName string `json:"taskName"`
+			UserID     string   `json:"userId"`
+			TaskName   string   `json:"taskName"`
+			WatcherIDs []string `json:"watcherIds"`
 		}{
-			UserID:   userID,
-			TaskName: task.Name,
+			UserID:     userID,
+			TaskName:   task.Name,
+			WatcherIDs: t.watcherIDs(ctx, task),
 		}
 
 		jsonMessage, err := json.Marshal(message)
@@ -924,6 +999,75 @@ func (t *TasksHandler) isUserInProject(ctx context.Context, projectID, userID st
 	}
 }
 
+// isProjectManager asks project-service whether the caller (identified by the auth cookie) manages the project
+func (t *TasksHandler) isProjectManager(ctx context.Context, projectID string, cookie *http.Cookie) bool {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.isProjectManager")
+	defer span.End()
+
+	linkToProjectService := os.Getenv("LINK_TO_PROJECT_SERVICE")
+	projectServiceURL := fmt.Sprintf("%s/projects/%s/manager", linkToProjectService, projectID)
+
+	req, err := http.NewRequest(http.MethodGet, projectServiceURL, nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error creating request:", err)
+		return false
+	}
+	req.AddCookie(cookie)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	clientToDo, err := createTLSClient()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error creating TLS client:", err)
+		return false
+	}
+
+	resp, err := clientToDo.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error making GET request:", err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.logger.Println("Unexpected status code:", resp.StatusCode)
+		return false
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false
+	}
+	span.SetStatus(codes.Ok, "Successfully checked project manager")
+	return strings.TrimSpace(string(body)) == "true"
+}
+
+// canAccessProject reports whether the user from the request context is a member or the manager of the project
+func (t *TasksHandler) canAccessProject(ctx context.Context, h *http.Request, projectID string) bool {
+	userID, _ := h.Context().Value(KeyId{}).(string)
+	role, _ := h.Context().Value(KeyRole{}).(string)
+	if userID == "" {
+		return false
+	}
+	if t.isUserInProject(ctx, projectID, userID) {
+		return true
+	}
+	if role != "manager" {
+		return false
+	}
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		return false
+	}
+	return t.isProjectManager(ctx, projectID, cookie)
+}
+
 func (th *TasksHandler) HandleStatusUpdate(rw http.ResponseWriter, req *http.Request) {
 	ctx, span := th.tracer.Start(req.Context(), "TaskHandler.HandleStatusUpdate")
 	defer span.End()
@@ -1052,6 +1196,7 @@ func (th *TasksHandler) HandleStatusUpdate(rw http.ResponseWriter, req *http.Req
 			"projectId": task.ProjectID,
 			"status":    task.Status,
 			"changedBy": id,
+			"labels":    task.Labels,
 		},
 		"projectId": task.ProjectID,
 	}
@@ -1070,10 +1215,25 @@ func (th *TasksHandler) HandleStatusUpdate(rw http.ResponseWriter, req *http.Req
 func (p *TasksHandler) sendEventToAnalyticsService(ctx context.Context, event interface{}) error {
 	ctx, span := p.tracer.Start(ctx, "TaskHandler.SendEventToAnalyticsService")
 	defer span.End()
+	return p.postToAnalyticsService(ctx, span, "/event/append", event)
+}
+
+// sendEventsToAnalyticsService stores several events with a single request
+func (p *TasksHandler) sendEventsToAnalyticsService(ctx context.Context, events []map[string]interface{}) error {
+	ctx, span := p.tracer.Start(ctx, "TaskHandler.SendEventsToAnalyticsService")
+	defer span.End()
+	if len(events) == 0 {
+		span.SetStatus(codes.Ok, "No events to send")
+		return nil
+	}
+	return p.postToAnalyticsService(ctx, span, "/event/append/batch", events)
+}
+
+func (p *TasksHandler) postToAnalyticsService(ctx context.Context, span trace.Span, path string, payload interface{}) error {
 	linkToUserServer := os.Getenv("LINK_TO_ANALYTIC_SERVICE")
-	analyticsServiceURL := fmt.Sprintf("%s/event/append", linkToUserServer)
+	analyticsServiceURL := linkToUserServer + path
 
-	eventData, err := json.Marshal(event)
+	eventData, err := json.Marshal(payload)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -1133,10 +1293,12 @@ func (t *TasksHandler) publishStatusUpdate(ctx context.Context, task *model.Task
 		TaskName   string   `json:"taskName"`
 		TaskStatus string   `json:"taskStatus"`
 		MemberIds  []string `json:"memberIds"`
+		WatcherIds []string `json:"watcherIds"`
 	}{
 		TaskName:   task.Name,
 		TaskStatus: string(task.Status),
 		MemberIds:  task.UserIDs,
+		WatcherIds: t.watcherIDs(ctx, task),
 	}
 
 	jsonMessage, err := json.Marshal(message)
@@ -1211,347 +1373,6 @@ func (th *TasksHandler) HandleCheckingIfUserIsInTask(rw http.ResponseWriter, r *
 	span.SetStatus(codes.Ok, "Successfully checked user")
 }
 
-func (h *TasksHandler) saveFileToHDFS(ctx context.Context, localFilePath, hdfsDirPath, hdfsFileName string) error {
-	ctx, span := h.tracer.Start(ctx, "TaskHandler.SaveFileToHDFS")
-	defer span.End()
-	hdfsAddress := "namenode:9000"
-
-	// Kreiranje HDFS klijenta
-	hdfsClient, err := hdfs.New(hdfsAddress)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return fmt.Errorf("failed to initialize HDFS client: %w", err)
-	}
-
-	// Kreiranje direktorijuma u HDFS (ako ne postoji)
-	err = hdfsClient.MkdirAll(hdfsDirPath, 0755)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return fmt.Errorf("failed to create directory in HDFS: %w", err)
-	}
-
-	// Putanja do fajla u HDFS
-	hdfsFilePath := filepath.Join(hdfsDirPath, hdfsFileName)
-
-	// Provera da li fajl postoji i brisanje ako postoji
-	_, err = hdfsClient.Stat(hdfsFilePath)
-	if err == nil {
-		log.Printf("File exists in HDFS. Deleting: %s\n", hdfsFilePath)
-		err = hdfsClient.Remove(hdfsFilePath)
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-			return fmt.Errorf("failed to delete existing file in HDFS: %w", err)
-		}
-	} else if !os.IsNotExist(err) {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return fmt.Errorf("failed to check if file exists in HDFS: %w", err)
-	}
-
-	// Otvaranje lokalnog fajla za čitanje
-	localFile, err := os.Open(localFilePath)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return fmt.Errorf("failed to open local file: %w", err)
-	}
-	defer localFile.Close()
-
-	// Kreiranje fajla u HDFS
-	hdfsFile, err := hdfsClient.Create(hdfsFilePath)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return fmt.Errorf("failed to create file in HDFS: %w", err)
-	}
-	defer hdfsFile.Close()
-
-	// Kopiranje sadržaja iz lokalnog fajla u HDFS fajl
-	_, err = io.Copy(hdfsFile, localFile)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return fmt.Errorf("failed to write to HDFS file: %w", err)
-	}
-
-	log.Printf("File successfully written to HDFS: %s\n", hdfsFilePath)
-	span.SetStatus(codes.Ok, "Successfully saved file to HDFS")
-	return nil
-}
-
-func (h *TasksHandler) UploadTaskDocument(w http.ResponseWriter, r *http.Request) {
-	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.UploadTaskDocument")
-	defer span.End()
-	// Parsiranje multipart forme
-	err := r.ParseMultipartForm(10 << 20) // 10 MB
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		http.Error(w, "Unable to parse form", http.StatusBadRequest)
-		return
-	}
-	h.logger.Println("DEBUG::parsed form")
-
-	// Preuzimanje fajla
-	file, header, err := r.FormFile("file")
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		http.Error(w, "Unable to get file from form", http.StatusBadRequest)
-		return
-	}
-	defer file.Close()
-	h.logger.Println("DEBUG::Got file from form")
-
-	// Preuzimanje taskId iz forme
-	taskId := r.FormValue("taskId")
-	if taskId == "" {
-		span.RecordError(errors.New("missing taskId"))
-		span.SetStatus(codes.Error, "missing taskId")
-		http.Error(w, "Missing taskId in form data", http.StatusBadRequest)
-		return
-	}
-	h.logger.Println("DEBUG::TaskId is found")
-
-	// Kreiranje privremenog fajla na lokalnom disku
-	tempDir := os.TempDir()
-	tempFilePath := filepath.Join(tempDir, uuid.New().String()+"_"+header.Filename)
-	tempFile, err := os.Create(tempFilePath)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		http.Error(w, "Unable to create temporary file", http.StatusInternalServerError)
-		return
-	}
-	defer tempFile.Close()
-
-	h.logger.Println("DEBUG::Created temp file")
-
-	// Kopiranje sadržaja iz primljenog fajla u privremeni fajl
-	_, err = io.Copy(tempFile, file)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		http.Error(w, "Unable to save file", http.StatusInternalServerError)
-		return
-	}
-	h.logger.Println("DEBUG::Saved temp file")
-
-	// Sačuvajte fajl u HDFS koristeći izdvojenu funkciju
-	hdfsDirPath := "/example/"
-	hdfsFileName := uuid.New().String() + "_" + header.Filename //header.Filename					//da ime bude bez uuid
-	err = h.saveFileToHDFS(ctx, tempFilePath, hdfsDirPath, hdfsFileName)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		http.Error(w, fmt.Sprintf("Failed to save file to HDFS: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	h.logger.Println("DEBUG::File saved to HDFS")
-
-	// Kreiranje TaskDocument objekta
-	taskDocument := model.TaskDocument{
-		ID:         primitive.NewObjectID(),
-		TaskID:     taskId,
-		FileName:   header.Filename,
-		FileType:   header.Header.Get("Content-Type"),
-		FilePath:   filepath.Join(hdfsDirPath, hdfsFileName),
-		UploadedAt: primitive.NewDateTimeFromTime(time.Now()),
-	}
-
-	err = h.documentRepo.SaveTaskDocument(ctx, &taskDocument)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		http.Error(w, "Unable to save task document to database", http.StatusInternalServerError)
-		return
-	}
-
-	id, ok := r.Context().Value(KeyId{}).(string)
-	if !ok || id == "" {
-		span.RecordError(errors.New("User ID is missing or invalid"))
-		span.SetStatus(codes.Error, "User ID is missing or invalid")
-		http.Error(w, "User ID is missing or invalid", http.StatusUnauthorized)
-		h.logger.Println("Error retrieving user ID from context")
-		errMsg := "User ID is missing or invalid"
-		h.logger.Println(errMsg)
-		h.custLogger.Error(nil, errMsg)
-		http.Error(w, errMsg, http.StatusUnauthorized)
-		return
-	}
-
-	task, err := h.repo.GetByID(ctx, taskId)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		h.logger.Print("Database exception:", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	currentTime := time.Now().Add(1 * time.Hour)
-	formattedTime := currentTime.Format(time.RFC3339)
-
-	event := map[string]interface{}{
-		"type": "DocumentAdded",
-		"time": formattedTime,
-		"event": map[string]interface{}{
-			"taskId":     taskDocument.TaskID,
-			"projectId":  task.ProjectID,
-			"documentId": taskDocument.ID,
-			"addedBy":    id,
-		},
-		"projectId": task.ProjectID,
-	}
-
-	if err := h.sendEventToAnalyticsService(ctx, event); err != nil {
-		http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
-		return
-	}
-
-	h.logger.Println("DEBUG::Saved task document to database")
-
-	span.SetStatus(codes.Ok, "Successfully saved task document to HDFS")
-	// Uspešan odgovor
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte("File uploaded successfully"))
-}
-
-func (h *TasksHandler) GetTaskDocumentsByTaskID(w http.ResponseWriter, r *http.Request) {
-	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.GetTaskDocumentsByTaskID")
-	defer span.End()
-	// Preuzimanje taskId iz query parametra
-	vars := mux.Vars(r)
-	taskID := vars["taskId"]
-
-	h.logger.Printf("Fetching documents for taskId: %s", taskID)
-
-	// Pozivanje metode iz repozitorijuma da se dobiju task dokumenti
-	documents, err := h.documentRepo.GetTaskDocumentsByTaskID(ctx, taskID)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		h.logger.Printf("Error fetching documents for taskId %s: %v", taskID, err)
-		http.Error(w, "Unable to fetch task documents", http.StatusInternalServerError)
-		return
-	}
-
-	// Vraćanje rezultata kao JSON
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(documents)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		h.logger.Printf("Error encoding documents for taskId %s: %v", taskID, err)
-		http.Error(w, "Unable to encode task documents", http.StatusInternalServerError)
-		return
-	}
-	span.SetStatus(codes.Ok, "Successfully fetched documents for taskId")
-	h.logger.Printf("Successfully fetched %d documents for taskId %s", len(documents), taskID)
-}
-
-func (h *TasksHandler) getFileFromHDFS(ctx context.Context, hdfsFilePath string) ([]byte, error) {
-	ctx, span := h.tracer.Start(ctx, "TaskHandler.GetFileFromHDFS")
-	defer span.End()
-	hdfsAddress := "namenode:9000"
-
-	// Kreiranje HDFS klijenta
-	hdfsClient, err := hdfs.New(hdfsAddress)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return nil, fmt.Errorf("failed to initialize HDFS client: %w", err)
-	}
-
-	// Otvaranje fajla iz HDFS-a
-	hdfsFile, err := hdfsClient.Open(hdfsFilePath)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return nil, fmt.Errorf("failed to open HDFS file: %w", err)
-	}
-	defer hdfsFile.Close()
-
-	// Čitanje sadržaja fajla
-	fileContent, err := io.ReadAll(hdfsFile)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return nil, fmt.Errorf("failed to read HDFS file: %w", err)
-	}
-	span.SetStatus(codes.Ok, "Successfully read HDFS file")
-	return fileContent, nil
-}
-
-func (h *TasksHandler) DownloadTaskDocument(w http.ResponseWriter, r *http.Request) {
-	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.DownloadTaskDocument")
-	defer span.End()
-	vars := mux.Vars(r)
-	taskDocumentId, ok := vars["taskDocumentId"]
-	if !ok || taskDocumentId == "" {
-		span.RecordError(errors.New("missing taskDocumentId"))
-		span.SetStatus(codes.Error, "missing taskDocumentId")
-		http.Error(w, "Missing taskDocumentId in path parameters", http.StatusBadRequest)
-		return
-	}
-
-	docID, err := primitive.ObjectIDFromHex(taskDocumentId)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		http.Error(w, "Invalid taskDocumentId format", http.StatusBadRequest)
-		return
-	}
-
-	// Preuzimanje TaskDocument iz repozitorijuma
-	taskDocument, err := h.documentRepo.GetTaskDocumentByID(ctx, docID)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		http.Error(w, fmt.Sprintf("Failed to fetch task document: %v", err), http.StatusInternalServerError)
-		return
-	}
-	if taskDocument == nil {
-		span.RecordError(errors.New("task document not found"))
-		span.SetStatus(codes.Error, "task document not found")
-		http.Error(w, "Task document not found", http.StatusNotFound)
-		return
-	}
-
-	hdfsFilePath := taskDocument.FilePath
-
-	// Preuzimanje fajla iz HDFS-a
-	fileContent, err := h.getFileFromHDFS(ctx, hdfsFilePath)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		http.Error(w, fmt.Sprintf("Failed to get file from HDFS: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	// Postavljanje zaglavlja HTTP odgovora
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", hdfsFilePath))
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(fileContent)))
-
-	// Slanje sadržaja fajla kao HTTP odgovor
-	_, err = w.Write(fileContent)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		http.Error(w, "Failed to send file content", http.StatusInternalServerError)
-		return
-	}
-	span.SetStatus(codes.Ok, "Successfully sent file content")
-	h.logger.Println("----------------------------------------------------------------------")
-	h.logger.Println("DEBUG::File sent successfully:", hdfsFilePath)
-	h.logger.Println("----------------------------------------------------------------------")
-
-}
 func (th *TasksHandler) BlockTask(rw http.ResponseWriter, r *http.Request) {
 	ctx, span := th.tracer.Start(r.Context(), "TaskHandler.BlockTask")
 	defer span.End()
@@ -1706,6 +1527,7 @@ func (t *TasksHandler) RollbackTasks(ctx context.Context, projectID string) {
 		_ = t.natsConn.Publish("WorkflowsDeletionFailed", []byte(projectID))
 	}
 	t.logger.Printf("Successfully deleted all tasks for project %s", projectID)
+	t.resumeProjectTaskTemplates(ctx, projectID)
 
 	err = t.natsConn.Publish("TasksDeleted", []byte(projectID))
 	if err != nil {
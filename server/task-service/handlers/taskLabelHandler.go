@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func (t *TasksHandler) AddLabelToTask(rw http.ResponseWriter, h *http.Request) {
+	t.changeTaskLabel(rw, h, true)
+}
+
+func (t *TasksHandler) RemoveLabelFromTask(rw http.ResponseWriter, h *http.Request) {
+	t.changeTaskLabel(rw, h, false)
+}
+
+func (t *TasksHandler) changeTaskLabel(rw http.ResponseWriter, h *http.Request, attach bool) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.changeTaskLabel")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	vars := mux.Vars(h)
+	taskID := vars["taskId"]
+	label := strings.TrimSpace(vars["label"])
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	task, err := t.repo.GetByID(ctx, taskID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !t.canAccessProject(ctx, h, task.ProjectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	eventType := "TaskLabelAdded"
+	if attach {
+		labels, err := t.resolveProjectLabels(ctx, h, task.ProjectID, []string{label})
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		label = labels[0]
+		err = t.repo.AddLabel(ctx, task, label)
+	} else {
+		eventType = "TaskLabelRemoved"
+		if !contains(task.Labels, label) {
+			http.Error(rw, "Task does not have that label", http.StatusNotFound)
+			return
+		}
+		err = t.repo.RemoveLabel(ctx, task, label)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to update task labels", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"taskID": taskID, "label": label, "attach": attach}, "Task labels updated")
+
+	event := map[string]interface{}{
+		"type": eventType,
+		"time": time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+		"event": map[string]interface{}{
+			"taskId":    task.ID,
+			"projectId": task.ProjectID,
+			"label":     label,
+			"changedBy": userID,
+		},
+		"projectId": task.ProjectID,
+	}
+	if err := t.sendEventToAnalyticsService(ctx, event); err != nil {
+		http.Error(rw, "Failed to send event to analytics service", http.StatusInternalServerError)
+		return
+	}
+
+	if err := task.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully updated task labels")
+}
+
+// HandleLabelRenamed propagates a label rename from the project registry to the project's tasks
+func (t *TasksHandler) HandleLabelRenamed(ctx context.Context, data []byte) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleLabelRenamed")
+	defer span.End()
+
+	var message struct {
+		ProjectID string `json:"projectId"`
+		OldName   string `json:"oldName"`
+		NewName   string `json:"newName"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error unmarshalling project.label.renamed message:", err)
+		return
+	}
+
+	count, err := t.repo.RenameLabelInProject(ctx, message.ProjectID, message.OldName, message.NewName)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Failed to rename label %s in project %s: %v", message.OldName, message.ProjectID, err)
+		return
+	}
+	t.logger.Printf("Renamed label %s to %s on %d tasks of project %s", message.OldName, message.NewName, count, message.ProjectID)
+	span.SetStatus(codes.Ok, "Successfully renamed label")
+}
+
+// HandleLabelDeleted detaches a deleted registry label from the project's tasks
+func (t *TasksHandler) HandleLabelDeleted(ctx context.Context, data []byte) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleLabelDeleted")
+	defer span.End()
+
+	var message struct {
+		ProjectID string `json:"projectId"`
+		Name      string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error unmarshalling project.label.deleted message:", err)
+		return
+	}
+
+	count, err := t.repo.RemoveLabelFromProject(ctx, message.ProjectID, message.Name)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Failed to remove label %s in project %s: %v", message.Name, message.ProjectID, err)
+		return
+	}
+	t.logger.Printf("Removed label %s from %d tasks of project %s", message.Name, count, message.ProjectID)
+	span.SetStatus(codes.Ok, "Successfully removed label")
+}
+
+// resolveProjectLabels checks the given names against the project's label registry and
+// returns them spelled the way the registry has them
+func (t *TasksHandler) resolveProjectLabels(ctx context.Context, h *http.Request, projectID string, names []string) ([]string, error) {
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		return nil, errors.New("no token found in cookie")
+	}
+
+	registry, err := t.projectClient.GetLabels(ctx, projectID, cookie)
+	if err != nil {
+		t.logger.Println("Error fetching project labels:", err)
+		return nil, errors.New("unable to fetch project labels")
+	}
+
+	resolved := []string{}
+	for _, name := range names {
+		found := false
+		for _, label := range registry {
+			if strings.EqualFold(label.Name, strings.TrimSpace(name)) {
+				if !contains(resolved, label.Name) {
+					resolved = append(resolved, label.Name)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("label %q is not defined in the project", name)
+		}
+	}
+	return resolved, nil
+}
+
+func parseLabelsQuery(query string) []string {
+	var labels []string
+	for _, label := range strings.Split(query, ",") {
+		label = strings.TrimSpace(label)
+		if label != "" && !contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"task--service/model"
+	"task--service/repositories"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// taskSortFields maps the sort names of the API to the stored task fields
+var taskSortFields = map[string]string{
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+	"name":      "name",
+	"status":    "status",
+}
+
+// SearchTasks pages through tasks matching the query parameters:
+// projectId, status (comma separated), assignee ("me" for the caller), blocked, createdFrom,
+// createdTo, updatedFrom, updatedTo (RFC 3339 or YYYY-MM-DD), q (name/description), labels,
+// field.<fieldId>, sort (createdAt, updatedAt, name, status or field.<fieldId>, "-" for
+// descending), limit, cursor and count=true for the total number of matches.
+func (t *TasksHandler) SearchTasks(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.SearchTasks")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	params := h.URL.Query()
+	projectID := params.Get("projectId")
+	if projectID != "" && !t.canAccessProject(ctx, h, projectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	query, err := t.parseTaskQuery(ctx, h, projectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query.ProjectID = projectID
+	if query.Assignee == "me" {
+		query.Assignee = userID
+	}
+	// without a project the search is limited to the caller's own tasks
+	if projectID == "" {
+		if query.Assignee != "" && query.Assignee != userID {
+			http.Error(rw, "projectId is required to search tasks of other users", http.StatusBadRequest)
+			return
+		}
+		query.Assignee = userID
+	}
+
+	limit := int64(defaultSearchLimit)
+	if value := params.Get("limit"); value != "" {
+		limit, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			http.Error(rw, fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit), http.StatusBadRequest)
+			return
+		}
+	}
+	withTotal := params.Get("count") == "true"
+
+	page, err := t.repo.SearchTasks(ctx, query, params.Get("cursor"), limit, withTotal)
+	if errors.Is(err, repositories.ErrInvalidCursor) {
+		http.Error(rw, "Invalid cursor for this sort order", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Database exception while searching tasks:", err)
+		http.Error(rw, "Failed to search tasks", http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(rw).Encode(page); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully searched tasks")
+}
+
+// parseTaskQuery builds a task query out of the request's query parameters. Custom field
+// filters and sorting are resolved against the definitions of the given project.
+func (t *TasksHandler) parseTaskQuery(ctx context.Context, h *http.Request, projectID string) (repositories.TaskQuery, error) {
+	params := h.URL.Query()
+	query := repositories.TaskQuery{
+		Labels:       parseLabelsQuery(params.Get("labels")),
+		CustomFields: map[string]interface{}{},
+		Assignee:     strings.TrimSpace(params.Get("assignee")),
+		Text:         strings.TrimSpace(params.Get("q")),
+	}
+
+	for _, status := range parseLabelsQuery(params.Get("status")) {
+		switch model.TaskStatus(status) {
+		case model.Pending, model.InProgress, model.Completed:
+			query.Statuses = append(query.Statuses, model.TaskStatus(status))
+		default:
+			return query, fmt.Errorf("unknown status %q", status)
+		}
+	}
+
+	if value := params.Get("blocked"); value != "" {
+		blocked, err := strconv.ParseBool(value)
+		if err != nil {
+			return query, fmt.Errorf("blocked must be true or false")
+		}
+		query.Blocked = &blocked
+	}
+
+	var err error
+	ranges := []struct {
+		name   string
+		target **time.Time
+		upper  bool
+	}{
+		{"createdFrom", &query.CreatedFrom, false},
+		{"createdTo", &query.CreatedTo, true},
+		{"updatedFrom", &query.UpdatedFrom, false},
+		{"updatedTo", &query.UpdatedTo, true},
+	}
+	for _, r := range ranges {
+		value := params.Get(r.name)
+		if value == "" {
+			continue
+		}
+		var date time.Time
+		if date, err = parseCustomFieldDate(value); err != nil {
+			return query, fmt.Errorf("%s must be a date like 2024-05-31", r.name)
+		}
+		// a plain date as the upper bound includes that whole day
+		if r.upper && len(strings.TrimSpace(value)) == len("2006-01-02") {
+			date = date.AddDate(0, 0, 1)
+		}
+		*r.target = &date
+	}
+
+	sort := params.Get("sort")
+	query.SortDesc = strings.HasPrefix(sort, "-")
+	sortName := strings.TrimPrefix(sort, "-")
+	if field, ok := taskSortFields[sortName]; ok {
+		query.SortBy = field
+	} else if sortName != "" && !strings.HasPrefix(sortName, customFieldQueryPrefix) {
+		return query, fmt.Errorf("cannot sort by %s", sort)
+	}
+
+	hasFieldParams := strings.HasPrefix(sortName, customFieldQueryPrefix)
+	for key := range params {
+		if strings.HasPrefix(key, customFieldQueryPrefix) {
+			hasFieldParams = true
+		}
+	}
+	if !hasFieldParams {
+		return query, nil
+	}
+	if projectID == "" {
+		return query, fmt.Errorf("custom fields can only be used within a project")
+	}
+
+	definitions, err := t.getCustomFieldDefinitions(ctx, h, projectID)
+	if err != nil {
+		return query, err
+	}
+
+	for key, values := range params {
+		fieldID, ok := strings.CutPrefix(key, customFieldQueryPrefix)
+		if !ok {
+			continue
+		}
+		definition := findCustomFieldDefinition(definitions, fieldID)
+		if definition == nil {
+			return query, fmt.Errorf("custom field %s is not defined in the project", fieldID)
+		}
+		condition, err := customFieldCondition(definition, values[0])
+		if err != nil {
+			return query, err
+		}
+		query.CustomFields[fieldID] = condition
+	}
+
+	if fieldID, ok := strings.CutPrefix(sortName, customFieldQueryPrefix); ok {
+		if findCustomFieldDefinition(definitions, fieldID) == nil {
+			return query, fmt.Errorf("cannot sort by %s", sort)
+		}
+		query.SortBy = "custom_fields." + fieldID
+	}
+	return query, nil
+}
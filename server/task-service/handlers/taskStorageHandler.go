@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"task--service/model"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// defaultProjectStorageQuota is how many bytes of documents a project may store unless
+// PROJECT_STORAGE_QUOTA_MB says otherwise
+const defaultProjectStorageQuota = 10 << 30
+
+// storageThresholds are the percentages of the quota at which the manager of a project is
+// told about its usage, once for each crossing
+var storageThresholds = []int{80, 100}
+
+var errStorageQuotaExceeded = errors.New("project storage quota exceeded")
+
+func projectStorageQuota() int64 {
+	quota, err := strconv.ParseInt(os.Getenv("PROJECT_STORAGE_QUOTA_MB"), 10, 64)
+	if err != nil || quota <= 0 {
+		return defaultProjectStorageQuota
+	}
+	return quota << 20
+}
+
+// describeUsage fills in the quota of a usage and how much of it is used
+func describeUsage(usage *model.StorageUsage) *model.StorageUsage {
+	usage.QuotaBytes = projectStorageQuota()
+	usage.UsedPercent = float64(usage.Bytes) * 100 / float64(usage.QuotaBytes)
+	if usage.Users == nil {
+		usage.Users = map[string]int64{}
+	}
+	return usage
+}
+
+// checkStorageQuota tells whether size more bytes fit in the quota of a project, without
+// reserving them, and returns how many bytes are left
+func (h *TasksHandler) checkStorageQuota(ctx context.Context, projectID string, size int64) (int64, error) {
+	usage, err := h.documentRepo.GetStorageUsage(ctx, projectID)
+	if err != nil {
+		return 0, err
+	}
+	describeUsage(usage)
+	if usage.Bytes+size > usage.QuotaBytes {
+		return 0, quotaExceeded(usage)
+	}
+	return usage.QuotaBytes - usage.Bytes, nil
+}
+
+func quotaExceeded(usage *model.StorageUsage) error {
+	return fmt.Errorf("%w: %d of %d MB are used", errStorageQuotaExceeded, usage.Bytes>>20, usage.QuotaBytes>>20)
+}
+
+// reserveStorage counts a stored file against the quota of a project before it is saved as a
+// document
+func (h *TasksHandler) reserveStorage(ctx context.Context, projectID string, file *storedFile, userID string) error {
+	quota := projectStorageQuota()
+	usage, err := h.documentRepo.ReserveStorage(ctx, projectID, userID, file.size, quota)
+	if err != nil {
+		return err
+	}
+	if usage == nil {
+		usage, err = h.documentRepo.GetStorageUsage(ctx, projectID)
+		if err != nil {
+			return fmt.Errorf("%w: the limit is %d MB", errStorageQuotaExceeded, quota>>20)
+		}
+		return quotaExceeded(describeUsage(usage))
+	}
+	h.storageUsageChanged(ctx, describeUsage(usage))
+	return nil
+}
+
+// releaseStorage takes bytes that are no longer stored off the usage of a project
+func (h *TasksHandler) releaseStorage(ctx context.Context, projectID string, userID string, size int64) {
+	usage, err := h.documentRepo.ReleaseStorage(ctx, projectID, userID, size)
+	if err != nil {
+		h.logger.Printf("Error releasing %d bytes of project %s: %v", size, projectID, err)
+		return
+	}
+	h.storageUsageChanged(ctx, describeUsage(usage))
+}
+
+// releaseDocumentStorage takes the files of a removed document off the usage of its project,
+// each file from the user who uploaded it first
+func (h *TasksHandler) releaseDocumentStorage(ctx context.Context, projectID string, taskDocument *model.TaskDocument) {
+	released := map[string]bool{}
+	for _, version := range taskDocument.AllVersions() {
+		if version.FilePath == "" || released[version.FilePath] {
+			continue
+		}
+		released[version.FilePath] = true
+		h.releaseStorage(ctx, projectID, version.UploadedBy, version.Size)
+	}
+}
+
+// BackfillStorageUsage counts the files of documents uploaded before the storage quota into
+// the usage of their projects. A project is counted once and its usage marked as backfilled;
+// one whose usage changes while it is counted is counted again on the next start. Documents
+// whose task is gone are left to the reconciliation.
+func (h *TasksHandler) BackfillStorageUsage(ctx context.Context) {
+	ctx, span := h.tracer.Start(ctx, "TaskHandler.BackfillStorageUsage")
+	defer span.End()
+
+	// the usage is read before the documents, so an upload in between changes the usage and
+	// keeps a count that may miss its file from replacing it
+	usages, err := h.documentRepo.GetAllStorageUsage(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Println("Error backfilling storage usage:", err)
+		return
+	}
+	documents, err := h.documentRepo.AllDocuments(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Println("Error backfilling storage usage:", err)
+		return
+	}
+	taskIDs := []string{}
+	for _, taskDocument := range documents {
+		taskIDs = append(taskIDs, taskDocument.TaskID)
+	}
+	projects, err := h.repo.ProjectIDs(ctx, taskIDs)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Println("Error backfilling storage usage:", err)
+		return
+	}
+
+	counted := map[string]*model.StorageUsage{}
+	for projectID, usage := range usages {
+		if !usage.Backfilled {
+			counted[projectID] = &model.StorageUsage{ProjectID: projectID, Users: map[string]int64{}}
+		}
+	}
+	for i := range documents {
+		projectID, ok := projects[documents[i].TaskID]
+		if !ok || usages[projectID].Backfilled {
+			continue
+		}
+		usage := counted[projectID]
+		if usage == nil {
+			usage = &model.StorageUsage{ProjectID: projectID, Users: map[string]int64{}}
+			counted[projectID] = usage
+		}
+		// like releaseDocumentStorage, each file counts once for the user who uploaded it first
+		seen := map[string]bool{}
+		for _, version := range documents[i].AllVersions() {
+			if version.FilePath == "" || seen[version.FilePath] {
+				continue
+			}
+			seen[version.FilePath] = true
+			usage.Bytes += version.Size
+			if version.UploadedBy != "" {
+				usage.Users[version.UploadedBy] += version.Size
+			}
+		}
+	}
+
+	backfilled, postponed := 0, 0
+	for projectID, usage := range counted {
+		var previous *model.StorageUsage
+		if existing, ok := usages[projectID]; ok {
+			previous = &existing
+			usage.NotifiedPercent = existing.NotifiedPercent
+		}
+		replaced, err := h.documentRepo.SetBackfilledStorageUsage(ctx, usage, previous)
+		if err != nil {
+			span.RecordError(err)
+			h.logger.Printf("Error backfilling storage usage of project %s: %v", projectID, err)
+		}
+		if err != nil || !replaced {
+			postponed++
+			continue
+		}
+		backfilled++
+		h.storageUsageChanged(ctx, describeUsage(usage))
+	}
+
+	h.custLogger.Info(logrus.Fields{
+		"projects":  backfilled,
+		"postponed": postponed,
+	}, "Storage usage backfilled")
+	span.SetStatus(codes.Ok, "Successfully backfilled storage usage")
+}
+
+// storageUsageChanged tells the manager of a project when its usage crossed one of the
+// storageThresholds upwards. Falling below a threshold lets it be reported again.
+func (h *TasksHandler) storageUsageChanged(ctx context.Context, usage *model.StorageUsage) {
+	reached := 0
+	for _, threshold := range storageThresholds {
+		if usage.UsedPercent >= float64(threshold) {
+			reached = threshold
+		}
+	}
+	if reached == usage.NotifiedPercent {
+		return
+	}
+	moved, err := h.documentRepo.SetNotifiedPercent(ctx, usage.ProjectID, usage.NotifiedPercent, reached)
+	if err != nil {
+		h.logger.Printf("Error recording storage threshold of project %s: %v", usage.ProjectID, err)
+		return
+	}
+	if !moved || reached < usage.NotifiedPercent {
+		return
+	}
+
+	project, err := h.projectClient.GetCalendarProject(ctx, usage.ProjectID)
+	if err != nil {
+		h.logger.Printf("Error fetching project %s: %v", usage.ProjectID, err)
+		return
+	}
+	h.publishEvent(ctx, "task.storage.threshold", map[string]interface{}{
+		"projectId":   usage.ProjectID,
+		"projectName": project.Name,
+		"managerId":   project.Manager,
+		"percent":     reached,
+		"bytes":       usage.Bytes,
+		"quotaBytes":  usage.QuotaBytes,
+	})
+	h.custLogger.Warn(logrus.Fields{
+		"projectID":  usage.ProjectID,
+		"percent":    reached,
+		"bytes":      usage.Bytes,
+		"quotaBytes": usage.QuotaBytes,
+	}, "Project storage threshold reached")
+}
+
+// GetProjectStorage reports how much of its storage quota a project uses, in total and by
+// user, to the members of the project
+func (h *TasksHandler) GetProjectStorage(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.GetProjectStorage")
+	defer span.End()
+
+	projectID := mux.Vars(r)["projectId"]
+	if !h.canAccessProject(ctx, r, projectID) {
+		http.Error(w, "You are not a member of this project", http.StatusForbidden)
+		return
+	}
+	usage, err := h.documentRepo.GetStorageUsage(ctx, projectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, "Failed to get storage usage", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetStatus(codes.Ok, "Successfully got storage usage")
+	w.Header().Set("Content-Type", "application/json")
+	if err := describeUsage(usage).ToJSON(w); err != nil {
+		span.RecordError(err)
+		h.logger.Println("Error encoding storage usage:", err)
+	}
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"task--service/client"
+	"task--service/model"
+	"task--service/repositories"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	recurringTaskInterval  = time.Minute
+	recurringTaskBatchSize = 50
+)
+
+type taskTemplateStatusRequest struct {
+	Status model.TaskTemplateStatus `json:"status"`
+}
+
+func (t *TasksHandler) PostTaskTemplate(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.PostTaskTemplate")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	template := &model.TaskTemplate{}
+	if err := template.FromJSON(h.Body); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" || template.ProjectID == "" {
+		http.Error(rw, "name and projectId are required", http.StatusBadRequest)
+		return
+	}
+
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+		return
+	}
+	if !t.isProjectManager(ctx, template.ProjectID, cookie) {
+		span.SetStatus(codes.Error, "User is not the project manager")
+		http.Error(rw, "Only the project manager can schedule recurring tasks", http.StatusForbidden)
+		return
+	}
+
+	rule, err := model.ParseRRule(template.RRule)
+	if err != nil {
+		http.Error(rw, "Invalid rrule: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	template.Recurrence = rule
+	template.RRule = rule.String()
+
+	if len(template.Labels) > 0 {
+		labels, err := t.resolveProjectLabels(ctx, h, template.ProjectID, template.Labels)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		template.Labels = labels
+	}
+	customFields, err := t.validateCustomFields(ctx, h, template.ProjectID, template.CustomFields, true)
+	if err != nil {
+		writeCustomFieldError(rw, err)
+		return
+	}
+	template.CustomFields = customFields
+	if template.UserIDs == nil {
+		template.UserIDs = []string{}
+	}
+	if template.Labels == nil {
+		template.Labels = []string{}
+	}
+
+	// occurrences are matched by their exact time, so it is kept at a precision Mongo preserves
+	if template.StartsAt.IsZero() {
+		template.StartsAt = time.Now()
+	}
+	template.StartsAt = template.StartsAt.UTC().Truncate(time.Second)
+	next, ok := rule.Next(template.StartsAt, template.StartsAt.Add(-time.Second))
+	if !ok {
+		http.Error(rw, "The recurrence rule has no occurrences", http.StatusBadRequest)
+		return
+	}
+	template.NextRunAt = &next
+	template.Status = model.TemplateActive
+	template.PausedWithProject = false
+	template.CreatedBy = userID
+	template.GeneratedCount = 0
+	template.LastRunAt = nil
+
+	if err := t.templateRepo.SaveTaskTemplate(ctx, template); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to save recurring task", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"templateID": template.ID.Hex(), "rrule": template.RRule}, "Recurring task scheduled")
+
+	rw.WriteHeader(http.StatusCreated)
+	if err := template.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully scheduled recurring task")
+}
+
+func (t *TasksHandler) GetTaskTemplatesByProjectID(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetTaskTemplatesByProjectID")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	projectID := mux.Vars(h)["projectId"]
+	if !t.canAccessProject(ctx, h, projectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	templates, err := t.templateRepo.GetTaskTemplatesByProjectID(ctx, projectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to fetch recurring tasks", http.StatusInternalServerError)
+		return
+	}
+	if err := templates.ToJSON(rw); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to encode recurring tasks", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched recurring tasks")
+}
+
+// UpdateTaskTemplateStatus pauses, resumes or ends a recurring series. Resuming continues with
+// the first occurrence after now, occurrences missed while paused are not created.
+func (t *TasksHandler) UpdateTaskTemplateStatus(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.UpdateTaskTemplateStatus")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	templateID, err := primitive.ObjectIDFromHex(mux.Vars(h)["templateId"])
+	if err != nil {
+		http.Error(rw, "Invalid templateId format", http.StatusBadRequest)
+		return
+	}
+
+	var request taskTemplateStatusRequest
+	if err := json.NewDecoder(h.Body).Decode(&request); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	template, err := t.templateRepo.GetTaskTemplateByID(ctx, templateID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to fetch recurring task", http.StatusInternalServerError)
+		return
+	}
+	if template == nil {
+		http.Error(rw, "Recurring task not found", http.StatusNotFound)
+		return
+	}
+
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+		return
+	}
+	if !t.isProjectManager(ctx, template.ProjectID, cookie) {
+		span.SetStatus(codes.Error, "User is not the project manager")
+		http.Error(rw, "Only the project manager can change recurring tasks", http.StatusForbidden)
+		return
+	}
+	if template.Status == model.TemplateEnded {
+		http.Error(rw, "The series has already ended", http.StatusConflict)
+		return
+	}
+
+	var next *time.Time
+	switch request.Status {
+	case model.TemplatePaused:
+		next = template.NextRunAt
+	case model.TemplateActive:
+		occurrence, ok := resumedOccurrence(template)
+		if !ok {
+			http.Error(rw, "The series has no further occurrences", http.StatusConflict)
+			return
+		}
+		next = &occurrence
+	case model.TemplateEnded:
+		next = nil
+	default:
+		http.Error(rw, "status must be active, paused or ended", http.StatusBadRequest)
+		return
+	}
+
+	if err := t.templateRepo.UpdateTaskTemplateStatus(ctx, template, request.Status, next); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to update recurring task", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"templateID": template.ID.Hex(), "status": request.Status}, "Recurring task status changed")
+
+	if err := template.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully updated recurring task")
+}
+
+// resumedOccurrence is the first occurrence of a paused series after now
+func resumedOccurrence(template *model.TaskTemplate) (time.Time, bool) {
+	occurrence, ok := template.Recurrence.Next(template.StartsAt, time.Now())
+	if !ok || (template.Recurrence.Count > 0 && template.GeneratedCount >= template.Recurrence.Count) {
+		return time.Time{}, false
+	}
+	return occurrence, true
+}
+
+// pauseProjectTaskTemplates stops the series of a project while it is deleted
+func (t *TasksHandler) pauseProjectTaskTemplates(ctx context.Context, projectID string) {
+	paused, err := t.templateRepo.PauseProjectTaskTemplates(ctx, projectID)
+	if err != nil {
+		t.logger.Printf("Error pausing recurring tasks of project %s: %v", projectID, err)
+		return
+	}
+	if paused > 0 {
+		t.custLogger.Info(logrus.Fields{"projectID": projectID, "count": paused}, "Recurring tasks paused with project")
+	}
+}
+
+// resumeProjectTaskTemplates resumes the series that were paused with their project, like a
+// manager resuming them by hand, and ends the ones that have no occurrence left
+func (t *TasksHandler) resumeProjectTaskTemplates(ctx context.Context, projectID string) {
+	templates, err := t.templateRepo.GetTaskTemplatesPausedWithProject(ctx, projectID)
+	if err != nil {
+		t.logger.Printf("Error fetching recurring tasks of project %s: %v", projectID, err)
+		return
+	}
+	for _, template := range templates {
+		status, next := model.TemplateEnded, (*time.Time)(nil)
+		if occurrence, ok := resumedOccurrence(template); ok {
+			status, next = model.TemplateActive, &occurrence
+		}
+		if err := t.templateRepo.UpdateTaskTemplateStatus(ctx, template, status, next); err != nil {
+			t.logger.Printf("Error resuming recurring task %s: %v", template.ID.Hex(), err)
+			continue
+		}
+		t.custLogger.Info(logrus.Fields{"templateID": template.ID.Hex(), "status": status}, "Recurring task resumed with project")
+	}
+}
+
+// endProjectTaskTemplates ends the series of a project that was purged
+func (t *TasksHandler) endProjectTaskTemplates(ctx context.Context, projectID string) error {
+	ended, err := t.templateRepo.EndProjectTaskTemplates(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if ended > 0 {
+		t.custLogger.Info(logrus.Fields{"projectID": projectID, "count": ended}, "Recurring tasks ended with project")
+	}
+	return nil
+}
+
+// RunRecurringTaskScheduler creates the tasks of due recurring series until ctx is done
+func (t *TasksHandler) RunRecurringTaskScheduler(ctx context.Context) {
+	ticker := time.NewTicker(recurringTaskInterval)
+	defer ticker.Stop()
+
+	for {
+		t.materializeDueTaskTemplates(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *TasksHandler) materializeDueTaskTemplates(ctx context.Context) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.materializeDueTaskTemplates")
+	defer span.End()
+
+	templates, err := t.templateRepo.GetDueTaskTemplates(ctx, time.Now(), recurringTaskBatchSize)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error fetching due recurring tasks:", err)
+		return
+	}
+	// a series whose project is gone or being deleted is paused with it, the project is looked
+	// up once per run, and a series is left for the next run when project-service cannot answer
+	live := map[string]bool{}
+	for _, template := range templates {
+		isLive, checked := live[template.ProjectID]
+		if !checked {
+			_, err := t.projectClient.GetCalendarProject(ctx, template.ProjectID)
+			switch {
+			case err == nil:
+				isLive = true
+			case errors.Is(err, client.ErrProjectNotFound):
+				t.pauseProjectTaskTemplates(ctx, template.ProjectID)
+			default:
+				span.RecordError(err)
+				t.logger.Printf("Error checking project %s of recurring tasks: %v", template.ProjectID, err)
+			}
+			live[template.ProjectID] = isLive
+		}
+		if !isLive {
+			continue
+		}
+		if err := t.materializeTaskTemplate(ctx, template); err != nil {
+			span.RecordError(err)
+			t.logger.Printf("Error creating occurrence of recurring task %s: %v", template.ID.Hex(), err)
+		}
+	}
+	span.SetStatus(codes.Ok, "Successfully processed due recurring tasks")
+}
+
+// materializeTaskTemplate creates the task for the template's next occurrence and moves the
+// template on. Every occurrence has a unique key, so a restart or another replica that handles
+// the same occurrence finds the task already created and only advances the template.
+func (t *TasksHandler) materializeTaskTemplate(ctx context.Context, template *model.TaskTemplate) error {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.materializeTaskTemplate")
+	defer span.End()
+
+	runAt := *template.NextRunAt
+	task := &model.Task{
+		ProjectID:     template.ProjectID,
+		Name:          template.Name,
+		Description:   template.Description,
+		UserIDs:       append([]string{}, template.UserIDs...),
+		Dependencies:  []string{},
+		Labels:        append([]string{}, template.Labels...),
+		CustomFields:  map[string]interface{}{},
+		TemplateID:    template.ID.Hex(),
+		RecurrenceKey: fmt.Sprintf("%s:%d", template.ID.Hex(), runAt.Unix()),
+	}
+	for fieldID, value := range template.CustomFields {
+		task.CustomFields[fieldID] = value
+	}
+
+	err := t.createTask(ctx, task)
+	switch {
+	case err == nil:
+		t.custLogger.Info(logrus.Fields{"templateID": template.ID.Hex(), "taskID": task.ID.Hex()}, "Recurring task created")
+	case errors.Is(err, repositories.ErrOccurrenceExists):
+		t.logger.Printf("Occurrence %s already created, advancing series", task.RecurrenceKey)
+	case errors.Is(err, errTaskCreatedEvent):
+		// the task exists, retrying would not resend the event, so the series moves on
+		t.logger.Printf("Recurring task %s created without analytics event: %v", task.ID.Hex(), err)
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	// occurrences missed while the service was down are skipped rather than created in a burst
+	var next *time.Time
+	after := time.Now()
+	if runAt.After(after) {
+		after = runAt
+	}
+	if template.Recurrence.Count == 0 || template.GeneratedCount+1 < template.Recurrence.Count {
+		if occurrence, ok := template.Recurrence.Next(template.StartsAt, after); ok {
+			next = &occurrence
+		}
+	}
+
+	if _, err := t.templateRepo.AdvanceTaskTemplate(ctx, template, runAt, next); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully created recurring task occurrence")
+	return nil
+}
@@ -0,0 +1,457 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"task--service/model"
+	"task--service/storage"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Restoring a deleted task mirrors its deletion: the task is taken out of the trash here, then
+// workflow-service recreates the task node with its DEPENDS_ON edges and answers with either
+// TaskWorkflowRestored, after which the task, its documents and dependencies are restored, or
+// TaskWorkflowRestoreFailed, after which the task goes back to the trash.
+const (
+	taskRestoreStartedSubject        = "TaskRestoreStarted"
+	taskWorkflowRestoredSubject      = "TaskWorkflowRestored"
+	taskWorkflowRestoreFailedSubject = "TaskWorkflowRestoreFailed"
+
+	trashPurgeInterval    = time.Hour
+	trashPurgeBatchSize   = 100
+	defaultTrashRetention = 30 * 24 * time.Hour
+)
+
+// trashedTask is a task in the trash together with the moment it is purged
+type trashedTask struct {
+	model.Task
+	RestorableUntil time.Time `json:"restorableUntil"`
+}
+
+// workflowTaskNode is the task node workflow-service recreates on restore
+type workflowTaskNode struct {
+	ID           string           `json:"id"`
+	ProjectID    string           `json:"projectId"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	Status       model.TaskStatus `json:"status"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+	Dependencies []string         `json:"dependencies"`
+	UserIDs      []string         `json:"user_ids"`
+	Blocked      bool             `json:"blocked"`
+}
+
+type taskRestoreMessage struct {
+	TaskID       string           `json:"taskId"`
+	ProjectID    string           `json:"projectId"`
+	Task         workflowTaskNode `json:"task"`
+	DependencyOf []string         `json:"dependencyOf"`
+}
+
+// trashRetention is how long a task deleted on its own stays restorable
+func trashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		return defaultTrashRetention
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetProjectTaskTrash lists the deleted tasks of a project that can still be restored
+func (t *TasksHandler) GetProjectTaskTrash(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetProjectTaskTrash")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+		return
+	}
+	projectID := mux.Vars(h)["projectId"]
+	if !t.isProjectManager(ctx, projectID, cookie) {
+		span.SetStatus(codes.Error, "User is not the project manager")
+		http.Error(rw, "Only the project manager can view deleted tasks", http.StatusForbidden)
+		return
+	}
+
+	tasks, err := t.repo.FindTrash(ctx, projectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error fetching deleted tasks:", err)
+		http.Error(rw, "Failed to fetch deleted tasks", http.StatusInternalServerError)
+		return
+	}
+	retention := trashRetention()
+	trash := make([]trashedTask, 0, len(tasks))
+	for _, task := range tasks {
+		if task.DeletedAt == nil {
+			continue
+		}
+		trash = append(trash, trashedTask{Task: task, RestorableUntil: task.DeletedAt.Add(retention)})
+	}
+
+	if err := json.NewEncoder(rw).Encode(trash); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched deleted tasks")
+}
+
+// RestoreTask lets the project manager bring a deleted task back while it is in the trash. The
+// restore finishes asynchronously, so the request is answered with 202 Accepted.
+func (t *TasksHandler) RestoreTask(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.RestoreTask")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+	cookie, err := h.Cookie("auth_token")
+	if err != nil {
+		http.Error(rw, "No token found in cookie", http.StatusUnauthorized)
+		return
+	}
+
+	task, err := t.repo.GetByID(ctx, mux.Vars(h)["taskId"])
+	if err != nil || task.DeletedAt == nil {
+		http.Error(rw, "Deleted task not found", http.StatusNotFound)
+		return
+	}
+	if !t.isProjectManager(ctx, task.ProjectID, cookie) {
+		span.SetStatus(codes.Error, "User is not the project manager")
+		http.Error(rw, "Only the project manager can restore tasks", http.StatusForbidden)
+		return
+	}
+	if !task.Trashed {
+		http.Error(rw, "Task deletion or restore is still in progress", http.StatusConflict)
+		return
+	}
+	if time.Since(*task.DeletedAt) > trashRetention() {
+		http.Error(rw, "Task can no longer be restored", http.StatusGone)
+		return
+	}
+
+	taken, err := t.repo.SetTrashed(ctx, task.ID, false)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error restoring task:", err)
+		http.Error(rw, "Failed to restore task", http.StatusInternalServerError)
+		return
+	}
+	if !taken {
+		http.Error(rw, "Task deletion or restore is still in progress", http.StatusConflict)
+		return
+	}
+	if err := t.publishTaskRestore(task); err != nil {
+		if _, revertErr := t.repo.SetTrashed(ctx, task.ID, true); revertErr != nil {
+			t.logger.Printf("Error moving task %s back to trash: %v", task.ID.Hex(), revertErr)
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error restoring task:", err)
+		http.Error(rw, "Failed to restore task", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"taskID": task.ID.Hex(), "restoredBy": userID}, "Task restore started")
+
+	rw.WriteHeader(http.StatusAccepted)
+	span.SetStatus(codes.Ok, "Successfully started task restore")
+}
+
+// publishTaskRestore asks workflow-service to recreate the node of a trashed task
+func (t *TasksHandler) publishTaskRestore(task *model.Task) error {
+	message, err := json.Marshal(taskRestoreMessage{
+		TaskID:    task.ID.Hex(),
+		ProjectID: task.ProjectID,
+		Task: workflowTaskNode{
+			ID:           task.ID.Hex(),
+			ProjectID:    task.ProjectID,
+			Name:         task.Name,
+			Description:  task.Description,
+			Status:       task.Status,
+			CreatedAt:    task.CreatedAt,
+			UpdatedAt:    task.UpdatedAt,
+			Dependencies: task.Dependencies,
+			UserIDs:      task.UserIDs,
+			Blocked:      task.Blocked,
+		},
+		DependencyOf: task.DependencyOf,
+	})
+	if err == nil {
+		err = t.natsConn.Publish(taskRestoreStartedSubject, message)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to publish %s: %w", taskRestoreStartedSubject, err)
+	}
+	return nil
+}
+
+// HandleTaskWorkflowRestored finishes a task restore once the workflow node is back: the task and
+// its documents become visible again, it is linked to the tasks that depended on it and the
+// blocked flags of the affected tasks are derived anew.
+func (t *TasksHandler) HandleTaskWorkflowRestored(ctx context.Context, data []byte) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleTaskWorkflowRestored")
+	defer span.End()
+
+	var message taskDeletionMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Error unmarshalling %s message: %v", taskWorkflowRestoredSubject, err)
+		return
+	}
+	task, err := t.repo.GetByID(ctx, message.TaskID)
+	if err != nil || !task.PendingDeletion || task.Trashed {
+		span.SetStatus(codes.Error, "Task is not being restored")
+		t.logger.Printf("Ignoring %s for task %s that is not being restored", taskWorkflowRestoredSubject, message.TaskID)
+		return
+	}
+
+	if err := t.repo.UnmarkDeleted(ctx, task.ID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error restoring task:", err)
+		return
+	}
+	if _, err := t.documentRepo.SetDeletedByTaskID(ctx, message.TaskID, false); err != nil {
+		span.RecordError(err)
+		t.logger.Printf("Error restoring documents of task %s: %v", message.TaskID, err)
+	}
+	if err := t.repo.RestoreDependencyReferences(ctx, message.TaskID, task.DependencyOf); err != nil {
+		span.RecordError(err)
+		t.logger.Println("Error restoring task dependencies:", err)
+	}
+	if _, err := t.repo.RecomputeBlocked(ctx, append(task.Dependencies, message.TaskID)); err != nil {
+		span.RecordError(err)
+		t.logger.Println("Error recomputing blocked flags:", err)
+	}
+	t.reindexTask(ctx, task)
+
+	t.custLogger.Info(logrus.Fields{"taskID": message.TaskID}, "Task restored")
+	span.SetStatus(codes.Ok, "Successfully restored task")
+}
+
+// HandleTaskWorkflowRestoreFailed moves a task back to the trash when workflow-service could not
+// recreate its node
+func (t *TasksHandler) HandleTaskWorkflowRestoreFailed(ctx context.Context, data []byte) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleTaskWorkflowRestoreFailed")
+	defer span.End()
+
+	var message taskDeletionMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Error unmarshalling %s message: %v", taskWorkflowRestoreFailedSubject, err)
+		return
+	}
+	task, err := t.repo.GetByID(ctx, message.TaskID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Cannot roll back restore of task %s: %v", message.TaskID, err)
+		return
+	}
+	if _, err := t.repo.SetTrashed(ctx, task.ID, true); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Error moving task %s back to trash: %v", message.TaskID, err)
+		return
+	}
+	t.custLogger.Warn(logrus.Fields{"taskID": message.TaskID}, "Task restore rolled back")
+	span.SetStatus(codes.Ok, "Successfully rolled back task restore")
+}
+
+// HandleProjectRestored brings back the tasks of a restored project, the tasks that were deleted
+// on their own before the project stay in the trash
+func (t *TasksHandler) HandleProjectRestored(ctx context.Context, projectID string) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleProjectRestored")
+	defer span.End()
+
+	if err := t.repo.UpdateAllTasksByProjectId(ctx, projectID, false); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Failed to restore tasks for project %s: %v", projectID, err)
+		return
+	}
+	tasks, err := t.repo.GetAllByProjectId(ctx, projectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Failed to fetch restored tasks for project %s: %v", projectID, err)
+		return
+	}
+	for i := range tasks {
+		if !tasks[i].PendingDeletion {
+			t.reindexTask(ctx, &tasks[i])
+		}
+	}
+	t.resumeProjectTaskTemplates(ctx, projectID)
+	if err := t.natsConn.Publish("TasksRestored", []byte(projectID)); err != nil {
+		span.RecordError(err)
+		t.logger.Printf("Failed to publish TasksRestored event for project %s: %v", projectID, err)
+	}
+	t.logger.Printf("Successfully restored all tasks for project %s", projectID)
+	span.SetStatus(codes.Ok, "Successfully restored all tasks")
+}
+
+// HandleProjectPurged ends the recurring tasks and removes every task of a project that left the
+// trash for good
+func (t *TasksHandler) HandleProjectPurged(ctx context.Context, projectID string) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleProjectPurged")
+	defer span.End()
+
+	if err := t.endProjectTaskTemplates(ctx, projectID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Failed to end recurring tasks for project %s: %v", projectID, err)
+		return
+	}
+	tasks, err := t.repo.GetAllByProjectId(ctx, projectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Failed to fetch tasks for project %s: %v", projectID, err)
+		return
+	}
+	failed := 0
+	for i := range tasks {
+		if err := t.purgeTask(ctx, &tasks[i]); err != nil {
+			span.RecordError(err)
+			t.logger.Printf("Failed to purge task %s: %v", tasks[i].ID.Hex(), err)
+			failed++
+		}
+	}
+	// project-service removes the project only after this answer and asks again otherwise
+	if failed > 0 {
+		span.SetStatus(codes.Error, "Failed to purge some tasks")
+		t.logger.Printf("Failed to purge %d tasks for project %s", failed, projectID)
+		return
+	}
+	if err := t.natsConn.Publish("TasksPurged", []byte(projectID)); err != nil {
+		span.RecordError(err)
+		t.logger.Printf("Failed to publish TasksPurged event for project %s: %v", projectID, err)
+	}
+	t.logger.Printf("Successfully purged all tasks for project %s", projectID)
+	span.SetStatus(codes.Ok, "Successfully purged all tasks")
+}
+
+// RunTrashPurge removes the tasks that stayed in the trash past the retention window until ctx
+// is done
+func (t *TasksHandler) RunTrashPurge(ctx context.Context) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		t.purgeExpiredTrash(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *TasksHandler) purgeExpiredTrash(ctx context.Context) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.purgeExpiredTrash")
+	defer span.End()
+
+	tasks, err := t.repo.FindTrashedBefore(ctx, time.Now().Add(-trashRetention()), trashPurgeBatchSize)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error fetching expired deleted tasks:", err)
+		return
+	}
+	for i := range tasks {
+		if err := t.purgeTask(ctx, &tasks[i]); err != nil {
+			span.RecordError(err)
+			t.logger.Printf("Error purging task %s: %v", tasks[i].ID.Hex(), err)
+			continue
+		}
+		t.custLogger.Info(logrus.Fields{"taskID": tasks[i].ID.Hex()}, "Deleted task purged")
+	}
+	span.SetStatus(codes.Ok, "Successfully purged expired deleted tasks")
+}
+
+// purgeTask removes a task for good together with its stored files, documents, comments and
+// work logs. The task itself goes last so that a failed purge is retried.
+func (t *TasksHandler) purgeTask(ctx context.Context, task *model.Task) error {
+	taskID := task.ID.Hex()
+	documents, err := t.documentRepo.GetAllByTaskID(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch documents: %w", err)
+	}
+	for _, document := range documents {
+		for _, filePath := range document.FilePaths() {
+			if err := t.documentStore.Delete(ctx, filePath); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				t.logger.Printf("Error removing file of document %s: %v", document.ID.Hex(), err)
+			}
+		}
+	}
+	if err := t.documentRepo.DeleteByTaskID(ctx, taskID); err != nil {
+		return err
+	}
+	for i := range documents {
+		t.releaseDocumentStorage(ctx, task.ProjectID, &documents[i])
+	}
+	if err := t.commentRepo.DeleteByTaskID(ctx, taskID); err != nil {
+		return err
+	}
+	if err := t.workLogRepo.DeleteByTaskID(ctx, taskID); err != nil {
+		return err
+	}
+	return t.repo.DeleteTask(ctx, task.ID)
+}
+
+// reindexTask publishes a restored task with its comments and documents again so that the
+// services that dropped them on deletion pick them up
+func (t *TasksHandler) reindexTask(ctx context.Context, task *model.Task) {
+	taskID := task.ID.Hex()
+	t.publishEvent(ctx, "task.created", map[string]string{
+		"taskId":      taskID,
+		"projectId":   task.ProjectID,
+		"name":        task.Name,
+		"description": task.Description,
+	})
+
+	comments, err := t.commentRepo.GetTaskCommentsByTaskID(ctx, taskID)
+	if err != nil {
+		t.logger.Printf("Error fetching comments of task %s: %v", taskID, err)
+	}
+	for _, comment := range comments {
+		t.publishCommentSaved(ctx, comment, nil)
+	}
+
+	documents, err := t.documentRepo.GetTaskDocumentsByTaskID(ctx, taskID)
+	if err != nil {
+		t.logger.Printf("Error fetching documents of task %s: %v", taskID, err)
+	}
+	for _, document := range documents {
+		t.publishEvent(ctx, "task.document.uploaded", map[string]string{
+			"documentId": document.ID.Hex(),
+			"taskId":     taskID,
+			"projectId":  task.ProjectID,
+			"fileName":   document.FileName,
+			"fileType":   document.FileType,
+			"text":       document.Text,
+		})
+	}
+}
@@ -0,0 +1,585 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"task--service/model"
+	"task--service/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Resumable uploads follow the tus protocol (https://tus.io/protocols/resumable-upload): a
+// client creates an upload session with the length of the file, sends the file in PATCH
+// requests that each start at the offset the server reports, asks for the offset after a
+// broken connection and continues from there. Once every byte arrived the upload is finalized
+// into a task document. Sessions that are abandoned expire and their chunks are removed.
+const (
+	tusVersion = "1.0.0"
+	// uploadDir is the directory of the document store that holds the chunks of unfinished
+	// uploads, one subdirectory per session
+	uploadDir = "/uploads/"
+
+	// maxUploadChunks keeps the list of chunks of a session small, the chunk that takes the
+	// last place has to complete the upload
+	maxUploadChunks = 1000
+
+	defaultUploadSessionTTL = 24 * time.Hour
+	uploadCleanupInterval   = time.Hour
+	uploadCleanupBatchSize  = 100
+)
+
+// uploadSessionTTL is how long an upload session lives after its last chunk, configured in
+// hours with UPLOAD_SESSION_TTL_HOURS
+func uploadSessionTTL() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("UPLOAD_SESSION_TTL_HOURS"))
+	if err != nil || hours <= 0 {
+		return defaultUploadSessionTTL
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// partialReader ends the stream without an error when the client connection breaks, so that
+// the bytes that did arrive are kept and the client can resume after them
+type partialReader struct {
+	r   io.Reader
+	err error
+}
+
+func (p *partialReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if err != nil && err != io.EOF && !errors.Is(err, errDocumentTooLarge) {
+		p.err = err
+		return n, io.EOF
+	}
+	return n, err
+}
+
+// chunkReader reads the chunks of an upload one after the other, opening each one only when
+// it is reached
+type chunkReader struct {
+	ctx     context.Context
+	store   storage.DocumentStore
+	chunks  []model.UploadChunk
+	current io.ReadCloser
+}
+
+func (c *chunkReader) Read(b []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if len(c.chunks) == 0 {
+				return 0, io.EOF
+			}
+			file, err := c.store.Get(c.ctx, c.chunks[0].Path)
+			if err != nil {
+				return 0, fmt.Errorf("failed to open chunk %s: %w", c.chunks[0].Path, err)
+			}
+			c.current = file
+			c.chunks = c.chunks[1:]
+		}
+		n, err := c.current.Read(b)
+		if err == io.EOF {
+			c.current.Close()
+			c.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *chunkReader) Close() error {
+	if c.current != nil {
+		return c.current.Close()
+	}
+	return nil
+}
+
+// parseUploadMetadata reads the Upload-Metadata header, a comma separated list of keys with
+// base64 encoded values
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %q: %v", key, err)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+func uploadLocation(session *model.UploadSession) string {
+	return "/tasks/uploads/" + session.ID.Hex()
+}
+
+func writeUploadHeaders(w http.ResponseWriter, session *model.UploadSession) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(session.Length, 10))
+	w.Header().Set("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// CreateUploadSession starts a resumable upload of a document for a task. The file length is
+// given in Upload-Length, its name and type in the filename and filetype keys of
+// Upload-Metadata, and the document it is a new version of in the optional documentId key.
+// The session is found at the returned Location.
+func (h *TasksHandler) CreateUploadSession(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.CreateUploadSession")
+	defer span.End()
+
+	userID, ok := r.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("User ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(w, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, "Upload-Length must be the size of the file in bytes", http.StatusBadRequest)
+		return
+	}
+	if limit := maxDocumentSize(); length > limit {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(limit, 10))
+		http.Error(w, fmt.Sprintf("Document is larger than %d MB", limit>>20), http.StatusRequestEntityTooLarge)
+		return
+	}
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid Upload-Metadata: %v", err), http.StatusBadRequest)
+		return
+	}
+	fileName := path.Base(metadata["filename"])
+	if metadata["filename"] == "" || fileName == "/" || fileName == "." || fileName == ".." {
+		http.Error(w, "Upload-Metadata must contain the filename", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.repo.GetByID(ctx, mux.Vars(r)["taskId"])
+	if err != nil || task == nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !h.canAccessProject(ctx, r, task.ProjectID) {
+		http.Error(w, "You are not a member of this project", http.StatusForbidden)
+		return
+	}
+	// the type is only known once the file arrived, the size is checked right away
+	rules, err := h.documentRules(ctx, task.ProjectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, "Failed to check the document policy", http.StatusInternalServerError)
+		return
+	}
+	if length > rules.maxSize {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(rules.maxSize, 10))
+		http.Error(w, fmt.Sprintf("Document is larger than %d MB", rules.maxSize>>20), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if _, err := h.checkStorageQuota(ctx, task.ProjectID, length); err != nil {
+		span.RecordError(err)
+		writeDocumentPolicyError(w, err)
+		return
+	}
+	if documentID := metadata["documentId"]; documentID != "" {
+		taskDocument, err := h.getTaskDocument(ctx, task.ID.Hex(), documentID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			http.Error(w, "Failed to fetch task document", http.StatusInternalServerError)
+			return
+		}
+		if taskDocument == nil {
+			http.Error(w, "Task document not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	session := &model.UploadSession{
+		TaskID:     task.ID.Hex(),
+		UserID:     userID,
+		DocumentID: metadata["documentId"],
+		FileName:   fileName,
+		FileType:   metadata["filetype"],
+		Length:     length,
+		ExpiresAt:  time.Now().Add(uploadSessionTTL()),
+	}
+	if err := h.uploadSessionRepo.Insert(ctx, session); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, "Failed to create upload session", http.StatusInternalServerError)
+		return
+	}
+	h.custLogger.Info(logrus.Fields{
+		"uploadID": session.ID.Hex(),
+		"taskID":   session.TaskID,
+		"length":   session.Length,
+	}, "Upload session created")
+
+	span.SetStatus(codes.Ok, "Successfully created upload session")
+	writeUploadHeaders(w, session)
+	w.Header().Set("Location", uploadLocation(session))
+	w.WriteHeader(http.StatusCreated)
+	if err := session.ToJSON(w); err != nil {
+		h.logger.Println("Error encoding upload session:", err)
+	}
+}
+
+// getUploadSession loads the session of the request for the user who created it. Sessions of
+// other users are reported as missing.
+func (h *TasksHandler) getUploadSession(ctx context.Context, w http.ResponseWriter, r *http.Request) *model.UploadSession {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	userID, _ := r.Context().Value(KeyId{}).(string)
+	session, err := h.uploadSessionRepo.GetByID(ctx, mux.Vars(r)["uploadId"])
+	if err != nil {
+		http.Error(w, "Failed to get upload session", http.StatusInternalServerError)
+		return nil
+	}
+	if session == nil || session.UserID != userID || session.ExpiresAt.Before(time.Now()) {
+		http.Error(w, "Upload session not found", http.StatusNotFound)
+		return nil
+	}
+	return session
+}
+
+// GetUploadOffset reports how much of an upload arrived in Upload-Offset. HEAD requests get
+// only the headers, GET requests also get the session.
+func (h *TasksHandler) GetUploadOffset(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.GetUploadOffset")
+	defer span.End()
+
+	session := h.getUploadSession(ctx, w, r)
+	if session == nil {
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully got upload offset")
+	writeUploadHeaders(w, session)
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err := session.ToJSON(w); err != nil {
+		h.logger.Println("Error encoding upload session:", err)
+	}
+}
+
+// PatchUploadChunk stores the body as the part of the file that starts at Upload-Offset,
+// which must be the current offset of the session. When the connection breaks, the bytes
+// that arrived are kept. A session holds at most maxUploadChunks chunks.
+func (h *TasksHandler) PatchUploadChunk(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.PatchUploadChunk")
+	defer span.End()
+
+	session := h.getUploadSession(ctx, w, r)
+	if session == nil {
+		return
+	}
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "Content-Type must be application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		http.Error(w, "Upload-Offset must be a number of bytes", http.StatusBadRequest)
+		return
+	}
+	if offset != session.Offset || session.FinalizingUntil != nil {
+		writeUploadHeaders(w, session)
+		http.Error(w, "Upload-Offset does not match the offset of the upload", http.StatusConflict)
+		return
+	}
+	// the offset only moves with a new chunk, so the count is still right when the chunk is
+	// appended at this offset
+	if len(session.Chunks) >= maxUploadChunks {
+		writeUploadHeaders(w, session)
+		http.Error(w, fmt.Sprintf("Upload has %d chunks already, start it again with larger chunks", maxUploadChunks), http.StatusRequestEntityTooLarge)
+		return
+	}
+	lastChunk := len(session.Chunks) == maxUploadChunks-1
+
+	controller := http.NewResponseController(w)
+	deadline := time.Now().Add(documentTransferTimeout)
+	if err := controller.SetReadDeadline(deadline); err != nil {
+		h.logger.Println("Error extending read deadline:", err)
+	}
+	if err := controller.SetWriteDeadline(deadline); err != nil {
+		h.logger.Println("Error extending write deadline:", err)
+	}
+
+	chunk := model.UploadChunk{
+		Path:   path.Join(uploadDir, session.ID.Hex(), fmt.Sprintf("%020d-%s", offset, uuid.New().String())),
+		Offset: offset,
+	}
+	limited := &sizeLimitReader{r: r.Body, limit: session.Length - offset}
+	body := &partialReader{r: limited}
+	chunk.Size, err = h.documentStore.Put(ctx, chunk.Path, body)
+	if limited.exceeded {
+		h.removeStoredFile(ctx, chunk.Path)
+		http.Error(w, "Chunk goes past Upload-Length", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, fmt.Sprintf("Failed to store chunk: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if body.err != nil {
+		h.logger.Printf("Upload %s was interrupted after %d bytes: %v", session.ID.Hex(), chunk.Size, body.err)
+	}
+	if lastChunk && offset+chunk.Size < session.Length {
+		h.removeStoredFile(ctx, chunk.Path)
+		writeUploadHeaders(w, session)
+		http.Error(w, fmt.Sprintf("Upload has %d chunks, the last one has to carry the rest of the file", maxUploadChunks-1), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if chunk.Size > 0 {
+		expiresAt := time.Now().Add(uploadSessionTTL())
+		appended, err := h.uploadSessionRepo.AppendChunk(ctx, session.ID, chunk, expiresAt)
+		if err != nil || !appended {
+			h.removeStoredFile(ctx, chunk.Path)
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			http.Error(w, "Failed to update upload session", http.StatusInternalServerError)
+			return
+		}
+		if !appended {
+			http.Error(w, "Another request changed the upload, ask for its offset", http.StatusConflict)
+			return
+		}
+		session.Offset += chunk.Size
+		session.ExpiresAt = expiresAt
+	} else {
+		h.removeStoredFile(ctx, chunk.Path)
+	}
+
+	span.SetStatus(codes.Ok, "Successfully stored chunk")
+	writeUploadHeaders(w, session)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FinalizeUpload joins the chunks of a complete upload into the file of a new task document
+func (h *TasksHandler) FinalizeUpload(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.FinalizeUpload")
+	defer span.End()
+
+	session := h.getUploadSession(ctx, w, r)
+	if session == nil {
+		return
+	}
+	if session.Offset != session.Length {
+		writeUploadHeaders(w, session)
+		http.Error(w, fmt.Sprintf("Upload is incomplete, %d of %d bytes arrived", session.Offset, session.Length), http.StatusConflict)
+		return
+	}
+	claimed, err := h.uploadSessionRepo.StartFinalizing(ctx, session.ID, time.Now().Add(documentTransferTimeout))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, "Failed to finalize upload", http.StatusInternalServerError)
+		return
+	}
+	if !claimed {
+		http.Error(w, "Upload is already being finalized", http.StatusConflict)
+		return
+	}
+	release := func() {
+		if err := h.uploadSessionRepo.StopFinalizing(ctx, session.ID, time.Now().Add(uploadSessionTTL())); err != nil {
+			h.logger.Println("Error releasing upload session:", err)
+		}
+	}
+
+	task, err := h.repo.GetByID(ctx, session.TaskID)
+	if err != nil || task == nil {
+		if err := h.removeUpload(ctx, session); err != nil {
+			h.logger.Printf("Error removing upload %s: %v", session.ID.Hex(), err)
+		}
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	// the user may have left the project since the session was created
+	if !h.canAccessProject(ctx, r, task.ProjectID) {
+		if err := h.removeUpload(ctx, session); err != nil {
+			h.logger.Printf("Error removing upload %s: %v", session.ID.Hex(), err)
+		}
+		span.SetStatus(codes.Error, "user is not a member of the project")
+		http.Error(w, "You are not a member of this project", http.StatusForbidden)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Now().Add(documentTransferTimeout)); err != nil {
+		h.logger.Println("Error extending write deadline:", err)
+	}
+
+	chunks := &chunkReader{ctx: ctx, store: h.documentStore, chunks: session.Chunks}
+	file, err := h.storeDocumentFile(ctx, chunks, session.FileName, session.Length, nil)
+	chunks.Close()
+	if err == nil && file.size != session.Length {
+		h.removeStoredFile(ctx, file.path)
+		err = fmt.Errorf("joined chunks have %d bytes instead of %d", file.size, session.Length)
+	}
+	if err != nil {
+		release()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, fmt.Sprintf("Failed to store file: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := h.checkDocumentPolicy(ctx, task.ProjectID, file); err != nil {
+		// the file will not be accepted however often it is finalized
+		h.removeStoredFile(ctx, file.path)
+		if removeErr := h.removeUpload(ctx, session); removeErr != nil {
+			h.logger.Printf("Error removing rejected upload %s: %v", session.ID.Hex(), removeErr)
+		}
+		writeDocumentPolicyError(w, err)
+		return
+	}
+	if err := h.reserveStorage(ctx, task.ProjectID, file, session.UserID); err != nil {
+		// the upload is kept, it can be finalized once the project has room for it
+		h.removeStoredFile(ctx, file.path)
+		release()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		writeDocumentPolicyError(w, err)
+		return
+	}
+
+	taskDocument, err := h.saveUploadedDocument(ctx, task, file, session.UserID, session.DocumentID)
+	if err != nil {
+		h.removeStoredFile(ctx, file.path)
+		h.releaseStorage(ctx, task.ProjectID, session.UserID, file.size)
+		release()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		writeSaveDocumentError(w, err)
+		return
+	}
+	if err := h.removeUpload(ctx, session); err != nil {
+		h.logger.Printf("Error removing finalized upload %s: %v", session.ID.Hex(), err)
+	}
+	if err := h.documentAdded(ctx, task, taskDocument, session.UserID); err != nil {
+		http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetStatus(codes.Ok, "Successfully finalized upload")
+	w.Header().Set("Location", "/tasks/download/"+taskDocument.ID.Hex())
+	w.WriteHeader(http.StatusCreated)
+	if err := taskDocument.ToJSON(w); err != nil {
+		span.RecordError(err)
+		h.logger.Println("Error encoding task document:", err)
+	}
+}
+
+// CancelUpload ends an upload that will not be finished and removes what arrived of it
+func (h *TasksHandler) CancelUpload(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TaskHandler.CancelUpload")
+	defer span.End()
+
+	session := h.getUploadSession(ctx, w, r)
+	if session == nil {
+		return
+	}
+	if session.FinalizingUntil != nil && session.FinalizingUntil.After(time.Now()) {
+		http.Error(w, "Upload is being finalized", http.StatusConflict)
+		return
+	}
+	if err := h.removeUpload(ctx, session); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, "Failed to remove upload", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully cancelled upload")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeUpload deletes a session and every chunk stored for it, including chunks whose
+// request failed before they were recorded. The session goes first so that it cannot be
+// finalized from chunks that are already gone.
+func (h *TasksHandler) removeUpload(ctx context.Context, session *model.UploadSession) error {
+	if err := h.uploadSessionRepo.Delete(ctx, session.ID); err != nil {
+		return err
+	}
+	files, err := h.documentStore.List(ctx, path.Join(uploadDir, session.ID.Hex()))
+	if err != nil {
+		return fmt.Errorf("failed to list chunks: %w", err)
+	}
+	for _, file := range files {
+		if err := h.documentStore.Delete(ctx, file.Path); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("failed to remove chunk %s: %w", file.Path, err)
+		}
+	}
+	return nil
+}
+
+// RunUploadCleanup removes the upload sessions that expired, with their chunks, until ctx is
+// done
+func (h *TasksHandler) RunUploadCleanup(ctx context.Context) {
+	ticker := time.NewTicker(uploadCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		h.removeExpiredUploads(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *TasksHandler) removeExpiredUploads(ctx context.Context) {
+	ctx, span := h.tracer.Start(ctx, "TaskHandler.removeExpiredUploads")
+	defer span.End()
+
+	sessions, err := h.uploadSessionRepo.FindExpired(ctx, time.Now(), uploadCleanupBatchSize)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Println("Error fetching expired upload sessions:", err)
+		return
+	}
+	for i := range sessions {
+		if err := h.removeUpload(ctx, &sessions[i]); err != nil {
+			span.RecordError(err)
+			h.logger.Printf("Error removing expired upload %s: %v", sessions[i].ID.Hex(), err)
+			continue
+		}
+		h.custLogger.Info(logrus.Fields{
+			"uploadID": sessions[i].ID.Hex(),
+			"taskID":   sessions[i].TaskID,
+			"offset":   sessions[i].Offset,
+			"length":   sessions[i].Length,
+		}, "Expired upload removed")
+	}
+	span.SetStatus(codes.Ok, "Successfully removed expired uploads")
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"task--service/model"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// WatchTask subscribes the caller to the changes of a task they are not assigned to
+func (t *TasksHandler) WatchTask(rw http.ResponseWriter, h *http.Request) {
+	t.changeTaskWatching(rw, h, true)
+}
+
+// UnwatchTask stops the notifications of a task, including the ones of a project watch
+func (t *TasksHandler) UnwatchTask(rw http.ResponseWriter, h *http.Request) {
+	t.changeTaskWatching(rw, h, false)
+}
+
+func (t *TasksHandler) changeTaskWatching(rw http.ResponseWriter, h *http.Request, watching bool) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.changeTaskWatching")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	taskID := mux.Vars(h)["taskId"]
+	task, err := t.repo.GetByID(ctx, taskID)
+	if err != nil || task.PendingDeletion {
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !t.canAccessProject(ctx, h, task.ProjectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	if err := t.repo.SetWatching(ctx, task, userID, watching); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Failed to update task watchers", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"taskID": taskID, "userID": userID, "watching": watching}, "Task watchers updated")
+
+	rw.WriteHeader(http.StatusNoContent)
+	span.SetStatus(codes.Ok, "Successfully updated task watchers")
+}
+
+// watcherIDs returns the users that get the notifications of a task on top of its members:
+// the watchers of the task and of its project, without the ones that unwatched the task
+func (t *TasksHandler) watcherIDs(ctx context.Context, task *model.Task) []string {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.watcherIDs")
+	defer span.End()
+
+	watchers := slices.Clone(task.Watchers)
+	projectWatchers, err := t.projectClient.GetWatchers(ctx, task.ProjectID)
+	if err != nil {
+		// the task watchers are still notified when project-service is unavailable
+		span.RecordError(err)
+		t.logger.Printf("Error fetching watchers of project %s: %v", task.ProjectID, err)
+	}
+	for _, userID := range projectWatchers {
+		if !slices.Contains(watchers, userID) {
+			watchers = append(watchers, userID)
+		}
+	}
+	watchers = slices.DeleteFunc(watchers, func(userID string) bool {
+		return slices.Contains(task.Unwatchers, userID)
+	})
+	span.SetStatus(codes.Ok, "Successfully resolved task watchers")
+	return watchers
+}
+
+// HandleMemberRemoved takes a user that was removed from a project off the watchers of its
+// tasks, so that they stop getting notifications of a project they cannot open anymore
+func (t *TasksHandler) HandleMemberRemoved(ctx context.Context, data []byte) {
+	ctx, span := t.tracer.Start(ctx, "TaskHandler.HandleMemberRemoved")
+	defer span.End()
+
+	var message struct {
+		UserID    string `json:"userId"`
+		ProjectID string `json:"projectId"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Println("Error unmarshalling project.removed message:", err)
+		return
+	}
+	if message.ProjectID == "" || message.UserID == "" {
+		span.SetStatus(codes.Error, "Member removal without project or user")
+		return
+	}
+
+	count, err := t.repo.RemoveWatcherFromProject(ctx, message.ProjectID, message.UserID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.Printf("Failed to remove watcher %s from tasks of project %s: %v", message.UserID, message.ProjectID, err)
+		return
+	}
+	t.logger.Printf("Removed watcher %s from %d tasks of project %s", message.UserID, count, message.ProjectID)
+	span.SetStatus(codes.Ok, "Successfully removed watcher")
+}
@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"task--service/model"
+	"task--service/repositories"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// maxWorkLogDuration caps a single entry, a longer one is almost certainly a typo or a forgotten timer
+const maxWorkLogDuration = 24 * time.Hour
+
+type workLogRequest struct {
+	DurationMinutes *int64     `json:"durationMinutes"`
+	StartedAt       *time.Time `json:"startedAt"`
+	Note            *string    `json:"note"`
+}
+
+type taskWorkLogsResponse struct {
+	WorkLogs model.WorkLogs      `json:"workLogs"`
+	Totals   model.WorkLogTotals `json:"totals"`
+}
+
+func (t *TasksHandler) StartTimer(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.StartTimer")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	task, err := t.repo.GetByID(ctx, mux.Vars(h)["taskId"])
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !t.canAccessProject(ctx, h, task.ProjectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	workLog := &model.WorkLog{
+		TaskID:    task.ID.Hex(),
+		ProjectID: task.ProjectID,
+		UserID:    userID,
+		StartedAt: time.Now(),
+		Running:   true,
+	}
+	if err := t.workLogRepo.SaveWorkLog(ctx, workLog); err != nil {
+		if errors.Is(err, repositories.ErrTimerAlreadyRunning) {
+			http.Error(rw, "Stop the running timer before starting a new one", http.StatusConflict)
+			return
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to start timer", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"taskID": workLog.TaskID, "userID": userID}, "Timer started")
+
+	rw.WriteHeader(http.StatusCreated)
+	if err := workLog.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully started timer")
+}
+
+func (t *TasksHandler) StopTimer(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.StopTimer")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	workLog, err := t.workLogRepo.GetRunningTimer(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to fetch running timer", http.StatusInternalServerError)
+		return
+	}
+	if workLog == nil {
+		http.Error(rw, "No timer is running", http.StatusNotFound)
+		return
+	}
+
+	if err := t.workLogRepo.StopTimer(ctx, workLog, time.Now()); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to stop timer", http.StatusConflict)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"taskID": workLog.TaskID, "userID": userID, "seconds": workLog.DurationSeconds}, "Timer stopped")
+
+	t.sendWorkLoggedEvent(ctx, workLog, workLog.StartedAt, workLog.DurationSeconds, "created")
+
+	if err := workLog.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully stopped timer")
+}
+
+func (t *TasksHandler) GetRunningTimer(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetRunningTimer")
+	defer span.End()
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	workLog, err := t.workLogRepo.GetRunningTimer(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to fetch running timer", http.StatusInternalServerError)
+		return
+	}
+	if workLog == nil {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err := workLog.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched running timer")
+}
+
+func (t *TasksHandler) PostWorkLog(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.PostWorkLog")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		span.RecordError(errors.New("user ID is missing or invalid"))
+		span.SetStatus(codes.Error, "User ID is missing or invalid")
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return
+	}
+
+	var request workLogRequest
+	if err := json.NewDecoder(h.Body).Decode(&request); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.DurationMinutes == nil {
+		http.Error(rw, "durationMinutes is required", http.StatusBadRequest)
+		return
+	}
+
+	task, err := t.repo.GetByID(ctx, mux.Vars(h)["taskId"])
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !t.canAccessProject(ctx, h, task.ProjectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	workLog := &model.WorkLog{
+		TaskID:    task.ID.Hex(),
+		ProjectID: task.ProjectID,
+		UserID:    userID,
+		StartedAt: time.Now(),
+		Manual:    true,
+	}
+	if err := applyWorkLogRequest(workLog, request); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	endedAt := workLog.StartedAt.Add(time.Duration(workLog.DurationSeconds) * time.Second)
+	workLog.EndedAt = &endedAt
+
+	if err := t.workLogRepo.SaveWorkLog(ctx, workLog); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to save work log", http.StatusInternalServerError)
+		return
+	}
+	t.custLogger.Info(logrus.Fields{"taskID": workLog.TaskID, "userID": userID, "seconds": workLog.DurationSeconds}, "Work logged")
+
+	t.sendWorkLoggedEvent(ctx, workLog, workLog.StartedAt, workLog.DurationSeconds, "created")
+
+	rw.WriteHeader(http.StatusCreated)
+	if err := workLog.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully saved work log")
+}
+
+func (t *TasksHandler) GetTaskWorkLogs(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetTaskWorkLogs")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	task, err := t.repo.GetByID(ctx, mux.Vars(h)["taskId"])
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !t.canAccessProject(ctx, h, task.ProjectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	workLogs, err := t.workLogRepo.GetWorkLogsByTaskID(ctx, task.ID.Hex())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to fetch work logs", http.StatusInternalServerError)
+		return
+	}
+
+	response := taskWorkLogsResponse{
+		WorkLogs: workLogs,
+		Totals:   model.WorkLogTotals{MemberSeconds: map[string]int64{}},
+	}
+	for _, workLog := range workLogs {
+		if workLog.Running {
+			continue
+		}
+		response.Totals.TotalSeconds += workLog.DurationSeconds
+		response.Totals.MemberSeconds[workLog.UserID] += workLog.DurationSeconds
+	}
+
+	if err := json.NewEncoder(rw).Encode(response); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to encode work logs", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched work logs")
+}
+
+func (t *TasksHandler) GetProjectWorkLogTotals(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.GetProjectWorkLogTotals")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	projectID := mux.Vars(h)["projectId"]
+	if !t.canAccessProject(ctx, h, projectID) {
+		span.SetStatus(codes.Error, "User is not part of the project")
+		http.Error(rw, "User is not part of the project", http.StatusForbidden)
+		return
+	}
+
+	totals, err := t.workLogRepo.GetProjectTotals(ctx, projectID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to sum up work logs", http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(rw).Encode(totals); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to encode work log totals", http.StatusInternalServerError)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully summed up work logs")
+}
+
+func (t *TasksHandler) EditWorkLog(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.EditWorkLog")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	var request workLogRequest
+	if err := json.NewDecoder(h.Body).Decode(&request); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	workLog, ok := t.getOwnWorkLog(rw, h)
+	if !ok {
+		return
+	}
+	if workLog.Running {
+		http.Error(rw, "Stop the timer before editing it", http.StatusConflict)
+		return
+	}
+
+	previousStart, previousSeconds := workLog.StartedAt, workLog.DurationSeconds
+	if err := applyWorkLogRequest(workLog, request); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := t.workLogRepo.UpdateWorkLog(ctx, workLog); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to update work log", http.StatusInternalServerError)
+		return
+	}
+
+	// the old entry is taken back and the new one counted, so the week buckets stay right
+	// even when the start moved to another week
+	if previousStart != workLog.StartedAt || previousSeconds != workLog.DurationSeconds {
+		t.sendWorkLoggedEvent(ctx, workLog, previousStart, -previousSeconds, "updated")
+		t.sendWorkLoggedEvent(ctx, workLog, workLog.StartedAt, workLog.DurationSeconds, "updated")
+	}
+
+	if err := workLog.ToJSON(rw); err != nil {
+		t.logger.Println("Error writing response:", err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully updated work log")
+}
+
+func (t *TasksHandler) DeleteWorkLog(rw http.ResponseWriter, h *http.Request) {
+	ctx, span := t.tracer.Start(h.Context(), "TaskHandler.DeleteWorkLog")
+	defer span.End()
+	t.logger.Printf("Received %s request for %s", h.Method, h.URL.Path)
+
+	workLog, ok := t.getOwnWorkLog(rw, h)
+	if !ok {
+		return
+	}
+
+	if err := t.workLogRepo.DeleteWorkLog(ctx, workLog.ID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(rw, "Unable to delete work log", http.StatusInternalServerError)
+		return
+	}
+	if !workLog.Running {
+		t.sendWorkLoggedEvent(ctx, workLog, workLog.StartedAt, -workLog.DurationSeconds, "deleted")
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+	span.SetStatus(codes.Ok, "Successfully deleted work log")
+}
+
+// getOwnWorkLog loads the work log from the URL and makes sure it belongs to the caller,
+// writing the error response otherwise
+func (t *TasksHandler) getOwnWorkLog(rw http.ResponseWriter, h *http.Request) (*model.WorkLog, bool) {
+	workLogID, err := primitive.ObjectIDFromHex(mux.Vars(h)["workLogId"])
+	if err != nil {
+		http.Error(rw, "Invalid workLogId format", http.StatusBadRequest)
+		return nil, false
+	}
+	userID, ok := h.Context().Value(KeyId{}).(string)
+	if !ok || userID == "" {
+		http.Error(rw, "User ID is missing or invalid", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	workLog, err := t.workLogRepo.GetWorkLogByID(h.Context(), workLogID)
+	if err != nil {
+		http.Error(rw, "Failed to fetch work log", http.StatusInternalServerError)
+		return nil, false
+	}
+	if workLog == nil {
+		http.Error(rw, "Work log not found", http.StatusNotFound)
+		return nil, false
+	}
+	if workLog.UserID != userID {
+		http.Error(rw, "Only the author can change a work log", http.StatusForbidden)
+		return nil, false
+	}
+	return workLog, true
+}
+
+func applyWorkLogRequest(workLog *model.WorkLog, request workLogRequest) error {
+	if request.DurationMinutes != nil {
+		duration := time.Duration(*request.DurationMinutes) * time.Minute
+		if duration <= 0 || duration > maxWorkLogDuration {
+			return errors.New("durationMinutes must be between 1 and 1440")
+		}
+		workLog.DurationSeconds = int64(duration.Seconds())
+	}
+	if request.StartedAt != nil {
+		if request.StartedAt.After(time.Now()) {
+			return errors.New("startedAt cannot be in the future")
+		}
+		workLog.StartedAt = *request.StartedAt
+	}
+	if request.Note != nil {
+		note := strings.TrimSpace(*request.Note)
+		if len(note) > 500 {
+			return errors.New("note cannot be longer than 500 characters")
+		}
+		workLog.Note = note
+	}
+	return nil
+}
+
+// sendWorkLoggedEvent reports logged time to analytics. The seconds are a delta, so edits and
+// deletions send negative amounts for the time that is taken back.
+func (t *TasksHandler) sendWorkLoggedEvent(ctx context.Context, workLog *model.WorkLog, startedAt time.Time, seconds int64, action string) {
+	event := map[string]interface{}{
+		"type": "WorkLogged",
+		"time": time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+		"event": map[string]interface{}{
+			"workLogId":       workLog.ID.Hex(),
+			"taskId":          workLog.TaskID,
+			"projectId":       workLog.ProjectID,
+			"userId":          workLog.UserID,
+			"startedAt":       startedAt.Format(time.RFC3339),
+			"durationSeconds": seconds,
+			"action":          action,
+		},
+		"projectId": workLog.ProjectID,
+	}
+	if err := t.sendEventToAnalyticsService(ctx, event); err != nil {
+		t.logger.Println("Failed to send WorkLogged event:", err)
+	}
+}
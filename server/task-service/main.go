@@ -20,6 +20,8 @@ import (
 	"task--service/customLogger"
 	"task--service/handlers"
 	"task--service/repositories"
+	"task--service/scanner"
+	"task--service/storage"
 	"time"
 )
 
@@ -27,6 +29,10 @@ func initUserClient() client.UserClient {
 	return client.NewUserClient(os.Getenv("USER_SERVICE_HOST"), os.Getenv("PORT"))
 }
 
+func initProjectClient() client.ProjectClient {
+	return client.NewProjectClient(os.Getenv("LINK_TO_PROJECT_SERVICE"), os.Getenv("LINK_TO_PROJECT_SERVICE_INTERNAL"))
+}
+
 func main() {
 
 	fmt.Println("Task Service is starting...")
@@ -75,6 +81,7 @@ func main() {
 
 	store.Ping()
 	userClient := initUserClient()
+	projectClient := initProjectClient()
 
 	taskDocStore, err := repositories.NewTaskDocumentRepository(timeoutContext, storeLogger, tracer)
 	if err != nil {
@@ -82,7 +89,54 @@ func main() {
 	}
 	defer store.Disconnect(timeoutContext)
 
-	taskHandler := handlers.NewTasksHandler(logger, store, taskDocStore, nc, tracer, userClient, custLogger)
+	taskCommentStore, err := repositories.NewTaskCommentRepository(timeoutContext, storeLogger, tracer)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer taskCommentStore.Disconnect(timeoutContext)
+
+	workLogStore, err := repositories.NewWorkLogRepository(timeoutContext, storeLogger, tracer)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer workLogStore.Disconnect(timeoutContext)
+
+	taskTemplateStore, err := repositories.NewTaskTemplateRepository(timeoutContext, storeLogger, tracer)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer taskTemplateStore.Disconnect(timeoutContext)
+
+	reminderStore, err := repositories.NewReminderRepository(timeoutContext, storeLogger, tracer)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer reminderStore.Disconnect(timeoutContext)
+
+	calendarFeedStore, err := repositories.NewCalendarFeedRepository(timeoutContext, storeLogger, tracer)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer calendarFeedStore.Disconnect(timeoutContext)
+
+	uploadSessionStore, err := repositories.NewUploadSessionRepository(timeoutContext, storeLogger, tracer)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer uploadSessionStore.Disconnect(timeoutContext)
+
+	documentStore, err := storage.NewDocumentStore(storeLogger, tracer)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer documentStore.Close()
+
+	documentScanner, err := scanner.NewScanner(storeLogger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	taskHandler := handlers.NewTasksHandler(logger, store, taskDocStore, taskCommentStore, workLogStore, taskTemplateStore, reminderStore, calendarFeedStore, uploadSessionStore, documentStore, documentScanner, nc, tracer, userClient, projectClient, custLogger)
 
 	sub, err := nc.QueueSubscribe("ProjectDeleted", "task-queue", func(msg *nats.Msg) {
 		projectID := string(msg.Data)
@@ -93,13 +147,12 @@ func main() {
 	}
 	defer sub.Unsubscribe()
 
-	sub2, err := nc.Subscribe("TasksDeletionComplete", func(msg *nats.Msg) {
+	sub2, err := nc.QueueSubscribe("ProjectPurged", "task-queue", func(msg *nats.Msg) {
 		projectID := string(msg.Data)
-		ctx := context.Background()
-		taskHandler.DeletedTasks(ctx, projectID)
+		taskHandler.HandleProjectPurged(context.Background(), projectID)
 	})
 	if err != nil {
-		logger.Fatalf("Failed to subscribe to ProjectDeleted: %v", err)
+		logger.Fatalf("Failed to subscribe to ProjectPurged: %v", err)
 	}
 	defer sub2.Unsubscribe()
 
@@ -113,6 +166,87 @@ func main() {
 	}
 	defer sub3.Unsubscribe()
 
+	sub4, err := nc.QueueSubscribe("project.label.renamed", "task-queue", func(msg *nats.Msg) {
+		taskHandler.HandleLabelRenamed(context.Background(), msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to project.label.renamed: %v", err)
+	}
+	defer sub4.Unsubscribe()
+
+	sub5, err := nc.QueueSubscribe("project.label.deleted", "task-queue", func(msg *nats.Msg) {
+		taskHandler.HandleLabelDeleted(context.Background(), msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to project.label.deleted: %v", err)
+	}
+	defer sub5.Unsubscribe()
+
+	sub6, err := nc.QueueSubscribe("project.field.deleted", "task-queue", func(msg *nats.Msg) {
+		taskHandler.HandleCustomFieldDeleted(context.Background(), msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to project.field.deleted: %v", err)
+	}
+	defer sub6.Unsubscribe()
+
+	sub7, err := nc.QueueSubscribe("project.field.options.changed", "task-queue", func(msg *nats.Msg) {
+		taskHandler.HandleCustomFieldOptionsChanged(context.Background(), msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to project.field.options.changed: %v", err)
+	}
+	defer sub7.Unsubscribe()
+
+	sub8, err := nc.QueueSubscribe("TaskWorkflowDeleted", "task-queue", func(msg *nats.Msg) {
+		taskHandler.HandleTaskWorkflowDeleted(context.Background(), msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to TaskWorkflowDeleted: %v", err)
+	}
+	defer sub8.Unsubscribe()
+
+	sub9, err := nc.QueueSubscribe("TaskWorkflowDeletionFailed", "task-queue", func(msg *nats.Msg) {
+		taskHandler.HandleTaskWorkflowDeletionFailed(context.Background(), msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to TaskWorkflowDeletionFailed: %v", err)
+	}
+	defer sub9.Unsubscribe()
+
+	sub10, err := nc.QueueSubscribe("TaskWorkflowRestored", "task-queue", func(msg *nats.Msg) {
+		taskHandler.HandleTaskWorkflowRestored(context.Background(), msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to TaskWorkflowRestored: %v", err)
+	}
+	defer sub10.Unsubscribe()
+
+	sub11, err := nc.QueueSubscribe("TaskWorkflowRestoreFailed", "task-queue", func(msg *nats.Msg) {
+		taskHandler.HandleTaskWorkflowRestoreFailed(context.Background(), msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to TaskWorkflowRestoreFailed: %v", err)
+	}
+	defer sub11.Unsubscribe()
+
+	sub12, err := nc.QueueSubscribe("ProjectRestored", "task-queue", func(msg *nats.Msg) {
+		projectID := string(msg.Data)
+		taskHandler.HandleProjectRestored(context.Background(), projectID)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to ProjectRestored: %v", err)
+	}
+	defer sub12.Unsubscribe()
+
+	sub13, err := nc.QueueSubscribe("project.removed", "task-queue", func(msg *nats.Msg) {
+		taskHandler.HandleMemberRemoved(context.Background(), msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to project.removed: %v", err)
+	}
+	defer sub13.Unsubscribe()
+
 	defer func() {
 		if err := nc.Drain(); err != nil {
 			logger.Printf("Error draining NATS connection: %v", err)
@@ -120,12 +254,26 @@ func main() {
 		nc.Close()
 	}()
 
+	schedulerContext, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go taskHandler.RunRecurringTaskScheduler(schedulerContext)
+	go taskHandler.RunTrashPurge(schedulerContext)
+	go taskHandler.RunTaskSagaRecovery(schedulerContext)
+	go taskHandler.RunDueDateScheduler(schedulerContext)
+	go taskHandler.RunUploadCleanup(schedulerContext)
+	go taskHandler.RunDocumentScanSweep(schedulerContext)
+	go taskHandler.RunDocumentPreviewSweep(schedulerContext)
+	go taskHandler.RunDocumentTextSweep(schedulerContext)
+	go taskHandler.RunDocumentReconciliation(schedulerContext)
+	go taskHandler.BackfillStorageUsage(schedulerContext)
+
 	router := mux.NewRouter()
 
 	router.Use(taskHandler.MiddlewareContentTypeSet)
 	router.Use(handlers.ExtractTraceInfoMiddleware)
 
 	getRouter := router.Methods(http.MethodGet).Subrouter()
+	getRouter.Handle("/tasks/search", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.SearchTasks))))
 	getRouter.Handle("/tasks", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetAllTask))))
 	getRouter.Handle("/tasks/{projectId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"member", "manager"}, http.HandlerFunc(taskHandler.GetAllTasksByProjectId))))
 	getRouter.Handle("/tasksDetails/{projectId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"member", "manager"}, http.HandlerFunc(taskHandler.GetAllTasksDetailsByProjectId))))
@@ -145,12 +293,59 @@ func main() {
 
 	documentGetRouter := router.Methods(http.MethodGet).Subrouter()
 	documentGetRouter.Handle("/tasks/getUploads/{taskId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetTaskDocumentsByTaskID))))
+	router.Handle("/tasks/projects/{projectId}/document-policy", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetDocumentPolicy)))).Methods(http.MethodGet)
+	router.Handle("/tasks/projects/{projectId}/document-policy", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(taskHandler.UpdateDocumentPolicy)))).Methods(http.MethodPut)
+	router.Handle("/tasks/documents/{documentId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.DeleteTaskDocument)))).Methods(http.MethodDelete)
+	router.Handle("/tasks/documents/{documentId}/preview", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetTaskDocumentPreview)))).Methods(http.MethodGet, http.MethodHead)
+	router.Handle("/tasks/documents/{documentId}/versions", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetTaskDocumentVersions)))).Methods(http.MethodGet)
+	router.Handle("/tasks/documents/{documentId}/versions/{version}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.DownloadTaskDocumentVersion)))).Methods(http.MethodGet, http.MethodHead)
+	router.Handle("/tasks/documents/{documentId}/versions/{version}/revert", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.RevertTaskDocument)))).Methods(http.MethodPost)
+	router.Handle("/tasks/{taskId}/uploads", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.CreateUploadSession)))).Methods(http.MethodPost)
+	router.Handle("/tasks/uploads/{uploadId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetUploadOffset)))).Methods(http.MethodHead, http.MethodGet)
+	router.Handle("/tasks/uploads/{uploadId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.PatchUploadChunk)))).Methods(http.MethodPatch)
+	router.Handle("/tasks/uploads/{uploadId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.CancelUpload)))).Methods(http.MethodDelete)
+	router.Handle("/tasks/uploads/{uploadId}/finalize", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.FinalizeUpload)))).Methods(http.MethodPost)
 	documentGetRouter.Handle("/tasks/download/{taskDocumentId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.DownloadTaskDocument))))
 
+	router.Handle("/tasks/{taskId}/comments", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetTaskComments)))).Methods(http.MethodGet)
+	router.Handle("/tasks/{taskId}/comments", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.PostTaskComment)))).Methods(http.MethodPost)
+	router.Handle("/tasks/{taskId}/labels/{label}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.AddLabelToTask)))).Methods(http.MethodPost)
+	router.Handle("/tasks/{taskId}/labels/{label}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.RemoveLabelFromTask)))).Methods(http.MethodDelete)
+	router.Handle("/tasks/{taskId}/fields", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.UpdateTaskCustomFields)))).Methods(http.MethodPut)
+	router.Handle("/tasks/timer/current", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetRunningTimer)))).Methods(http.MethodGet)
+	router.Handle("/tasks/timer/stop", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.StopTimer)))).Methods(http.MethodPost)
+	router.Handle("/tasks/{taskId}/timer/start", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.StartTimer)))).Methods(http.MethodPost)
+	router.Handle("/tasks/{taskId}/worklogs", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetTaskWorkLogs)))).Methods(http.MethodGet)
+	router.Handle("/tasks/{taskId}/worklogs", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.PostWorkLog)))).Methods(http.MethodPost)
+	router.Handle("/tasks/worklogs/{workLogId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.EditWorkLog)))).Methods(http.MethodPut)
+	router.Handle("/tasks/worklogs/{workLogId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.DeleteWorkLog)))).Methods(http.MethodDelete)
+	router.Handle("/tasks/projects/{projectId}/worklogs/totals", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetProjectWorkLogTotals)))).Methods(http.MethodGet)
+	router.Handle("/tasks/templates", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(taskHandler.PostTaskTemplate)))).Methods(http.MethodPost)
+	router.Handle("/tasks/templates/project/{projectId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetTaskTemplatesByProjectID)))).Methods(http.MethodGet)
+	router.Handle("/tasks/templates/{templateId}/status", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(taskHandler.UpdateTaskTemplateStatus)))).Methods(http.MethodPut)
+	router.Handle("/tasks/{taskId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(taskHandler.DeleteTask)))).Methods(http.MethodDelete)
+	router.Handle("/tasks/{taskId}/due-date", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(taskHandler.UpdateTaskDueDate)))).Methods(http.MethodPut)
+	router.Handle("/tasks/calendar/feeds", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetCalendarFeeds)))).Methods(http.MethodGet)
+	router.Handle("/tasks/calendar/feeds", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.CreateCalendarFeed)))).Methods(http.MethodPost)
+	router.Handle("/tasks/calendar/feeds/{feedId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.RevokeCalendarFeed)))).Methods(http.MethodDelete)
+	router.HandleFunc("/tasks/calendar/{token}.ics", taskHandler.GetCalendarFeed).Methods(http.MethodGet)
+	router.Handle("/tasks/reminders/preferences", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetReminderPreference)))).Methods(http.MethodGet)
+	router.Handle("/tasks/reminders/preferences", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.UpdateReminderPreference)))).Methods(http.MethodPut)
+	router.Handle("/tasks/{taskId}/watch", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.WatchTask)))).Methods(http.MethodPost)
+	router.Handle("/tasks/{taskId}/watch", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.UnwatchTask)))).Methods(http.MethodDelete)
+	router.Handle("/tasks/{taskId}/restore", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(taskHandler.RestoreTask)))).Methods(http.MethodPost)
+	router.Handle("/tasks/projects/{projectId}/storage", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetProjectStorage)))).Methods(http.MethodGet)
+	router.Handle("/tasks/projects/{projectId}/trash", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(taskHandler.GetProjectTaskTrash)))).Methods(http.MethodGet)
+	router.Handle("/tasks/projects/{projectId}/export", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.GetProjectExport)))).Methods(http.MethodGet)
+	router.Handle("/tasks/projects/{projectId}/comments/import", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager"}, http.HandlerFunc(taskHandler.ImportProjectComments)))).Methods(http.MethodPost)
+	router.Handle("/tasks/bulk", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.BulkUpdateTasks)))).Methods(http.MethodPost)
+	router.Handle("/tasks/comments/{commentId}", taskHandler.MiddlewareExtractUserFromCookie(taskHandler.MiddlewareCheckRoles([]string{"manager", "member"}, http.HandlerFunc(taskHandler.EditTaskComment)))).Methods(http.MethodPut)
+
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{"Location", "Tus-Resumable", "Upload-Offset", "Upload-Length", "Upload-Expires"},
 		AllowCredentials: true,
 	})
 
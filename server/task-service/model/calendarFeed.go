@@ -0,0 +1,18 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// CalendarFeed is a secret feed URL that calendar clients subscribe to. Only a hash of the
+// token is stored, the token itself is shown once when the feed is created. A feed with a
+// project lists every task of that project, otherwise it lists the tasks of its user.
+type CalendarFeed struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    string             `bson:"user_id" json:"userId"`
+	ProjectID string             `bson:"project_id,omitempty" json:"projectId,omitempty"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+}
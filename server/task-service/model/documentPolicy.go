@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// DocumentPolicy limits the documents that can be uploaded to the tasks of a project. Types
+// are MIME types such as "application/pdf" or whole families such as "image/*". When
+// AllowedTypes is empty every type that is not denied is allowed.
+type DocumentPolicy struct {
+	ProjectID    string    `bson:"project_id" json:"projectId"`
+	AllowedTypes []string  `bson:"allowed_types" json:"allowedTypes"`
+	DeniedTypes  []string  `bson:"denied_types" json:"deniedTypes"`
+	MaxSizeMB    int64     `bson:"max_size_mb,omitempty" json:"maxSizeMb,omitempty"`
+	UpdatedBy    string    `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+	UpdatedAt    time.Time `bson:"updated_at,omitempty" json:"updatedAt,omitempty"`
+}
+
+func (dp *DocumentPolicy) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(dp)
+}
+
+func (dp *DocumentPolicy) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(dp)
+}
@@ -0,0 +1,237 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FrequencyDaily   = "DAILY"
+	FrequencyWeekly  = "WEEKLY"
+	FrequencyMonthly = "MONTHLY"
+)
+
+// maxRecurrenceSteps bounds the search for the next occurrence, so a rule that can never
+// fire (e.g. BYMONTHDAY=31 with INTERVAL=2 starting in February) cannot spin forever
+const maxRecurrenceSteps = 1000
+
+var weekdayCodes = map[string]int{"MO": 0, "TU": 1, "WE": 2, "TH": 3, "FR": 4, "SA": 5, "SU": 6}
+
+// RecurrenceRule is the supported subset of an iCalendar RRULE: daily, weekly on given days
+// and monthly on a given day of the month, every Interval periods. Occurrences keep the
+// time of day of the series start and are computed in UTC.
+type RecurrenceRule struct {
+	Frequency  string     `bson:"freq" json:"freq"`
+	Interval   int        `bson:"interval" json:"interval"`
+	ByDay      []string   `bson:"by_day,omitempty" json:"byDay,omitempty"`
+	ByMonthDay int        `bson:"by_month_day,omitempty" json:"byMonthDay,omitempty"`
+	Count      int        `bson:"count,omitempty" json:"count,omitempty"`
+	Until      *time.Time `bson:"until,omitempty" json:"until,omitempty"`
+}
+
+// ParseRRule parses a rule like "FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,FR;COUNT=10"
+func ParseRRule(value string) (RecurrenceRule, error) {
+	rule := RecurrenceRule{Interval: 1}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return rule, fmt.Errorf("invalid rrule part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Frequency = strings.ToUpper(val)
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil {
+				return rule, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			rule.ByDay = strings.Split(strings.ToUpper(val), ",")
+		case "BYMONTHDAY":
+			day, err := strconv.Atoi(val)
+			if err != nil {
+				return rule, fmt.Errorf("invalid BYMONTHDAY %q", val)
+			}
+			rule.ByMonthDay = day
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil {
+				return rule, fmt.Errorf("invalid COUNT %q", val)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseRRuleTime(val)
+			if err != nil {
+				return rule, fmt.Errorf("invalid UNTIL %q", val)
+			}
+			rule.Until = &until
+		default:
+			return rule, fmt.Errorf("unsupported rrule part %s", key)
+		}
+	}
+	return rule, rule.Validate()
+}
+
+func parseRRuleTime(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown time format")
+}
+
+func (r RecurrenceRule) Validate() error {
+	if r.Interval < 1 {
+		return fmt.Errorf("INTERVAL must be at least 1")
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("COUNT cannot be negative")
+	}
+	switch r.Frequency {
+	case FrequencyDaily:
+		if len(r.ByDay) > 0 || r.ByMonthDay != 0 {
+			return fmt.Errorf("DAILY rules do not take BYDAY or BYMONTHDAY")
+		}
+	case FrequencyWeekly:
+		if r.ByMonthDay != 0 {
+			return fmt.Errorf("WEEKLY rules do not take BYMONTHDAY")
+		}
+		for _, day := range r.ByDay {
+			if _, ok := weekdayCodes[day]; !ok {
+				return fmt.Errorf("invalid BYDAY value %q", day)
+			}
+		}
+	case FrequencyMonthly:
+		if len(r.ByDay) > 0 {
+			return fmt.Errorf("MONTHLY rules do not take BYDAY")
+		}
+		if r.ByMonthDay < 0 || r.ByMonthDay > 31 {
+			return fmt.Errorf("BYMONTHDAY must be between 1 and 31")
+		}
+	default:
+		return fmt.Errorf("FREQ must be DAILY, WEEKLY or MONTHLY")
+	}
+	return nil
+}
+
+func (r RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Frequency, "INTERVAL=" + strconv.Itoa(r.Interval)}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(r.ByDay, ","))
+	}
+	if r.ByMonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	if r.Count != 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of a series starting at start that lies strictly after
+// after. The second result is false when the series has no further occurrence.
+func (r RecurrenceRule) Next(start, after time.Time) (time.Time, bool) {
+	start = start.UTC()
+	after = after.UTC()
+
+	var next time.Time
+	var ok bool
+	switch r.Frequency {
+	case FrequencyDaily:
+		next, ok = r.nextDaily(start, after)
+	case FrequencyWeekly:
+		next, ok = r.nextWeekly(start, after)
+	case FrequencyMonthly:
+		next, ok = r.nextMonthly(start, after)
+	}
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (r RecurrenceRule) nextDaily(start, after time.Time) (time.Time, bool) {
+	if after.Before(start) {
+		return start, true
+	}
+	// jump close to the answer instead of walking day by day from the start
+	step := int(after.Sub(start).Hours()/24) / r.Interval
+	for i := 0; i < maxRecurrenceSteps; i++ {
+		candidate := start.AddDate(0, 0, (step+i)*r.Interval)
+		if candidate.After(after) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r RecurrenceRule) nextWeekly(start, after time.Time) (time.Time, bool) {
+	offsets := []int{}
+	for _, day := range r.ByDay {
+		offsets = append(offsets, weekdayCodes[day])
+	}
+	if len(offsets) == 0 {
+		offsets = append(offsets, (int(start.Weekday())+6)%7)
+	}
+	sort.Ints(offsets)
+
+	weekStart := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	step := 0
+	if after.After(start) {
+		step = int(after.Sub(weekStart).Hours()/(24*7))/r.Interval - 1
+		if step < 0 {
+			step = 0
+		}
+	}
+	for i := 0; i < maxRecurrenceSteps; i++ {
+		week := weekStart.AddDate(0, 0, (step+i)*7*r.Interval)
+		for _, offset := range offsets {
+			candidate := week.AddDate(0, 0, offset)
+			if !candidate.Before(start) && candidate.After(after) {
+				return candidate, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r RecurrenceRule) nextMonthly(start, after time.Time) (time.Time, bool) {
+	day := r.ByMonthDay
+	if day == 0 {
+		day = start.Day()
+	}
+
+	step := 0
+	if after.After(start) {
+		months := (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+		step = months/r.Interval - 1
+		if step < 0 {
+			step = 0
+		}
+	}
+	for i := 0; i < maxRecurrenceSteps; i++ {
+		month := time.Date(start.Year(), start.Month()+time.Month((step+i)*r.Interval), 1, start.Hour(), start.Minute(), start.Second(), 0, time.UTC)
+		// months that are too short for the day are skipped, as RFC 5545 does
+		if day > month.AddDate(0, 1, -1).Day() {
+			continue
+		}
+		candidate := month.AddDate(0, 0, day-1)
+		if !candidate.Before(start) && candidate.After(after) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
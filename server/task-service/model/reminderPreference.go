@@ -0,0 +1,23 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ReminderPreference holds how many hours before a due date a user wants to be reminded.
+// Users without a preference get the default offsets of the scheduler.
+type ReminderPreference struct {
+	UserID       string `bson:"_id" json:"userId"`
+	OffsetsHours []int  `bson:"offsets_hours" json:"offsetsHours"`
+}
+
+func (rp *ReminderPreference) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(rp)
+}
+
+func (rp *ReminderPreference) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(rp)
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// StorageUsage is how many bytes of document files a project stores, in total and by the user
+// who uploaded them. Files shared by reverted versions count once, for the user who uploaded
+// them first. NotifiedPercent is the highest quota threshold the manager was told about.
+// Backfilled marks a usage that was counted from the stored documents of the project, usage
+// kept before that missed the files uploaded before the quota existed.
+type StorageUsage struct {
+	ProjectID       string           `bson:"project_id" json:"projectId"`
+	Bytes           int64            `bson:"bytes" json:"bytes"`
+	Users           map[string]int64 `bson:"users" json:"users"`
+	QuotaBytes      int64            `bson:"-" json:"quotaBytes"`
+	UsedPercent     float64          `bson:"-" json:"usedPercent"`
+	NotifiedPercent int              `bson:"notified_percent,omitempty" json:"-"`
+	UpdatedAt       time.Time        `bson:"updated_at,omitempty" json:"updatedAt"`
+	Backfilled      bool             `bson:"backfilled,omitempty" json:"-"`
+}
+
+func (su *StorageUsage) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(su)
+}
@@ -16,17 +16,39 @@ const (
 )
 
 type Task struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ProjectID       string             `bson:"project_id" json:"projectId"`
-	Name            string             `bson:"name" json:"name"`
-	Description     string             `bson:"description" json:"description"`
-	Status          TaskStatus         `bson:"status" json:"status"`
-	CreatedAt       time.Time          `bson:"created_at" json:"createdAt"`
-	UpdatedAt       time.Time          `bson:"updated_at" json:"updatedAt"`
-	UserIDs         []string           `bson:"user_ids" json:"user_ids"`
-	Dependencies    []string           `bson:"dependencies" json:"dependencies"`
-	Blocked         bool               `bson:"blocked" json:"blocked"`
-	PendingDeletion bool               `bson:"pending_deletion" json:"pending_deletion"`
+	ID              primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ProjectID       string                 `bson:"project_id" json:"projectId"`
+	Name            string                 `bson:"name" json:"name"`
+	Description     string                 `bson:"description" json:"description"`
+	Status          TaskStatus             `bson:"status" json:"status"`
+	CreatedAt       time.Time              `bson:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time              `bson:"updated_at" json:"updatedAt"`
+	UserIDs         []string               `bson:"user_ids" json:"user_ids"`
+	Dependencies    []string               `bson:"dependencies" json:"dependencies"`
+	Blocked         bool                   `bson:"blocked" json:"blocked"`
+	Labels          []string               `bson:"labels" json:"labels"`
+	CustomFields    map[string]interface{} `bson:"custom_fields" json:"customFields"`
+	TemplateID      string                 `bson:"template_id,omitempty" json:"templateId,omitempty"`
+	RecurrenceKey   string                 `bson:"recurrence_key,omitempty" json:"-"`
+	PendingDeletion bool                   `bson:"pending_deletion" json:"pending_deletion"`
+	DeletedAt       *time.Time             `bson:"deleted_at,omitempty" json:"deletedAt,omitempty"`
+	DeletedBy       string                 `bson:"deleted_by,omitempty" json:"deletedBy,omitempty"`
+	Trashed         bool                   `bson:"trashed,omitempty" json:"trashed,omitempty"`
+	DependencyOf    []string               `bson:"dependency_of,omitempty" json:"-"`
+	RestoringSince  *time.Time             `bson:"restoring_since,omitempty" json:"-"`
+	SagaRetries     int                    `bson:"saga_retries,omitempty" json:"-"`
+	Watchers        []string               `bson:"watchers,omitempty" json:"watchers,omitempty"`
+	Unwatchers      []string               `bson:"unwatchers,omitempty" json:"-"`
+	DueDate         *time.Time             `bson:"due_date,omitempty" json:"dueDate,omitempty"`
+	Overdue         bool                   `bson:"overdue,omitempty" json:"overdue,omitempty"`
+	RemindersSent   []string               `bson:"reminders_sent,omitempty" json:"-"`
+	Checklist       []ChecklistItem        `bson:"checklist,omitempty" json:"checklist,omitempty"`
+}
+
+// ChecklistItem is a step of a task, tasks imported from other tools bring their checklists along
+type ChecklistItem struct {
+	Name string `bson:"name" json:"name"`
+	Done bool   `bson:"done" json:"done"`
 }
 
 type Tasks []*Task
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"time"
+)
+
+type TaskComment struct {
+	ID        primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	TaskID    string                `bson:"task_id" json:"taskId"`
+	ProjectID string                `bson:"project_id" json:"projectId"`
+	ParentID  string                `bson:"parent_id,omitempty" json:"parentId,omitempty"`
+	AuthorID  string                `bson:"author_id" json:"authorId"`
+	Body      string                `bson:"body" json:"body"` // Markdown
+	Mentions  []string              `bson:"mentions" json:"mentions"`
+	CreatedAt time.Time             `bson:"created_at" json:"createdAt"`
+	EditedAt  *time.Time            `bson:"edited_at,omitempty" json:"editedAt,omitempty"`
+	History   []TaskCommentRevision `bson:"history" json:"history"`
+}
+
+// TaskCommentRevision keeps a previous body of an edited comment
+type TaskCommentRevision struct {
+	Body     string    `bson:"body" json:"body"`
+	EditedAt time.Time `bson:"edited_at" json:"editedAt"`
+}
+
+type TaskComments []*TaskComment
+
+func (tc *TaskComments) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(tc)
+}
+
+func (tc *TaskComment) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(tc)
+}
+
+func (tc *TaskComment) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(tc)
+}
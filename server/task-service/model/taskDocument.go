@@ -4,15 +4,148 @@ import (
 	"encoding/json"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"io"
+	"time"
 )
 
+// TaskDocument is a file attached to a task. Uploading a file with the same name to the task
+// again, or uploading to the document directly, adds a version. The file fields describe the
+// current version, the earlier ones are kept in Versions.
 type TaskDocument struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	TaskID     string             `bson:"task_id" json:"taskId"`
 	FileName   string             `bson:"file_name" json:"fileName"`
 	FileType   string             `bson:"file_type" json:"fileType"`
 	FilePath   string             `bson:"file_path" json:"filePath"`
+	Size       int64              `bson:"size,omitempty" json:"size,omitempty"`
+	SHA256     string             `bson:"sha256,omitempty" json:"sha256,omitempty"`
 	UploadedAt primitive.DateTime `bson:"uploaded_at" json:"uploadedAt"`
+	UploadedBy string             `bson:"uploaded_by,omitempty" json:"uploadedBy,omitempty"`
+	Status     DocumentStatus     `bson:"status,omitempty" json:"status,omitempty"`
+	Preview    PreviewStatus      `bson:"preview,omitempty" json:"preview,omitempty"`
+	TextStatus TextStatus         `bson:"text_status,omitempty" json:"textStatus,omitempty"`
+	Text       string             `bson:"text,omitempty" json:"-"`
+	Version    int                `bson:"version,omitempty" json:"version"`
+	Versions   []DocumentVersion  `bson:"versions,omitempty" json:"-"`
+	Deleted    bool               `bson:"deleted" json:"-"`
+
+	// ScanAttempts and ScanAttemptedAt count the sweeps that tried to scan a pending file, the
+	// sweep takes the documents it tried longest ago first
+	ScanAttempts    int        `bson:"scan_attempts,omitempty" json:"-"`
+	ScanAttemptedAt *time.Time `bson:"scan_attempted_at,omitempty" json:"-"`
+}
+
+// DocumentVersion is one uploaded file of a document. A version made by reverting shares the
+// file of the version it reverted to.
+type DocumentVersion struct {
+	Number       int                `bson:"number" json:"number"`
+	FileName     string             `bson:"file_name" json:"fileName"`
+	FileType     string             `bson:"file_type" json:"fileType"`
+	FilePath     string             `bson:"file_path" json:"-"`
+	Size         int64              `bson:"size,omitempty" json:"size,omitempty"`
+	SHA256       string             `bson:"sha256,omitempty" json:"sha256,omitempty"`
+	UploadedBy   string             `bson:"uploaded_by,omitempty" json:"uploadedBy,omitempty"`
+	UploadedAt   primitive.DateTime `bson:"uploaded_at" json:"uploadedAt"`
+	RevertedFrom int                `bson:"reverted_from,omitempty" json:"revertedFrom,omitempty"`
+	Status       DocumentStatus     `bson:"status,omitempty" json:"status,omitempty"`
+	Signature    string             `bson:"signature,omitempty" json:"signature,omitempty"`
+	Preview      PreviewStatus      `bson:"preview,omitempty" json:"preview,omitempty"`
+	PreviewPath  string             `bson:"preview_path,omitempty" json:"-"`
+}
+
+// DocumentStatus is the malware scan verdict on the file of a version, unscannable when the
+// file is larger than the scanner accepts, or missing when the reconciliation found the file
+// gone from the store. Files uploaded before scanning, or while no scanner is configured, have
+// no status and count as clean.
+type DocumentStatus string
+
+const (
+	DocumentPending     DocumentStatus = "pending"
+	DocumentClean       DocumentStatus = "clean"
+	DocumentInfected    DocumentStatus = "infected"
+	DocumentUnscannable DocumentStatus = "unscannable"
+	DocumentMissing     DocumentStatus = "missing"
+)
+
+// PreviewStatus tells whether the preview of the file of a version can be shown. Files
+// uploaded before previews were generated have no status and no preview.
+type PreviewStatus string
+
+const (
+	PreviewPending     PreviewStatus = "pending"
+	PreviewReady       PreviewStatus = "ready"
+	PreviewFailed      PreviewStatus = "failed"
+	PreviewUnsupported PreviewStatus = "unsupported"
+)
+
+// TextStatus tells whether the text of the current file of a document was extracted. Text is
+// only kept for the current file, a new version or a revert extracts it again.
+type TextStatus string
+
+const (
+	TextPending     TextStatus = "pending"
+	TextExtracted   TextStatus = "extracted"
+	TextFailed      TextStatus = "failed"
+	TextUnsupported TextStatus = "unsupported"
+)
+
+// Available reports whether the file of the version may be downloaded
+func (dv *DocumentVersion) Available() bool {
+	return dv.Status == "" || dv.Status == DocumentClean
+}
+
+// AllVersions returns the versions of the document, oldest first. Documents uploaded before
+// versioning have their only file as version 1.
+func (td *TaskDocument) AllVersions() []DocumentVersion {
+	if len(td.Versions) > 0 {
+		return td.Versions
+	}
+	return []DocumentVersion{td.CurrentVersion()}
+}
+
+// CurrentVersion returns the version the file fields of the document describe
+func (td *TaskDocument) CurrentVersion() DocumentVersion {
+	for _, version := range td.Versions {
+		if version.Number == td.Version {
+			return version
+		}
+	}
+	return DocumentVersion{
+		Number:     max(td.Version, 1),
+		FileName:   td.FileName,
+		FileType:   td.FileType,
+		FilePath:   td.FilePath,
+		Size:       td.Size,
+		SHA256:     td.SHA256,
+		UploadedBy: td.UploadedBy,
+		UploadedAt: td.UploadedAt,
+		Status:     td.Status,
+		Preview:    td.Preview,
+	}
+}
+
+// GetVersion returns the version with the given number, or nil when there is none
+func (td *TaskDocument) GetVersion(number int) *DocumentVersion {
+	for _, version := range td.AllVersions() {
+		if version.Number == number {
+			return &version
+		}
+	}
+	return nil
+}
+
+// FilePaths returns the distinct stored files of every version, with their previews
+func (td *TaskDocument) FilePaths() []string {
+	paths := []string{}
+	seen := map[string]bool{}
+	for _, version := range td.AllVersions() {
+		for _, filePath := range []string{version.FilePath, version.PreviewPath} {
+			if filePath != "" && !seen[filePath] {
+				seen[filePath] = true
+				paths = append(paths, filePath)
+			}
+		}
+	}
+	return paths
 }
 
 func (td *TaskDocument) ToJSON(w io.Writer) error {
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/json"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"time"
+)
+
+type TaskTemplateStatus string
+
+const (
+	TemplateActive TaskTemplateStatus = "active"
+	TemplatePaused TaskTemplateStatus = "paused"
+	TemplateEnded  TaskTemplateStatus = "ended"
+)
+
+// TaskTemplate describes a recurring task. The scheduler creates a task out of it at
+// every occurrence of its recurrence rule.
+type TaskTemplate struct {
+	ID             primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ProjectID      string                 `bson:"project_id" json:"projectId"`
+	Name           string                 `bson:"name" json:"name"`
+	Description    string                 `bson:"description" json:"description"`
+	UserIDs        []string               `bson:"user_ids" json:"user_ids"`
+	Labels         []string               `bson:"labels" json:"labels"`
+	CustomFields   map[string]interface{} `bson:"custom_fields" json:"customFields"`
+	RRule          string                 `bson:"rrule" json:"rrule"`
+	Recurrence     RecurrenceRule         `bson:"recurrence" json:"recurrence"`
+	StartsAt       time.Time              `bson:"starts_at" json:"startsAt"`
+	NextRunAt      *time.Time             `bson:"next_run_at,omitempty" json:"nextRunAt,omitempty"`
+	LastRunAt      *time.Time             `bson:"last_run_at,omitempty" json:"lastRunAt,omitempty"`
+	GeneratedCount int                    `bson:"generated_count" json:"generatedCount"`
+	Status         TaskTemplateStatus     `bson:"status" json:"status"`
+	CreatedBy      string                 `bson:"created_by" json:"createdBy"`
+	CreatedAt      time.Time              `bson:"created_at" json:"createdAt"`
+	UpdatedAt      time.Time              `bson:"updated_at" json:"updatedAt"`
+	// PausedWithProject marks a series paused because its project was deleted, it is resumed
+	// when the project is restored
+	PausedWithProject bool `bson:"paused_with_project,omitempty" json:"pausedWithProject,omitempty"`
+}
+
+type TaskTemplates []*TaskTemplate
+
+func (tt *TaskTemplates) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(tt)
+}
+
+func (tt *TaskTemplate) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(tt)
+}
+
+func (tt *TaskTemplate) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(tt)
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"encoding/json"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"time"
+)
+
+// UploadSession is a resumable upload of a task document. Every accepted PATCH is kept as a
+// chunk in the document store, the chunks are joined into the document file when the upload
+// is finalized, as a new version of DocumentID when it is set. Sessions that are not
+// finalized before ExpiresAt are removed with their chunks.
+type UploadSession struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TaskID          string             `bson:"task_id" json:"taskId"`
+	UserID          string             `bson:"user_id" json:"userId"`
+	DocumentID      string             `bson:"document_id,omitempty" json:"documentId,omitempty"`
+	FileName        string             `bson:"file_name" json:"fileName"`
+	FileType        string             `bson:"file_type" json:"fileType"`
+	Length          int64              `bson:"length" json:"length"`
+	Offset          int64              `bson:"offset" json:"offset"`
+	Chunks          []UploadChunk      `bson:"chunks" json:"-"`
+	CreatedAt       time.Time          `bson:"created_at" json:"createdAt"`
+	ExpiresAt       time.Time          `bson:"expires_at" json:"expiresAt"`
+	FinalizingUntil *time.Time         `bson:"finalizing_until,omitempty" json:"-"`
+}
+
+// UploadChunk is a stored part of an upload, starting at Offset of the whole file
+type UploadChunk struct {
+	Path   string `bson:"path"`
+	Offset int64  `bson:"offset"`
+	Size   int64  `bson:"size"`
+}
+
+func (us *UploadSession) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(us)
+}
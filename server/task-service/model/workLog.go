@@ -0,0 +1,49 @@
+package model
+
+import (
+	"encoding/json"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"time"
+)
+
+// WorkLog is time spent by a user on a task, either tracked with a timer or entered manually.
+// A running timer has no EndedAt and its duration is only known once it is stopped.
+type WorkLog struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TaskID          string             `bson:"task_id" json:"taskId"`
+	ProjectID       string             `bson:"project_id" json:"projectId"`
+	UserID          string             `bson:"user_id" json:"userId"`
+	StartedAt       time.Time          `bson:"started_at" json:"startedAt"`
+	EndedAt         *time.Time         `bson:"ended_at,omitempty" json:"endedAt,omitempty"`
+	DurationSeconds int64              `bson:"duration_seconds" json:"durationSeconds"`
+	Note            string             `bson:"note" json:"note"`
+	Running         bool               `bson:"running" json:"running"`
+	Manual          bool               `bson:"manual" json:"manual"`
+	CreatedAt       time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+type WorkLogs []*WorkLog
+
+// WorkLogTotals sums up logged time, in seconds, overall and per member
+type WorkLogTotals struct {
+	TotalSeconds  int64            `json:"totalSeconds"`
+	MemberSeconds map[string]int64 `json:"memberSeconds"`
+	TaskSeconds   map[string]int64 `json:"taskSeconds,omitempty"`
+}
+
+func (w *WorkLogs) ToJSON(wr io.Writer) error {
+	e := json.NewEncoder(wr)
+	return e.Encode(w)
+}
+
+func (w *WorkLog) ToJSON(wr io.Writer) error {
+	e := json.NewEncoder(wr)
+	return e.Encode(w)
+}
+
+func (w *WorkLog) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(w)
+}
@@ -0,0 +1,237 @@
+// Package preview renders small previews of document files: thumbnails of images, the first
+// lines of text files and the first lines of the text of the first page of PDFs. Other files
+// have no preview.
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"strings"
+	"task--service/extract"
+	"unicode/utf8"
+)
+
+// Kind is the kind of preview a file type gets
+type Kind int
+
+const (
+	None Kind = iota
+	Image
+	Text
+	// PDF previews show the text layer of the first page, so scanned PDFs without one have
+	// an empty preview
+	PDF
+)
+
+const (
+	// MaxDimension is the longest side of a thumbnail in pixels
+	MaxDimension = 320
+	// MaxLines is how many lines of a text file, or rows of a CSV file, a preview shows
+	MaxLines = 50
+
+	// maxPixels guards against images that are small files but decode to huge bitmaps
+	maxPixels = 50_000_000
+	// maxTextBytes is how much of a text file is read for its preview
+	maxTextBytes = 64 << 10
+	// maxCellWidth is where long CSV cells are cut in the preview
+	maxCellWidth = 30
+)
+
+// ErrTooLarge is returned for images with more pixels than a preview is rendered for
+var ErrTooLarge = errors.New("image is too large to preview")
+
+// KindOf tells which preview a file type gets
+func KindOf(contentType string) Kind {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return None
+	}
+	switch {
+	case mediaType == "image/png" || mediaType == "image/jpeg" || mediaType == "image/gif":
+		return Image
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json":
+		return Text
+	case mediaType == "application/pdf":
+		return PDF
+	default:
+		return None
+	}
+}
+
+// ContentType is the type of the rendered preview of a kind
+func ContentType(kind Kind) string {
+	if kind == Image {
+		return "image/png"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// Render renders the preview of a file of size bytes of the given type
+func Render(r io.Reader, size int64, contentType string) ([]byte, error) {
+	switch KindOf(contentType) {
+	case Image:
+		return Thumbnail(r)
+	case Text:
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType == "text/csv" {
+			return CSV(r)
+		}
+		return Lines(r)
+	case PDF:
+		text, err := extract.PDFFirstPage(r, size)
+		if err != nil {
+			return nil, err
+		}
+		return Lines(strings.NewReader(text))
+	default:
+		return nil, fmt.Errorf("no preview for %s files", contentType)
+	}
+}
+
+// Thumbnail scales an image down to fit MaxDimension and encodes it as PNG. Smaller images
+// keep their size.
+func Thumbnail(r io.Reader) ([]byte, error) {
+	// the header read for the size is replayed to the decoder
+	var head bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(r, &head))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	src, _, err := image.Decode(io.MultiReader(&head, r))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, scaleDown(src, MaxDimension)); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// scaleDown averages the pixels of src into an image whose longer side is at most max
+func scaleDown(src image.Image, max int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= max && h <= max {
+		return src
+	}
+	tw, th := max, h*max/w
+	if h > w {
+		tw, th = w*max/h, max
+	}
+	tw, th = maxInt(tw, 1), maxInt(th, 1)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0 := bounds.Min.Y + y*h/th
+		y1 := maxInt(bounds.Min.Y+(y+1)*h/th, y0+1)
+		for x := 0; x < tw; x++ {
+			x0 := bounds.Min.X + x*w/tw
+			x1 := maxInt(bounds.Min.X+(x+1)*w/tw, x0+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(b / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Lines returns the first MaxLines lines of a text file
+func Lines(r io.Reader) ([]byte, error) {
+	scanner := bufio.NewScanner(io.LimitReader(r, maxTextBytes))
+	scanner.Buffer(make([]byte, 0, 4096), maxTextBytes)
+	var out strings.Builder
+	for lines := 0; lines < MaxLines && scanner.Scan(); lines++ {
+		out.WriteString(strings.ToValidUTF8(scanner.Text(), "�"))
+		out.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return nil, fmt.Errorf("failed to read text: %w", err)
+	}
+	return []byte(out.String()), nil
+}
+
+// CSV renders the first MaxLines rows of a CSV file as aligned columns. Files that are not
+// valid CSV are shown as plain lines.
+func CSV(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxTextBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text: %w", err)
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	var rows [][]string
+	for len(rows) < MaxLines {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// the limit may cut the last row short
+			if len(rows) > 0 {
+				break
+			}
+			return Lines(bytes.NewReader(data))
+		}
+		for i, cell := range row {
+			cell = strings.ToValidUTF8(strings.Join(strings.Fields(cell), " "), "�")
+			if utf8.RuneCountInString(cell) > maxCellWidth {
+				cell = string([]rune(cell)[:maxCellWidth-1]) + "…"
+			}
+			row[i] = cell
+		}
+		rows = append(rows, row)
+	}
+
+	widths := []int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = maxInt(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	var out strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			if i > 0 {
+				out.WriteString(" | ")
+			}
+			out.WriteString(cell)
+			if i < len(row)-1 {
+				out.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+			}
+		}
+		out.WriteByte('\n')
+	}
+	return []byte(out.String()), nil
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type CalendarFeedRepository struct {
+	cli    *mongo.Client
+	logger *log.Logger
+	tracer trace.Tracer
+}
+
+func NewCalendarFeedRepository(ctx context.Context, logger *log.Logger, tracer trace.Tracer) (*CalendarFeedRepository, error) {
+	dburi := os.Getenv("MONGO_DB_URI")
+	if dburi == "" {
+		return nil, fmt.Errorf("MONGO_DB_URI environment variable is not set")
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(dburi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+
+	if err = client.Ping(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	logger.Println("Successfully connected to MongoDB")
+
+	repo := &CalendarFeedRepository{
+		cli:    client,
+		logger: logger,
+		tracer: tracer,
+	}
+
+	_, err = repo.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar feed indexes: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (cfr *CalendarFeedRepository) Disconnect(ctx context.Context) error {
+	ctx, span := cfr.tracer.Start(ctx, "CalendarFeedRepository.Disconnect")
+	defer span.End()
+
+	err := cfr.cli.Disconnect(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully disconnected")
+	return nil
+}
+
+func (cfr *CalendarFeedRepository) getCollection() *mongo.Collection {
+	taskDatabase := cfr.cli.Database("mongoTask")
+	feedsCollection := taskDatabase.Collection("calendar_feeds")
+	return feedsCollection
+}
+
+func (cfr *CalendarFeedRepository) Insert(ctx context.Context, feed *model.CalendarFeed) error {
+	ctx, span := cfr.tracer.Start(ctx, "CalendarFeedRepository.Insert")
+	defer span.End()
+
+	feed.ID = primitive.NewObjectID()
+	feed.CreatedAt = time.Now()
+	if _, err := cfr.getCollection().InsertOne(ctx, feed); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to insert calendar feed: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully inserted calendar feed")
+	return nil
+}
+
+// GetActiveByTokenHash returns the feed a token belongs to, or nil when the token is unknown
+// or was revoked
+func (cfr *CalendarFeedRepository) GetActiveByTokenHash(ctx context.Context, tokenHash string) (*model.CalendarFeed, error) {
+	ctx, span := cfr.tracer.Start(ctx, "CalendarFeedRepository.GetActiveByTokenHash")
+	defer span.End()
+
+	var feed model.CalendarFeed
+	err := cfr.getCollection().FindOne(ctx, bson.M{
+		"token_hash": tokenHash,
+		"revoked_at": bson.M{"$exists": false},
+	}).Decode(&feed)
+	if err == mongo.ErrNoDocuments {
+		span.SetStatus(codes.Ok, "Calendar feed not found")
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to get calendar feed: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully got calendar feed")
+	return &feed, nil
+}
+
+// GetByUserID returns the feeds of a user that were not revoked, newest first
+func (cfr *CalendarFeedRepository) GetByUserID(ctx context.Context, userID string) ([]model.CalendarFeed, error) {
+	ctx, span := cfr.tracer.Start(ctx, "CalendarFeedRepository.GetByUserID")
+	defer span.End()
+
+	feeds := []model.CalendarFeed{}
+	cursor, err := cfr.getCollection().Find(ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &feeds); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got calendar feeds")
+	return feeds, nil
+}
+
+// Revoke disables a feed of a user for good. It reports false when the user has no such
+// active feed.
+func (cfr *CalendarFeedRepository) Revoke(ctx context.Context, feedID string, userID string) (bool, error) {
+	ctx, span := cfr.tracer.Start(ctx, "CalendarFeedRepository.Revoke")
+	defer span.End()
+
+	feedObjID, err := primitive.ObjectIDFromHex(feedID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, nil
+	}
+	result, err := cfr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": feedObjID, "user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to revoke calendar feed %s: %v", feedID, err)
+	}
+	span.SetStatus(codes.Ok, "Successfully revoked calendar feed")
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ReminderRepository stores the reminder preferences of users and the leases that keep a
+// single replica of the due date scheduler running at a time
+type ReminderRepository struct {
+	cli    *mongo.Client
+	logger *log.Logger
+	tracer trace.Tracer
+}
+
+func NewReminderRepository(ctx context.Context, logger *log.Logger, tracer trace.Tracer) (*ReminderRepository, error) {
+	dburi := os.Getenv("MONGO_DB_URI")
+	if dburi == "" {
+		return nil, fmt.Errorf("MONGO_DB_URI environment variable is not set")
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(dburi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+
+	if err = client.Ping(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	logger.Println("Successfully connected to MongoDB")
+
+	return &ReminderRepository{
+		cli:    client,
+		logger: logger,
+		tracer: tracer,
+	}, nil
+}
+
+func (rr *ReminderRepository) Disconnect(ctx context.Context) error {
+	ctx, span := rr.tracer.Start(ctx, "ReminderRepository.Disconnect")
+	defer span.End()
+
+	err := rr.cli.Disconnect(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully disconnected")
+	return nil
+}
+
+func (rr *ReminderRepository) getPreferenceCollection() *mongo.Collection {
+	return rr.cli.Database("mongoTask").Collection("reminder_preferences")
+}
+
+func (rr *ReminderRepository) getLeaseCollection() *mongo.Collection {
+	return rr.cli.Database("mongoTask").Collection("scheduler_leases")
+}
+
+// GetPreference returns the reminder preference of a user, or nil when the user has none
+func (rr *ReminderRepository) GetPreference(ctx context.Context, userID string) (*model.ReminderPreference, error) {
+	ctx, span := rr.tracer.Start(ctx, "ReminderRepository.GetPreference")
+	defer span.End()
+
+	var preference model.ReminderPreference
+	err := rr.getPreferenceCollection().FindOne(ctx, bson.M{"_id": userID}).Decode(&preference)
+	if err == mongo.ErrNoDocuments {
+		span.SetStatus(codes.Ok, "User has no reminder preference")
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to get reminder preference: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully got reminder preference")
+	return &preference, nil
+}
+
+// GetPreferences returns the reminder offsets of the given users that set a preference
+func (rr *ReminderRepository) GetPreferences(ctx context.Context, userIDs []string) (map[string][]int, error) {
+	ctx, span := rr.tracer.Start(ctx, "ReminderRepository.GetPreferences")
+	defer span.End()
+
+	offsets := map[string][]int{}
+	if len(userIDs) == 0 {
+		span.SetStatus(codes.Ok, "No users to look up")
+		return offsets, nil
+	}
+	cursor, err := rr.getPreferenceCollection().Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to get reminder preferences: %v", err)
+	}
+	var preferences []model.ReminderPreference
+	if err = cursor.All(ctx, &preferences); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to decode reminder preferences: %v", err)
+	}
+	for _, preference := range preferences {
+		offsets[preference.UserID] = preference.OffsetsHours
+	}
+	span.SetStatus(codes.Ok, "Successfully got reminder preferences")
+	return offsets, nil
+}
+
+func (rr *ReminderRepository) SavePreference(ctx context.Context, preference *model.ReminderPreference) error {
+	ctx, span := rr.tracer.Start(ctx, "ReminderRepository.SavePreference")
+	defer span.End()
+
+	_, err := rr.getPreferenceCollection().ReplaceOne(ctx,
+		bson.M{"_id": preference.UserID},
+		preference,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to save reminder preference: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully saved reminder preference")
+	return nil
+}
+
+// AcquireLease takes or renews the named lease for ttl. It reports false while another owner
+// holds a lease that has not expired yet.
+func (rr *ReminderRepository) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	ctx, span := rr.tracer.Start(ctx, "ReminderRepository.AcquireLease")
+	defer span.End()
+
+	now := time.Now()
+	_, err := rr.getLeaseCollection().UpdateOne(ctx,
+		bson.M{"_id": name, "$or": []bson.M{
+			{"owner": owner},
+			{"expires_at": bson.M{"$lte": now}},
+		}},
+		bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(ttl)}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// the lease exists and belongs to someone else, so the upsert tried to insert it again
+		span.SetStatus(codes.Ok, "Lease is held by another owner")
+		return false, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to acquire lease %s: %v", name, err)
+	}
+	span.SetStatus(codes.Ok, "Successfully acquired lease")
+	return true, nil
+}
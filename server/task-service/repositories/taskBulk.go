@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// FindByIDs returns the tasks with the given IDs. Unknown or malformed IDs and tasks that are
+// being deleted are left out.
+func (tr *TaskRepository) FindByIDs(ctx context.Context, taskIDs []string) ([]model.Task, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.FindByIDs")
+	defer span.End()
+
+	tasks := []model.Task{}
+	objectIDs := toObjectIDs(taskIDs)
+	if len(objectIDs) == 0 {
+		span.SetStatus(codes.Ok, "No task IDs to look up")
+		return tasks, nil
+	}
+
+	cursor, err := tr.getCollection().Find(ctx, bson.M{
+		"_id":              bson.M{"$in": objectIDs},
+		"pending_deletion": bson.M{"$ne": true},
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got tasks by IDs")
+	return tasks, nil
+}
+
+// SetStatusMany moves the tasks to a status
+func (tr *TaskRepository) SetStatusMany(ctx context.Context, taskIDs []string, status model.TaskStatus) (int64, error) {
+	return tr.updateMany(ctx, "TaskRepository.SetStatusMany", taskIDs, bson.M{
+		"$set": bson.M{"status": status, "updated_at": time.Now()},
+	})
+}
+
+// SetBlockedMany sets the blocked flag of the tasks
+func (tr *TaskRepository) SetBlockedMany(ctx context.Context, taskIDs []string, blocked bool) (int64, error) {
+	return tr.updateMany(ctx, "TaskRepository.SetBlockedMany", taskIDs, bson.M{
+		"$set": bson.M{"blocked": blocked, "updated_at": time.Now()},
+	})
+}
+
+// AddMemberMany assigns a user to the tasks
+func (tr *TaskRepository) AddMemberMany(ctx context.Context, taskIDs []string, userID string) (int64, error) {
+	return tr.updateMany(ctx, "TaskRepository.AddMemberMany", taskIDs, bson.M{
+		"$addToSet": bson.M{"user_ids": userID},
+		"$set":      bson.M{"updated_at": time.Now()},
+	})
+}
+
+// RemoveMemberMany unassigns a user from the tasks
+func (tr *TaskRepository) RemoveMemberMany(ctx context.Context, taskIDs []string, userID string) (int64, error) {
+	return tr.updateMany(ctx, "TaskRepository.RemoveMemberMany", taskIDs, bson.M{
+		"$pull": bson.M{"user_ids": userID},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+}
+
+// AddLabelMany attaches a label to the tasks
+func (tr *TaskRepository) AddLabelMany(ctx context.Context, taskIDs []string, label string) (int64, error) {
+	return tr.updateMany(ctx, "TaskRepository.AddLabelMany", taskIDs, bson.M{
+		"$addToSet": bson.M{"labels": label},
+		"$set":      bson.M{"updated_at": time.Now()},
+	})
+}
+
+// RemoveLabelMany detaches a label from the tasks
+func (tr *TaskRepository) RemoveLabelMany(ctx context.Context, taskIDs []string, label string) (int64, error) {
+	return tr.updateMany(ctx, "TaskRepository.RemoveLabelMany", taskIDs, bson.M{
+		"$pull": bson.M{"labels": label},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+}
+
+func (tr *TaskRepository) updateMany(ctx context.Context, spanName string, taskIDs []string, update bson.M) (int64, error) {
+	ctx, span := tr.tracer.Start(ctx, spanName)
+	defer span.End()
+
+	result, err := tr.getCollection().UpdateMany(ctx, bson.M{"_id": bson.M{"$in": toObjectIDs(taskIDs)}}, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to update tasks: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully updated tasks")
+	return result.ModifiedCount, nil
+}
+
+func toObjectIDs(ids []string) []primitive.ObjectID {
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	return objectIDs
+}
@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TaskCommentRepository struct {
+	cli    *mongo.Client
+	logger *log.Logger
+	tracer trace.Tracer
+}
+
+func NewTaskCommentRepository(ctx context.Context, logger *log.Logger, tracer trace.Tracer) (*TaskCommentRepository, error) {
+	dburi := os.Getenv("MONGO_DB_URI")
+	if dburi == "" {
+		return nil, fmt.Errorf("MONGO_DB_URI environment variable is not set")
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(dburi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+
+	if err = client.Ping(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	logger.Println("Successfully connected to MongoDB")
+
+	return &TaskCommentRepository{
+		cli:    client,
+		logger: logger,
+		tracer: tracer,
+	}, nil
+}
+
+func (tcr *TaskCommentRepository) Disconnect(ctx context.Context) error {
+	ctx, span := tcr.tracer.Start(ctx, "TaskCommentRepository.Disconnect")
+	defer span.End()
+
+	err := tcr.cli.Disconnect(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully disconnected")
+	return nil
+}
+
+func (tcr *TaskCommentRepository) getCollection() *mongo.Collection {
+	projectDatabase := tcr.cli.Database("mongoTask")
+	taskCommentsCollection := projectDatabase.Collection("task_comments")
+	return taskCommentsCollection
+}
+
+func (tcr *TaskCommentRepository) SaveTaskComment(ctx context.Context, comment *model.TaskComment) error {
+	ctx, span := tcr.tracer.Start(ctx, "TaskCommentRepository.SaveTaskComment")
+	defer span.End()
+
+	if comment.ID.IsZero() {
+		comment.ID = primitive.NewObjectID()
+	}
+	if comment.Mentions == nil {
+		comment.Mentions = []string{}
+	}
+	if comment.History == nil {
+		comment.History = []model.TaskCommentRevision{}
+	}
+	comment.CreatedAt = time.Now()
+
+	_, err := tcr.getCollection().InsertOne(ctx, comment)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		tcr.logger.Printf("Failed to save task comment: %v", err)
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully saved task comment")
+	return nil
+}
+
+// ImportTaskComments inserts comments restored from a project archive, unlike SaveTaskComment
+// it keeps their creation time
+func (tcr *TaskCommentRepository) ImportTaskComments(ctx context.Context, comments []*model.TaskComment) error {
+	ctx, span := tcr.tracer.Start(ctx, "TaskCommentRepository.ImportTaskComments")
+	defer span.End()
+
+	if len(comments) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, 0, len(comments))
+	for _, comment := range comments {
+		if comment.ID.IsZero() {
+			comment.ID = primitive.NewObjectID()
+		}
+		if comment.Mentions == nil {
+			comment.Mentions = []string{}
+		}
+		if comment.History == nil {
+			comment.History = []model.TaskCommentRevision{}
+		}
+		documents = append(documents, comment)
+	}
+
+	_, err := tcr.getCollection().InsertMany(ctx, documents)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		tcr.logger.Printf("Failed to import task comments: %v", err)
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully imported task comments")
+	return nil
+}
+
+func (tcr *TaskCommentRepository) GetTaskCommentsByTaskID(ctx context.Context, taskID string) (model.TaskComments, error) {
+	ctx, span := tcr.tracer.Start(ctx, "TaskCommentRepository.GetTaskCommentsByTaskID")
+	defer span.End()
+
+	filter := bson.M{"task_id": taskID}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := tcr.getCollection().Find(ctx, filter, opts)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		tcr.logger.Printf("Failed to find task comments: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	comments := model.TaskComments{}
+	if err = cursor.All(ctx, &comments); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		tcr.logger.Printf("Failed to decode task comments: %v", err)
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched task comments")
+	return comments, nil
+}
+
+// DeleteByTaskID removes the comments of a purged task
+func (tcr *TaskCommentRepository) DeleteByTaskID(ctx context.Context, taskID string) error {
+	ctx, span := tcr.tracer.Start(ctx, "TaskCommentRepository.DeleteByTaskID")
+	defer span.End()
+
+	_, err := tcr.getCollection().DeleteMany(ctx, bson.M{"task_id": taskID})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		tcr.logger.Printf("Failed to delete task comments: %v", err)
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully deleted task comments")
+	return nil
+}
+
+func (tcr *TaskCommentRepository) GetTaskCommentByID(ctx context.Context, commentID primitive.ObjectID) (*model.TaskComment, error) {
+	ctx, span := tcr.tracer.Start(ctx, "TaskCommentRepository.GetTaskCommentByID")
+	defer span.End()
+
+	var comment model.TaskComment
+	err := tcr.getCollection().FindOne(ctx, bson.M{"_id": commentID}).Decode(&comment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			tcr.logger.Printf("Task comment with ID %s not found", commentID.Hex())
+			span.SetStatus(codes.Ok, "No comment found")
+			return nil, nil
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		tcr.logger.Printf("Failed to find task comment: %v", err)
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched task comment")
+	return &comment, nil
+}
+
+// UpdateTaskCommentBody replaces the body of a comment and appends the previous one to its edit history
+func (tcr *TaskCommentRepository) UpdateTaskCommentBody(ctx context.Context, comment *model.TaskComment, body string, mentions []string) error {
+	ctx, span := tcr.tracer.Start(ctx, "TaskCommentRepository.UpdateTaskCommentBody")
+	defer span.End()
+
+	now := time.Now()
+	revision := model.TaskCommentRevision{
+		Body:     comment.Body,
+		EditedAt: now,
+	}
+	if mentions == nil {
+		mentions = []string{}
+	}
+
+	filter := bson.M{"_id": comment.ID}
+	update := bson.M{
+		"$set": bson.M{
+			"body":      body,
+			"mentions":  mentions,
+			"edited_at": now,
+		},
+		"$push": bson.M{
+			"history": revision,
+		},
+	}
+
+	_, err := tcr.getCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		tcr.logger.Printf("Failed to update task comment: %v", err)
+		return err
+	}
+
+	comment.History = append(comment.History, revision)
+	comment.Body = body
+	comment.Mentions = mentions
+	comment.EditedAt = &now
+	span.SetStatus(codes.Ok, "Successfully updated task comment")
+	return nil
+}
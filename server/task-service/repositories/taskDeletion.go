@@ -0,0 +1,346 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// MarkDeleted soft deletes a task. It reports false when the task does not exist or is
+// already being deleted.
+func (tr *TaskRepository) MarkDeleted(ctx context.Context, taskID primitive.ObjectID, userID string) (bool, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.MarkDeleted")
+	defer span.End()
+
+	now := time.Now()
+	result, err := tr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": taskID, "pending_deletion": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{
+			"pending_deletion": true,
+			"deleted_at":       now,
+			"deleted_by":       userID,
+			"updated_at":       now,
+		}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to mark task %s as deleted: %v", taskID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully marked task as deleted")
+	return result.ModifiedCount == 1, nil
+}
+
+// UnmarkDeleted reverts MarkDeleted and takes the task out of the trash
+func (tr *TaskRepository) UnmarkDeleted(ctx context.Context, taskID primitive.ObjectID) error {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.UnmarkDeleted")
+	defer span.End()
+
+	_, err := tr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": taskID},
+		bson.M{
+			"$set":   bson.M{"pending_deletion": false, "updated_at": time.Now()},
+			"$unset": bson.M{"deleted_at": "", "deleted_by": "", "trashed": "", "dependency_of": "", "restoring_since": "", "saga_retries": ""},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to restore task %s: %v", taskID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully restored task")
+	return nil
+}
+
+// MoveToTrash finishes the deletion of a task. The tasks that listed it among their
+// dependencies are kept so that a restore can link them again.
+func (tr *TaskRepository) MoveToTrash(ctx context.Context, taskID primitive.ObjectID, dependencyOf []string) error {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.MoveToTrash")
+	defer span.End()
+
+	_, err := tr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": taskID, "pending_deletion": true},
+		bson.M{
+			"$set":   bson.M{"trashed": true, "dependency_of": dependencyOf},
+			"$unset": bson.M{"saga_retries": ""},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to move task %s to trash: %v", taskID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully moved task to trash")
+	return nil
+}
+
+// SetTrashed flips the trash flag of a deleted task. A restore clears it and records when it
+// started while it waits for workflow-service, so it reports false when the flag already had
+// the other value.
+func (tr *TaskRepository) SetTrashed(ctx context.Context, taskID primitive.ObjectID, trashed bool) (bool, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.SetTrashed")
+	defer span.End()
+
+	current := bson.M{"$ne": true}
+	if !trashed {
+		current = bson.M{"$eq": true}
+	}
+	update := bson.M{
+		"$set":   bson.M{"trashed": trashed},
+		"$unset": bson.M{"saga_retries": "", "restoring_since": ""},
+	}
+	if !trashed {
+		update["$set"] = bson.M{"trashed": false, "restoring_since": time.Now()}
+		update["$unset"] = bson.M{"saga_retries": ""}
+	}
+	result, err := tr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": taskID, "pending_deletion": true, "trashed": current},
+		update,
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to update trash flag of task %s: %v", taskID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully updated trash flag")
+	return result.ModifiedCount == 1, nil
+}
+
+// FindStuckInSaga returns up to limit tasks whose deletion or restore started before cutoff
+// and has not finished, a deletion counts from deleted_at and a restore from
+// restoring_since. Tasks of a deleted project have neither and are left to project-service.
+func (tr *TaskRepository) FindStuckInSaga(ctx context.Context, cutoff time.Time, limit int64) ([]model.Task, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.FindStuckInSaga")
+	defer span.End()
+
+	tasks := []model.Task{}
+	cursor, err := tr.getCollection().Find(ctx,
+		bson.M{
+			"pending_deletion": true,
+			"trashed":          bson.M{"$ne": true},
+			"$or": bson.A{
+				bson.M{"restoring_since": bson.M{"$lt": cutoff}},
+				bson.M{"restoring_since": bson.M{"$exists": false}, "deleted_at": bson.M{"$lt": cutoff}},
+			},
+		},
+		options.Find().SetLimit(limit),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got tasks stuck in deletion or restore")
+	return tasks, nil
+}
+
+// CountSagaRetry records that the deletion or restore of a task was started again
+func (tr *TaskRepository) CountSagaRetry(ctx context.Context, taskID primitive.ObjectID) error {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.CountSagaRetry")
+	defer span.End()
+
+	_, err := tr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": taskID, "pending_deletion": true, "trashed": bson.M{"$ne": true}},
+		bson.M{"$inc": bson.M{"saga_retries": 1}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to count retry of task %s: %v", taskID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully counted retry")
+	return nil
+}
+
+// RemoveDependencyReferences drops a task from the dependencies of every other task and
+// returns the IDs of the tasks it was dropped from
+func (tr *TaskRepository) RemoveDependencyReferences(ctx context.Context, taskID string) ([]string, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.RemoveDependencyReferences")
+	defer span.End()
+
+	cursor, err := tr.getCollection().Find(ctx, bson.M{"dependencies": taskID}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to find tasks depending on %s: %v", taskID, err)
+	}
+	var referencing []model.Task
+	if err = cursor.All(ctx, &referencing); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to decode tasks depending on %s: %v", taskID, err)
+	}
+	ids := make([]string, 0, len(referencing))
+	for _, task := range referencing {
+		ids = append(ids, task.ID.Hex())
+	}
+	if len(ids) == 0 {
+		span.SetStatus(codes.Ok, "Task is not a dependency of other tasks")
+		return ids, nil
+	}
+
+	_, err = tr.getCollection().UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": toObjectIDs(ids)}},
+		bson.M{
+			"$pull": bson.M{"dependencies": taskID},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to remove task %s from dependencies: %v", taskID, err)
+	}
+	span.SetStatus(codes.Ok, "Successfully removed task from dependencies")
+	return ids, nil
+}
+
+// RestoreDependencyReferences adds a restored task back to the dependencies of the tasks
+// that listed it before it was deleted, as long as they still exist
+func (tr *TaskRepository) RestoreDependencyReferences(ctx context.Context, taskID string, dependencyOf []string) error {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.RestoreDependencyReferences")
+	defer span.End()
+
+	if len(dependencyOf) == 0 {
+		span.SetStatus(codes.Ok, "No dependencies to restore")
+		return nil
+	}
+	_, err := tr.getCollection().UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": toObjectIDs(dependencyOf)}, "pending_deletion": bson.M{"$ne": true}},
+		bson.M{
+			"$addToSet": bson.M{"dependencies": taskID},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to restore dependencies of task %s: %v", taskID, err)
+	}
+	span.SetStatus(codes.Ok, "Successfully restored dependencies")
+	return nil
+}
+
+// FindTrash returns the deleted tasks of a project, most recently deleted first
+func (tr *TaskRepository) FindTrash(ctx context.Context, projectID string) ([]model.Task, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.FindTrash")
+	defer span.End()
+
+	tasks := []model.Task{}
+	cursor, err := tr.getCollection().Find(ctx,
+		bson.M{"project_id": projectID, "trashed": true},
+		options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}}),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got trashed tasks")
+	return tasks, nil
+}
+
+// FindTrashedBefore returns up to limit tasks that were moved to the trash before cutoff
+func (tr *TaskRepository) FindTrashedBefore(ctx context.Context, cutoff time.Time, limit int64) ([]model.Task, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.FindTrashedBefore")
+	defer span.End()
+
+	tasks := []model.Task{}
+	cursor, err := tr.getCollection().Find(ctx,
+		bson.M{"trashed": true, "deleted_at": bson.M{"$lt": cutoff}},
+		options.Find().SetLimit(limit),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got expired trashed tasks")
+	return tasks, nil
+}
+
+// RecomputeBlocked derives the blocked flag of the given tasks from the tasks they wait for:
+// a task is blocked while another unfinished task lists it among its dependencies. The IDs
+// of the tasks that are not blocked are returned.
+func (tr *TaskRepository) RecomputeBlocked(ctx context.Context, taskIDs []string) ([]string, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.RecomputeBlocked")
+	defer span.End()
+
+	if len(taskIDs) == 0 {
+		span.SetStatus(codes.Ok, "No tasks to recompute")
+		return nil, nil
+	}
+
+	cursor, err := tr.getCollection().Find(ctx,
+		bson.M{
+			"dependencies":     bson.M{"$in": taskIDs},
+			"status":           bson.M{"$ne": model.Completed},
+			"pending_deletion": bson.M{"$ne": true},
+		},
+		options.Find().SetProjection(bson.M{"dependencies": 1}),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to find blocking tasks: %v", err)
+	}
+	var blockers []model.Task
+	if err = cursor.All(ctx, &blockers); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to decode blocking tasks: %v", err)
+	}
+
+	stillBlocked := map[string]bool{}
+	for _, blocker := range blockers {
+		for _, dependent := range blocker.Dependencies {
+			stillBlocked[dependent] = true
+		}
+	}
+	var unblocked, blocked []string
+	for _, taskID := range taskIDs {
+		if stillBlocked[taskID] {
+			blocked = append(blocked, taskID)
+		} else {
+			unblocked = append(unblocked, taskID)
+		}
+	}
+
+	for flag, ids := range map[bool][]string{false: unblocked, true: blocked} {
+		if len(ids) == 0 {
+			continue
+		}
+		if _, err := tr.getCollection().UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": toObjectIDs(ids)}, "blocked": bson.M{"$ne": flag}},
+			bson.M{"$set": bson.M{"blocked": flag, "updated_at": time.Now()}},
+		); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, fmt.Errorf("failed to update blocked flags: %v", err)
+		}
+	}
+	span.SetStatus(codes.Ok, "Successfully recomputed blocked flags")
+	return unblocked, nil
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SetPreview records the preview of a stored file in every version of the document that
+// shares it, and in the document when it is the current file
+func (tdr *TaskDocumentRepository) SetPreview(ctx context.Context, docID primitive.ObjectID, filePath string, status model.PreviewStatus, previewPath string) error {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.SetPreview")
+	defer span.End()
+
+	_, err := tdr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": docID, "versions.file_path": filePath},
+		bson.M{"$set": bson.M{
+			"versions.$[version].preview":      status,
+			"versions.$[version].preview_path": previewPath,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"version.file_path": filePath}},
+		}),
+	)
+	if err == nil {
+		_, err = tdr.getCollection().UpdateOne(ctx,
+			bson.M{"_id": docID, "file_path": filePath},
+			bson.M{"$set": bson.M{"preview": status}},
+		)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to set preview of task document %s: %v", docID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully set preview")
+	return nil
+}
+
+// FindPendingPreviews returns up to limit documents with a version uploaded before cutoff
+// whose file may be shown but still has no preview
+func (tdr *TaskDocumentRepository) FindPendingPreviews(ctx context.Context, cutoff time.Time, limit int64) ([]model.TaskDocument, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.FindPendingPreviews")
+	defer span.End()
+
+	documents := []model.TaskDocument{}
+	cursor, err := tdr.getCollection().Find(ctx,
+		bson.M{"versions": bson.M{"$elemMatch": bson.M{
+			"preview":     model.PreviewPending,
+			"status":      bson.M{"$in": bson.A{nil, model.DocumentClean}},
+			"uploaded_at": bson.M{"$lt": primitive.NewDateTimeFromTime(cutoff)},
+		}}},
+		options.Find().SetLimit(limit),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &documents); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got documents waiting for a preview")
+	return documents, nil
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -17,6 +18,10 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrDocumentNameTaken means the task already has a document with the file name, which
+// another upload created in the meantime
+var ErrDocumentNameTaken = errors.New("task already has a document with this name")
+
 type TaskDocumentRepository struct {
 	cli    *mongo.Client
 	logger *log.Logger
@@ -40,11 +45,38 @@ func NewTaskDocumentRepository(ctx context.Context, logger *log.Logger, tracer t
 
 	logger.Println("Successfully connected to MongoDB")
 
-	return &TaskDocumentRepository{
+	repo := &TaskDocumentRepository{
 		cli:    client,
 		logger: logger,
 		tracer: tracer,
-	}, nil
+	}
+	repo.createIndexes(ctx)
+	return repo, nil
+}
+
+// createIndexes keeps a single live document per file name of a task, so that concurrent first
+// uploads of a name end up as versions of one document. Documents stored before the deleted
+// flag was always written get it first, the index only covers documents with deleted false.
+// Tasks that already have such duplicates keep working without the index, which is logged.
+func (tdr *TaskDocumentRepository) createIndexes(ctx context.Context) {
+	_, err := tdr.getCollection().UpdateMany(ctx,
+		bson.M{"deleted": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deleted": false}},
+	)
+	if err != nil {
+		tdr.logger.Printf("Failed to backfill deleted flag of task documents: %v", err)
+		return
+	}
+	_, err = tdr.getCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "file_name", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"deleted": false}).
+			SetName("unique_live_file_name"),
+	})
+	if err != nil {
+		tdr.logger.Printf("Failed to create task document indexes, concurrent uploads of a name may create separate documents: %v", err)
+	}
 }
 
 func (tdr *TaskDocumentRepository) Disconnect(ctx context.Context) error {
@@ -75,6 +107,10 @@ func (tdr *TaskDocumentRepository) SaveTaskDocument(ctx context.Context, doc *mo
 	collection := tdr.getCollection()
 
 	_, err := collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		span.SetStatus(codes.Error, ErrDocumentNameTaken.Error())
+		return ErrDocumentNameTaken
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -90,7 +126,7 @@ func (tdr *TaskDocumentRepository) GetTaskDocumentsByTaskID(ctx context.Context,
 	defer span.End()
 
 	collection := tdr.getCollection()
-	filter := bson.M{"task_id": taskID}
+	filter := bson.M{"task_id": taskID, "deleted": bson.M{"$ne": true}}
 
 	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
@@ -130,12 +166,87 @@ func (tdr *TaskDocumentRepository) DeleteTaskDocument(ctx context.Context, docID
 	return nil
 }
 
+// SetDeletedByTaskID hides or shows again the documents of a task that is being deleted
+func (tdr *TaskDocumentRepository) SetDeletedByTaskID(ctx context.Context, taskID string, deleted bool) (int64, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.SetDeletedByTaskID")
+	defer span.End()
+
+	result, err := tdr.getCollection().UpdateMany(ctx, bson.M{"task_id": taskID}, bson.M{"$set": bson.M{"deleted": deleted}})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		tdr.logger.Printf("Failed to update deleted flag of task documents: %v", err)
+		return 0, err
+	}
+	span.SetStatus(codes.Ok, "Successfully updated deleted flag of task documents")
+	return result.ModifiedCount, nil
+}
+
+// GetAllByTaskID returns every document of a task, including the ones hidden by a deletion
+func (tdr *TaskDocumentRepository) GetAllByTaskID(ctx context.Context, taskID string) ([]model.TaskDocument, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.GetAllByTaskID")
+	defer span.End()
+
+	documents := []model.TaskDocument{}
+	cursor, err := tdr.getCollection().Find(ctx, bson.M{"task_id": taskID})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &documents); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched all task documents")
+	return documents, nil
+}
+
+// AllDocuments returns every document, including the ones hidden by a deletion, without
+// their extracted text
+func (tdr *TaskDocumentRepository) AllDocuments(ctx context.Context) ([]model.TaskDocument, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.AllDocuments")
+	defer span.End()
+
+	documents := []model.TaskDocument{}
+	cursor, err := tdr.getCollection().Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"text": 0}))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &documents); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched all documents")
+	return documents, nil
+}
+
+// DeleteByTaskID removes the documents of a purged task
+func (tdr *TaskDocumentRepository) DeleteByTaskID(ctx context.Context, taskID string) error {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.DeleteByTaskID")
+	defer span.End()
+
+	_, err := tdr.getCollection().DeleteMany(ctx, bson.M{"task_id": taskID})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		tdr.logger.Printf("Failed to delete task documents: %v", err)
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully deleted task documents")
+	return nil
+}
+
 func (tdr *TaskDocumentRepository) GetTaskDocumentByID(ctx context.Context, docID primitive.ObjectID) (*model.TaskDocument, error) {
 	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.GetTaskDocumentByID")
 	defer span.End()
 
 	collection := tdr.getCollection()
-	filter := bson.M{"_id": docID}
+	filter := bson.M{"_id": docID, "deleted": bson.M{"$ne": true}}
 
 	var document model.TaskDocument
 	err := collection.FindOne(ctx, filter).Decode(&document)
@@ -153,3 +264,82 @@ func (tdr *TaskDocumentRepository) GetTaskDocumentByID(ctx context.Context, docI
 	span.SetStatus(codes.Ok, "Successfully fetched task document")
 	return &document, nil
 }
+
+// GetByTaskAndName returns the document of a task with the given file name, or nil when the
+// task has none. The name of a document is the name of its first version.
+func (tdr *TaskDocumentRepository) GetByTaskAndName(ctx context.Context, taskID string, fileName string) (*model.TaskDocument, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.GetByTaskAndName")
+	defer span.End()
+
+	var document model.TaskDocument
+	err := tdr.getCollection().FindOne(ctx,
+		bson.M{"task_id": taskID, "file_name": fileName, "deleted": bson.M{"$ne": true}},
+		options.FindOne().SetSort(bson.D{{Key: "uploaded_at", Value: -1}}),
+	).Decode(&document)
+	if err == mongo.ErrNoDocuments {
+		span.SetStatus(codes.Ok, "No document found")
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to find task document: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched task document")
+	return &document, nil
+}
+
+// AddVersion makes version the current version of doc, numbered after the last one, and
+// updates doc to match. It reports false when doc changed since it was read, in which case
+// the caller reads it again and retries.
+func (tdr *TaskDocumentRepository) AddVersion(ctx context.Context, doc *model.TaskDocument, version model.DocumentVersion) (bool, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.AddVersion")
+	defer span.End()
+
+	versions := doc.AllVersions()
+	version.Number = versions[len(versions)-1].Number + 1
+	versions = append(versions[:len(versions):len(versions)], version)
+
+	filter := bson.M{"_id": doc.ID, "deleted": bson.M{"$ne": true}, "version": doc.Version}
+	if doc.Version == 0 {
+		// documents uploaded before versioning have no version yet
+		filter["version"] = bson.M{"$in": bson.A{nil, 0}}
+	}
+	result, err := tdr.getCollection().UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"file_type":   version.FileType,
+		"file_path":   version.FilePath,
+		"size":        version.Size,
+		"sha256":      version.SHA256,
+		"uploaded_at": version.UploadedAt,
+		"uploaded_by": version.UploadedBy,
+		"status":      version.Status,
+		"preview":     version.Preview,
+		"text_status": model.TextPending,
+		"version":     version.Number,
+		"versions":    versions,
+	}, "$unset": bson.M{"text": ""}})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to add version to task document %s: %v", doc.ID.Hex(), err)
+	}
+	if result.ModifiedCount != 1 {
+		span.SetStatus(codes.Ok, "Task document changed concurrently")
+		return false, nil
+	}
+
+	doc.FileType = version.FileType
+	doc.FilePath = version.FilePath
+	doc.Size = version.Size
+	doc.SHA256 = version.SHA256
+	doc.UploadedAt = version.UploadedAt
+	doc.UploadedBy = version.UploadedBy
+	doc.Status = version.Status
+	doc.Preview = version.Preview
+	doc.TextStatus = model.TextPending
+	doc.Text = ""
+	doc.Version = version.Number
+	doc.Versions = versions
+	span.SetStatus(codes.Ok, "Successfully added task document version")
+	return true, nil
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SetScanResult records the verdict on a stored file in every version of the document that
+// shares it, and in the document when it is the current file. A quarantined file is moved, so
+// newPath replaces the path of the file when it is not empty.
+func (tdr *TaskDocumentRepository) SetScanResult(ctx context.Context, docID primitive.ObjectID, filePath string, status model.DocumentStatus, signature string, newPath string) error {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.SetScanResult")
+	defer span.End()
+
+	if newPath == "" {
+		newPath = filePath
+	}
+	_, err := tdr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": docID, "versions.file_path": filePath},
+		bson.M{"$set": bson.M{
+			"versions.$[version].status":    status,
+			"versions.$[version].signature": signature,
+			"versions.$[version].file_path": newPath,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"version.file_path": filePath}},
+		}),
+	)
+	if err == nil {
+		_, err = tdr.getCollection().UpdateOne(ctx,
+			bson.M{"_id": docID, "file_path": filePath},
+			bson.M{"$set": bson.M{"status": status, "file_path": newPath}},
+		)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to set scan result of task document %s: %v", docID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully set scan result")
+	return nil
+}
+
+// FindPendingScans returns up to limit documents with a version uploaded before cutoff that
+// is still waiting for its scan, the ones the sweep tried longest ago, or never, first
+func (tdr *TaskDocumentRepository) FindPendingScans(ctx context.Context, cutoff time.Time, limit int64) ([]model.TaskDocument, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.FindPendingScans")
+	defer span.End()
+
+	documents := []model.TaskDocument{}
+	cursor, err := tdr.getCollection().Find(ctx,
+		bson.M{"versions": bson.M{"$elemMatch": bson.M{
+			"status":      model.DocumentPending,
+			"uploaded_at": bson.M{"$lt": primitive.NewDateTimeFromTime(cutoff)},
+		}}},
+		options.Find().SetSort(bson.D{{Key: "scan_attempted_at", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &documents); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got documents waiting for a scan")
+	return documents, nil
+}
+
+// RecordScanAttempt counts a sweep that tries to scan the pending files of a document
+func (tdr *TaskDocumentRepository) RecordScanAttempt(ctx context.Context, docID primitive.ObjectID) error {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.RecordScanAttempt")
+	defer span.End()
+
+	_, err := tdr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": docID},
+		bson.M{
+			"$set": bson.M{"scan_attempted_at": time.Now()},
+			"$inc": bson.M{"scan_attempts": 1},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to record scan attempt of task document %s: %v", docID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully recorded scan attempt")
+	return nil
+}
+
+func (tdr *TaskDocumentRepository) getPolicyCollection() *mongo.Collection {
+	taskDatabase := tdr.cli.Database("mongoTask")
+	policiesCollection := taskDatabase.Collection("document_policies")
+	return policiesCollection
+}
+
+// GetPolicy returns the document policy of a project, or nil when the project has none
+func (tdr *TaskDocumentRepository) GetPolicy(ctx context.Context, projectID string) (*model.DocumentPolicy, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.GetPolicy")
+	defer span.End()
+
+	var policy model.DocumentPolicy
+	err := tdr.getPolicyCollection().FindOne(ctx, bson.M{"project_id": projectID}).Decode(&policy)
+	if err == mongo.ErrNoDocuments {
+		span.SetStatus(codes.Ok, "Project has no document policy")
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to get document policy: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully got document policy")
+	return &policy, nil
+}
+
+// SavePolicy replaces the document policy of a project
+func (tdr *TaskDocumentRepository) SavePolicy(ctx context.Context, policy *model.DocumentPolicy) error {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.SavePolicy")
+	defer span.End()
+
+	policy.UpdatedAt = time.Now()
+	_, err := tdr.getPolicyCollection().ReplaceOne(ctx,
+		bson.M{"project_id": policy.ProjectID},
+		policy,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to save document policy: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully saved document policy")
+	return nil
+}
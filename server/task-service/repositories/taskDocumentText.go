@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SetText records the text extracted from a stored file. It reports false when the file is
+// no longer the current file of the document or its text was recorded already.
+func (tdr *TaskDocumentRepository) SetText(ctx context.Context, docID primitive.ObjectID, filePath string, status model.TextStatus, text string) (bool, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.SetText")
+	defer span.End()
+
+	result, err := tdr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": docID, "file_path": filePath, "text_status": model.TextPending},
+		bson.M{"$set": bson.M{"text_status": status, "text": text}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to set text of task document %s: %v", docID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully set text")
+	return result.ModifiedCount > 0, nil
+}
+
+// FindPendingTexts returns up to limit documents whose current file was uploaded before
+// cutoff, may be downloaded and still has no text extracted
+func (tdr *TaskDocumentRepository) FindPendingTexts(ctx context.Context, cutoff time.Time, limit int64) ([]model.TaskDocument, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.FindPendingTexts")
+	defer span.End()
+
+	documents := []model.TaskDocument{}
+	cursor, err := tdr.getCollection().Find(ctx,
+		bson.M{
+			"text_status": model.TextPending,
+			"status":      bson.M{"$in": bson.A{nil, model.DocumentClean}},
+			"uploaded_at": bson.M{"$lt": primitive.NewDateTimeFromTime(cutoff)},
+			"deleted":     bson.M{"$ne": true},
+		},
+		options.Find().SetLimit(limit),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &documents); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got documents waiting for their text")
+	return documents, nil
+}
@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func (tdr *TaskDocumentRepository) getUsageCollection() *mongo.Collection {
+	taskDatabase := tdr.cli.Database("mongoTask")
+	usageCollection := taskDatabase.Collection("storage_usage")
+	return usageCollection
+}
+
+// GetStorageUsage returns the storage usage of a project, which is empty for a project that
+// never stored a document
+func (tdr *TaskDocumentRepository) GetStorageUsage(ctx context.Context, projectID string) (*model.StorageUsage, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.GetStorageUsage")
+	defer span.End()
+
+	usage := model.StorageUsage{ProjectID: projectID, Users: map[string]int64{}}
+	err := tdr.getUsageCollection().FindOne(ctx, bson.M{"project_id": projectID}).Decode(&usage)
+	if err != nil && err != mongo.ErrNoDocuments {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to get storage usage: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully got storage usage")
+	return &usage, nil
+}
+
+// GetAllStorageUsage returns the usage of every project that has one, keyed by project
+func (tdr *TaskDocumentRepository) GetAllStorageUsage(ctx context.Context) (map[string]model.StorageUsage, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.GetAllStorageUsage")
+	defer span.End()
+
+	cursor, err := tdr.getUsageCollection().Find(ctx, bson.M{})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to get storage usage: %v", err)
+	}
+	var usages []model.StorageUsage
+	if err = cursor.All(ctx, &usages); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to decode storage usage: %v", err)
+	}
+	usageByProject := map[string]model.StorageUsage{}
+	for _, usage := range usages {
+		usageByProject[usage.ProjectID] = usage
+	}
+	span.SetStatus(codes.Ok, "Successfully got storage usage")
+	return usageByProject, nil
+}
+
+// SetBackfilledStorageUsage replaces the usage of a project by one counted from its documents
+// and marks it as backfilled. previous is the usage the count started from, nil when the
+// project had none, and the usage is only replaced when it did not change since; it reports
+// false otherwise.
+func (tdr *TaskDocumentRepository) SetBackfilledStorageUsage(ctx context.Context, usage *model.StorageUsage, previous *model.StorageUsage) (bool, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.SetBackfilledStorageUsage")
+	defer span.End()
+
+	fields := bson.M{
+		"project_id": usage.ProjectID,
+		"bytes":      usage.Bytes,
+		"users":      usage.Users,
+		"backfilled": true,
+		"updated_at": time.Now(),
+	}
+	var result *mongo.UpdateResult
+	var err error
+	if previous == nil {
+		result, err = tdr.getUsageCollection().UpdateOne(ctx,
+			bson.M{"project_id": usage.ProjectID},
+			bson.M{"$setOnInsert": fields},
+			options.Update().SetUpsert(true),
+		)
+	} else {
+		filter := bson.M{"project_id": usage.ProjectID, "bytes": previous.Bytes, "backfilled": bson.M{"$ne": true}}
+		if previous.UpdatedAt.IsZero() {
+			filter["updated_at"] = bson.M{"$exists": false}
+		} else {
+			filter["updated_at"] = previous.UpdatedAt
+		}
+		result, err = tdr.getUsageCollection().UpdateOne(ctx, filter, bson.M{"$set": fields})
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to backfill storage usage: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully backfilled storage usage")
+	return result.UpsertedCount == 1 || result.ModifiedCount == 1, nil
+}
+
+// ReserveStorage adds size bytes to the usage of a project and of the user who uploaded them,
+// unless the project would use more than quota bytes then. It returns the usage after the
+// change, or nil when the quota does not allow it.
+func (tdr *TaskDocumentRepository) ReserveStorage(ctx context.Context, projectID string, userID string, size int64, quota int64) (*model.StorageUsage, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.ReserveStorage")
+	defer span.End()
+
+	// the project needs a usage document for the conditional update to match
+	_, err := tdr.getUsageCollection().UpdateOne(ctx,
+		bson.M{"project_id": projectID},
+		bson.M{"$setOnInsert": bson.M{"project_id": projectID, "bytes": int64(0), "users": bson.M{}}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to reserve storage: %v", err)
+	}
+
+	increments := bson.M{"bytes": size}
+	if userID != "" {
+		increments["users."+userID] = size
+	}
+	var usage model.StorageUsage
+	err = tdr.getUsageCollection().FindOneAndUpdate(ctx,
+		bson.M{"project_id": projectID, "bytes": bson.M{"$lte": quota - size}},
+		bson.M{"$inc": increments, "$set": bson.M{"updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&usage)
+	if err == mongo.ErrNoDocuments {
+		span.SetStatus(codes.Ok, "Storage quota exceeded")
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to reserve storage: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully reserved storage")
+	return &usage, nil
+}
+
+// ReleaseStorage takes size bytes off the usage of a project and of the user who uploaded
+// them and returns the usage after the change. Documents stored before their usage was
+// counted would take off more than was added, so the usage does not go below zero.
+func (tdr *TaskDocumentRepository) ReleaseStorage(ctx context.Context, projectID string, userID string, size int64) (*model.StorageUsage, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.ReleaseStorage")
+	defer span.End()
+
+	decrement := func(field string) bson.M {
+		return bson.M{"$max": bson.A{int64(0), bson.M{"$subtract": bson.A{bson.M{"$ifNull": bson.A{"$" + field, int64(0)}}, size}}}}
+	}
+	fields := bson.M{"bytes": decrement("bytes"), "updated_at": time.Now()}
+	if userID != "" {
+		fields["users."+userID] = decrement("users." + userID)
+	}
+	var usage model.StorageUsage
+	err := tdr.getUsageCollection().FindOneAndUpdate(ctx,
+		bson.M{"project_id": projectID},
+		mongo.Pipeline{{{Key: "$set", Value: fields}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&usage)
+	if err == mongo.ErrNoDocuments {
+		span.SetStatus(codes.Ok, "Project has no storage usage")
+		return &model.StorageUsage{ProjectID: projectID, Users: map[string]int64{}}, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to release storage: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully released storage")
+	return &usage, nil
+}
+
+// SetNotifiedPercent moves the quota threshold the manager of a project was told about from
+// one value to another. It reports false when another change moved it first.
+func (tdr *TaskDocumentRepository) SetNotifiedPercent(ctx context.Context, projectID string, from int, to int) (bool, error) {
+	ctx, span := tdr.tracer.Start(ctx, "TaskDocumentRepository.SetNotifiedPercent")
+	defer span.End()
+
+	filter := bson.M{"project_id": projectID, "notified_percent": from}
+	if from == 0 {
+		filter["notified_percent"] = bson.M{"$in": bson.A{nil, 0}}
+	}
+	result, err := tdr.getUsageCollection().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"notified_percent": to}})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to set notified storage threshold: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully set notified storage threshold")
+	return result.MatchedCount > 0, nil
+}
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// UpdateDueDate sets or clears the due date of a task. The reminders and the overdue flag of
+// the previous due date no longer apply, so they are reset.
+func (tr *TaskRepository) UpdateDueDate(ctx context.Context, task *model.Task, dueDate *time.Time) error {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.UpdateDueDate")
+	defer span.End()
+
+	now := time.Now()
+	update := bson.M{
+		"$set":   bson.M{"due_date": dueDate, "updated_at": now},
+		"$unset": bson.M{"overdue": "", "reminders_sent": ""},
+	}
+	if dueDate == nil {
+		update["$unset"] = bson.M{"due_date": "", "overdue": "", "reminders_sent": ""}
+		update["$set"] = bson.M{"updated_at": now}
+	}
+
+	_, err := tr.getCollection().UpdateOne(ctx, bson.M{"_id": task.ID}, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to update due date of task %s: %v", task.ID.Hex(), err)
+	}
+	task.DueDate = dueDate
+	task.Overdue = false
+	task.RemindersSent = nil
+	task.UpdatedAt = now
+	span.SetStatus(codes.Ok, "Successfully updated due date")
+	return nil
+}
+
+// dueTaskFilter matches the tasks that are still being worked on
+func dueTaskFilter(dueDate bson.M) bson.M {
+	return bson.M{
+		"due_date":         dueDate,
+		"status":           bson.M{"$ne": model.Completed},
+		"pending_deletion": bson.M{"$ne": true},
+	}
+}
+
+// FindDueBetween returns up to limit open tasks that are due after from and no later than to,
+// ordered by due date. A window with more tasks is read page by page, passing the last task
+// of a page as after to get the next one.
+func (tr *TaskRepository) FindDueBetween(ctx context.Context, from, to time.Time, after *model.Task, limit int64) ([]model.Task, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.FindDueBetween")
+	defer span.End()
+
+	filter := dueTaskFilter(bson.M{"$gt": from, "$lte": to})
+	if after != nil && after.DueDate != nil {
+		filter["$or"] = bson.A{
+			bson.M{"due_date": bson.M{"$gt": *after.DueDate}},
+			bson.M{"due_date": *after.DueDate, "_id": bson.M{"$gt": after.ID}},
+		}
+	}
+
+	tasks := []model.Task{}
+	cursor, err := tr.getCollection().Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got tasks due soon")
+	return tasks, nil
+}
+
+// FindNewlyOverdue returns up to limit open tasks that are past their due date but not
+// marked as overdue yet
+func (tr *TaskRepository) FindNewlyOverdue(ctx context.Context, now time.Time, limit int64) ([]model.Task, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.FindNewlyOverdue")
+	defer span.End()
+
+	filter := dueTaskFilter(bson.M{"$lte": now})
+	filter["overdue"] = bson.M{"$ne": true}
+
+	tasks := []model.Task{}
+	cursor, err := tr.getCollection().Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got overdue tasks")
+	return tasks, nil
+}
+
+// MarkRemindersSent records reminders before they are sent. It reports false when one of
+// them was already recorded, by an earlier run or by another replica, so nothing is sent twice.
+func (tr *TaskRepository) MarkRemindersSent(ctx context.Context, taskID primitive.ObjectID, keys []string) (bool, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.MarkRemindersSent")
+	defer span.End()
+
+	result, err := tr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": taskID, "reminders_sent": bson.M{"$nin": keys}},
+		bson.M{"$addToSet": bson.M{"reminders_sent": bson.M{"$each": keys}}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to record reminders of task %s: %v", taskID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully recorded reminders")
+	return result.ModifiedCount == 1, nil
+}
+
+// MarkOverdue flags a task as overdue. It reports false when the task was already flagged.
+func (tr *TaskRepository) MarkOverdue(ctx context.Context, taskID primitive.ObjectID) (bool, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.MarkOverdue")
+	defer span.End()
+
+	result, err := tr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": taskID, "overdue": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"overdue": true}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to mark task %s as overdue: %v", taskID.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully marked task as overdue")
+	return result.ModifiedCount == 1, nil
+}
+
+// FindWithDueDate returns the tasks with a due date of a project, or the ones assigned to a
+// user when projectID is empty
+func (tr *TaskRepository) FindWithDueDate(ctx context.Context, userID, projectID string) ([]model.Task, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.FindWithDueDate")
+	defer span.End()
+
+	filter := bson.M{"due_date": bson.M{"$exists": true}, "pending_deletion": bson.M{"$ne": true}}
+	if projectID != "" {
+		filter["project_id"] = projectID
+	} else {
+		filter["user_ids"] = userID
+	}
+
+	tasks := []model.Task{}
+	cursor, err := tr.getCollection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "due_date", Value: 1}}))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got tasks with due dates")
+	return tasks, nil
+}
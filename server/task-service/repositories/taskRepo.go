@@ -13,10 +13,14 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"log"
 	"os"
+	"slices"
 	"task--service/model"
 	"time"
 )
 
+// ErrOccurrenceExists is returned when the task for an occurrence of a recurring series was already created
+var ErrOccurrenceExists = errors.New("task for this occurrence already exists")
+
 type TaskRepository struct {
 	cli    *mongo.Client
 	logger *log.Logger
@@ -40,11 +44,38 @@ func New(ctx context.Context, logger *log.Logger, trace trace.Tracer) (*TaskRepo
 
 	logger.Println("Successfully connected to MongoDB")
 
-	return &TaskRepository{
+	repo := &TaskRepository{
 		cli:    client,
 		logger: logger,
 		tracer: trace,
-	}, nil
+	}
+	if err := repo.createIndexes(ctx); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (tr *TaskRepository) createIndexes(ctx context.Context) error {
+	_, err := tr.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// one task per occurrence of a recurring series, no matter how many replicas race for it
+			Keys: bson.D{{Key: "recurrence_key", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"recurrence_key": bson.M{"$exists": true}}).
+				SetName("unique_recurrence_key"),
+		},
+		// search and listing within a project, see TaskQuery
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "labels", Value: 1}}},
+		{Keys: bson.D{{Key: "user_ids", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create task indexes: %w", err)
+	}
+	return nil
 }
 
 func (tr *TaskRepository) Disconnect(ctx context.Context) error {
@@ -91,6 +122,14 @@ func (tr *TaskRepository) Insert(ctx context.Context, task *model.Task) error {
 		task.UserIDs = []string{}
 	}
 
+	if task.Labels == nil {
+		task.Labels = []string{}
+	}
+
+	if task.CustomFields == nil {
+		task.CustomFields = map[string]interface{}{}
+	}
+
 	if task.Status == "" {
 		task.Status = model.Pending
 	}
@@ -100,18 +139,22 @@ func (tr *TaskRepository) Insert(ctx context.Context, task *model.Task) error {
 
 	insertOneCtx, insertOneSpan := tr.tracer.Start(ctx, "TaskRepository.Insert.InsertOne")
 	result, err := tasksCollection.InsertOne(insertOneCtx, task)
-	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
-		task.ID = oid
-	} else {
-		tr.logger.Println("Failed to convert InsertedID to ObjectID")
-	}
 	insertOneSpan.End()
 	if err != nil {
+		if task.RecurrenceKey != "" && mongo.IsDuplicateKeyError(err) {
+			span.SetStatus(codes.Ok, "Occurrence already created")
+			return ErrOccurrenceExists
+		}
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		tr.logger.Println(err)
 		return err
 	}
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		task.ID = oid
+	} else {
+		tr.logger.Println("Failed to convert InsertedID to ObjectID")
+	}
 	tr.logger.Printf("Task created with ID: %v\n", result.InsertedID)
 	span.SetStatus(codes.Ok, "Successfully inserted a task")
 	return nil
@@ -261,6 +304,10 @@ func (t *TaskRepository) UpdateAllTasksByProjectId(ctx context.Context, projectI
 	}
 
 	for _, task := range tasks {
+		// tasks deleted on their own stay in the trash when the project is deleted or restored
+		if task.DeletedAt != nil {
+			continue
+		}
 		task.PendingDeletion = toDelete
 		err := t.UpdatePendingDeletion(&task)
 		if err != nil {
@@ -538,7 +585,7 @@ func (tr *TaskRepository) UnblockDependencies(task *model.Task) error {
 
 	updateResult, err := tasksCollection.UpdateMany(ctx, dependencyFilter, dependencyUpdate)
 	if err != nil {
-		tr.logger.Println("failed to update dependencies: %v", err)
+		tr.logger.Printf("failed to update dependencies: %v", err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("failed to update dependencies: %v", err)
@@ -556,3 +603,286 @@ func (tr *TaskRepository) UnblockDependencies(task *model.Task) error {
 	return nil
 
 }
+
+func (tr *TaskRepository) AddLabel(ctx context.Context, task *model.Task, label string) error {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.AddLabel")
+	defer span.End()
+
+	filter := bson.M{"_id": task.ID}
+	update := bson.M{
+		"$addToSet": bson.M{"labels": label},
+		"$set":      bson.M{"updated_at": time.Now()},
+	}
+
+	_, err := tr.getCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to add label to task: %v", err)
+	}
+	if !slices.Contains(task.Labels, label) {
+		task.Labels = append(task.Labels, label)
+	}
+	span.SetStatus(codes.Ok, "Successfully added label to task")
+	return nil
+}
+
+func (tr *TaskRepository) RemoveLabel(ctx context.Context, task *model.Task, label string) error {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.RemoveLabel")
+	defer span.End()
+
+	filter := bson.M{"_id": task.ID}
+	update := bson.M{
+		"$pull": bson.M{"labels": label},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	_, err := tr.getCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to remove label from task: %v", err)
+	}
+	task.Labels = slices.DeleteFunc(task.Labels, func(l string) bool { return l == label })
+	span.SetStatus(codes.Ok, "Successfully removed label from task")
+	return nil
+}
+
+// RenameLabelInProject replaces a label name on every task of the project that carries it
+func (tr *TaskRepository) RenameLabelInProject(ctx context.Context, projectID, oldName, newName string) (int64, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.RenameLabelInProject")
+	defer span.End()
+
+	filter := bson.M{"project_id": projectID, "labels": oldName}
+	update := bson.M{
+		"$set": bson.M{
+			"labels.$":   newName,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := tr.getCollection().UpdateMany(ctx, filter, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to rename label: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully renamed label")
+	return result.ModifiedCount, nil
+}
+
+// RemoveLabelFromProject detaches a label from every task of the project
+func (tr *TaskRepository) RemoveLabelFromProject(ctx context.Context, projectID, name string) (int64, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.RemoveLabelFromProject")
+	defer span.End()
+
+	filter := bson.M{"project_id": projectID, "labels": name}
+	update := bson.M{
+		"$pull": bson.M{"labels": name},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := tr.getCollection().UpdateMany(ctx, filter, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to remove label: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully removed label from project tasks")
+	return result.ModifiedCount, nil
+}
+
+// UpdateCustomFields sets and clears custom field values of a task
+func (tr *TaskRepository) UpdateCustomFields(ctx context.Context, task *model.Task, set map[string]interface{}, unset []string) error {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.UpdateCustomFields")
+	defer span.End()
+
+	setFields := bson.M{"updated_at": time.Now()}
+	for fieldID, value := range set {
+		setFields["custom_fields."+fieldID] = value
+	}
+	update := bson.M{"$set": setFields}
+	if len(unset) > 0 {
+		unsetFields := bson.M{}
+		for _, fieldID := range unset {
+			unsetFields["custom_fields."+fieldID] = ""
+		}
+		update["$unset"] = unsetFields
+	}
+
+	_, err := tr.getCollection().UpdateOne(ctx, bson.M{"_id": task.ID}, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to update custom fields: %v", err)
+	}
+
+	if task.CustomFields == nil {
+		task.CustomFields = map[string]interface{}{}
+	}
+	for fieldID, value := range set {
+		task.CustomFields[fieldID] = value
+	}
+	for _, fieldID := range unset {
+		delete(task.CustomFields, fieldID)
+	}
+	span.SetStatus(codes.Ok, "Successfully updated custom fields")
+	return nil
+}
+
+// ClearCustomFieldInProject removes the value of a custom field from the project's tasks. When
+// keep is not nil, only values outside of keep are removed.
+func (tr *TaskRepository) ClearCustomFieldInProject(ctx context.Context, projectID, fieldID string, keep []string) (int64, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.ClearCustomFieldInProject")
+	defer span.End()
+
+	key := "custom_fields." + fieldID
+	condition := bson.M{"$exists": true}
+	if keep != nil {
+		condition["$nin"] = keep
+	}
+	filter := bson.M{"project_id": projectID, key: condition}
+	update := bson.M{
+		"$unset": bson.M{key: ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := tr.getCollection().UpdateMany(ctx, filter, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to clear custom field: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully cleared custom field")
+	return result.ModifiedCount, nil
+}
+
+// SetWatching subscribes a user to the changes of a task or unsubscribes them. Unwatching is
+// remembered so that a watch on the whole project does not apply to the task either.
+func (tr *TaskRepository) SetWatching(ctx context.Context, task *model.Task, userID string, watching bool) error {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.SetWatching")
+	defer span.End()
+
+	update := bson.M{
+		"$addToSet": bson.M{"watchers": userID},
+		"$pull":     bson.M{"unwatchers": userID},
+	}
+	if !watching {
+		update = bson.M{
+			"$addToSet": bson.M{"unwatchers": userID},
+			"$pull":     bson.M{"watchers": userID},
+		}
+	}
+
+	_, err := tr.getCollection().UpdateOne(ctx, bson.M{"_id": task.ID}, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to update task watchers: %v", err)
+	}
+	if watching {
+		task.Unwatchers = slices.DeleteFunc(task.Unwatchers, func(id string) bool { return id == userID })
+		if !slices.Contains(task.Watchers, userID) {
+			task.Watchers = append(task.Watchers, userID)
+		}
+	} else {
+		task.Watchers = slices.DeleteFunc(task.Watchers, func(id string) bool { return id == userID })
+		if !slices.Contains(task.Unwatchers, userID) {
+			task.Unwatchers = append(task.Unwatchers, userID)
+		}
+	}
+	span.SetStatus(codes.Ok, "Successfully updated task watchers")
+	return nil
+}
+
+// RemoveWatcherFromProject takes a user that left a project off the watchers of its tasks
+func (tr *TaskRepository) RemoveWatcherFromProject(ctx context.Context, projectID, userID string) (int64, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.RemoveWatcherFromProject")
+	defer span.End()
+
+	result, err := tr.getCollection().UpdateMany(ctx,
+		bson.M{"project_id": projectID, "watchers": userID},
+		bson.M{"$pull": bson.M{"watchers": userID}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to remove watcher from project tasks: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully removed watcher from project tasks")
+	return result.ModifiedCount, nil
+}
+
+// ExistingIDs returns which of the given task IDs belong to a stored task, deleted or not
+func (tr *TaskRepository) ExistingIDs(ctx context.Context, taskIDs []string) (map[string]bool, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.ExistingIDs")
+	defer span.End()
+
+	objectIDs := make([]primitive.ObjectID, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		if objectID, err := primitive.ObjectIDFromHex(taskID); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	existing := map[string]bool{}
+	cursor, err := tr.getCollection().Find(ctx,
+		bson.M{"_id": bson.M{"$in": objectIDs}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to find tasks: %v", err)
+	}
+	var tasks []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to find tasks: %v", err)
+	}
+	for _, task := range tasks {
+		existing[task.ID.Hex()] = true
+	}
+	span.SetStatus(codes.Ok, "Successfully found existing tasks")
+	return existing, nil
+}
+
+// ProjectIDs returns the project of each of the given task IDs that belongs to a stored task,
+// deleted or not
+func (tr *TaskRepository) ProjectIDs(ctx context.Context, taskIDs []string) (map[string]string, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.ProjectIDs")
+	defer span.End()
+
+	objectIDs := make([]primitive.ObjectID, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		if objectID, err := primitive.ObjectIDFromHex(taskID); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	projects := map[string]string{}
+	cursor, err := tr.getCollection().Find(ctx,
+		bson.M{"_id": bson.M{"$in": objectIDs}},
+		options.Find().SetProjection(bson.M{"_id": 1, "project_id": 1}),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to find tasks: %v", err)
+	}
+	var tasks []struct {
+		ID        primitive.ObjectID `bson:"_id"`
+		ProjectID string             `bson:"project_id"`
+	}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to find tasks: %v", err)
+	}
+	for _, task := range tasks {
+		projects[task.ID.Hex()] = task.ProjectID
+	}
+	span.SetStatus(codes.Ok, "Successfully found projects of tasks")
+	return projects, nil
+}
@@ -0,0 +1,255 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued for the same sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TaskQuery narrows down and orders tasks. CustomFields maps a field ID to the value (or
+// query condition) its stored value has to match. SortBy is a stored field name such as
+// "created_at" or "custom_fields.<fieldId>"; ties are always broken by _id, so the order is stable.
+type TaskQuery struct {
+	ProjectID    string
+	Statuses     []model.TaskStatus
+	Assignee     string
+	Blocked      *bool
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	UpdatedFrom  *time.Time
+	UpdatedTo    *time.Time
+	Text         string
+	Labels       []string
+	CustomFields map[string]interface{}
+	SortBy       string
+	SortDesc     bool
+}
+
+// TaskPage is one page of a task search
+type TaskPage struct {
+	Tasks      []model.Task `json:"tasks"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+	Total      *int64       `json:"total,omitempty"`
+}
+
+// taskCursor points after the last task of a page
+type taskCursor struct {
+	SortBy string             `bson:"s"`
+	Value  interface{}        `bson:"v"`
+	ID     primitive.ObjectID `bson:"i"`
+}
+
+func (q TaskQuery) filter() bson.M {
+	// tasks that are being deleted are hidden from every listing
+	conditions := []bson.M{{"pending_deletion": bson.M{"$ne": true}}}
+	if q.ProjectID != "" {
+		conditions = append(conditions, bson.M{"project_id": q.ProjectID})
+	}
+	if len(q.Statuses) > 0 {
+		conditions = append(conditions, bson.M{"status": bson.M{"$in": q.Statuses}})
+	}
+	if q.Assignee != "" {
+		conditions = append(conditions, bson.M{"user_ids": q.Assignee})
+	}
+	if q.Blocked != nil {
+		conditions = append(conditions, bson.M{"blocked": *q.Blocked})
+	}
+	if r := timeRange(q.CreatedFrom, q.CreatedTo); r != nil {
+		conditions = append(conditions, bson.M{"created_at": r})
+	}
+	if r := timeRange(q.UpdatedFrom, q.UpdatedTo); r != nil {
+		conditions = append(conditions, bson.M{"updated_at": r})
+	}
+	if q.Text != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(q.Text), Options: "i"}
+		conditions = append(conditions, bson.M{"$or": []bson.M{
+			{"name": pattern},
+			{"description": pattern},
+		}})
+	}
+	if len(q.Labels) > 0 {
+		conditions = append(conditions, bson.M{"labels": bson.M{"$all": q.Labels}})
+	}
+	for fieldID, condition := range q.CustomFields {
+		conditions = append(conditions, bson.M{"custom_fields." + fieldID: condition})
+	}
+
+	return bson.M{"$and": conditions}
+}
+
+func timeRange(from, to *time.Time) bson.M {
+	if from == nil && to == nil {
+		return nil
+	}
+	r := bson.M{}
+	if from != nil {
+		r["$gte"] = *from
+	}
+	if to != nil {
+		r["$lt"] = *to
+	}
+	return r
+}
+
+func (q TaskQuery) sortKey() string {
+	if q.SortBy == "" {
+		return "created_at"
+	}
+	return q.SortBy
+}
+
+func (q TaskQuery) sort() bson.D {
+	order := 1
+	if q.SortDesc {
+		order = -1
+	}
+	return bson.D{{Key: q.sortKey(), Value: order}, {Key: "_id", Value: 1}}
+}
+
+// after builds the condition for the tasks that come after the cursor in the query's order.
+// Missing values sort before everything else ascending and after everything else descending.
+func (q TaskQuery) after(cursor taskCursor) bson.M {
+	key := q.sortKey()
+	tie := bson.M{key: cursor.Value, "_id": bson.M{"$gt": cursor.ID}}
+
+	if cursor.Value == nil {
+		tie = bson.M{key: nil, "_id": bson.M{"$gt": cursor.ID}}
+		if q.SortDesc {
+			return tie
+		}
+		return bson.M{"$or": []bson.M{tie, {key: bson.M{"$ne": nil}}}}
+	}
+	if q.SortDesc {
+		return bson.M{"$or": []bson.M{{key: bson.M{"$lt": cursor.Value}}, tie, {key: nil}}}
+	}
+	return bson.M{"$or": []bson.M{{key: bson.M{"$gt": cursor.Value}}, tie}}
+}
+
+// FindByProject returns all tasks of a project that match the query
+func (tr *TaskRepository) FindByProject(ctx context.Context, projectID string, query TaskQuery) ([]model.Task, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.FindByProject")
+	defer span.End()
+
+	query.ProjectID = projectID
+	tasks := []model.Task{}
+
+	opts := options.Find().SetSort(query.sort())
+	tasksCursor, err := tr.getCollection().Find(ctx, query.filter(), opts)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return tasks, err
+	}
+	if err = tasksCursor.All(ctx, &tasks); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return tasks, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got tasks by query")
+	return tasks, nil
+}
+
+// SearchTasks returns one page of the tasks matching the query, starting after the given
+// cursor. The total number of matches is only counted when withTotal is set.
+func (tr *TaskRepository) SearchTasks(ctx context.Context, query TaskQuery, cursor string, limit int64, withTotal bool) (*TaskPage, error) {
+	ctx, span := tr.tracer.Start(ctx, "TaskRepository.SearchTasks")
+	defer span.End()
+
+	filter := query.filter()
+	page := &TaskPage{Tasks: []model.Task{}}
+
+	if withTotal {
+		total, err := tr.getCollection().CountDocuments(ctx, filter)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	if cursor != "" {
+		position, err := decodeTaskCursor(cursor)
+		if err != nil || position.SortBy != query.sortKey() {
+			span.SetStatus(codes.Error, ErrInvalidCursor.Error())
+			return nil, ErrInvalidCursor
+		}
+		filter = bson.M{"$and": []bson.M{filter, query.after(position)}}
+	}
+
+	// one extra task tells whether there is a next page
+	opts := options.Find().SetSort(query.sort()).SetLimit(limit + 1)
+	tasksCursor, err := tr.getCollection().Find(ctx, filter, opts)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	var documents []bson.Raw
+	if err = tasksCursor.All(ctx, &documents); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	for i, document := range documents {
+		if int64(i) == limit {
+			last := documents[i-1]
+			next := taskCursor{SortBy: query.sortKey()}
+			if value, err := last.LookupErr(strings.Split(query.sortKey(), ".")...); err == nil {
+				next.Value = value
+			}
+			next.ID = last.Lookup("_id").ObjectID()
+			if page.NextCursor, err = encodeTaskCursor(next); err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return nil, err
+			}
+			break
+		}
+		var task model.Task
+		if err := bson.Unmarshal(document, &task); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+		page.Tasks = append(page.Tasks, task)
+	}
+
+	span.SetStatus(codes.Ok, "Successfully searched tasks")
+	return page, nil
+}
+
+func encodeTaskCursor(cursor taskCursor) (string, error) {
+	data, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeTaskCursor(value string) (taskCursor, error) {
+	var cursor taskCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return cursor, err
+	}
+	return cursor, nil
+}
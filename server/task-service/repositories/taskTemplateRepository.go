@@ -0,0 +1,306 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TaskTemplateRepository struct {
+	cli    *mongo.Client
+	logger *log.Logger
+	tracer trace.Tracer
+}
+
+func NewTaskTemplateRepository(ctx context.Context, logger *log.Logger, tracer trace.Tracer) (*TaskTemplateRepository, error) {
+	dburi := os.Getenv("MONGO_DB_URI")
+	if dburi == "" {
+		return nil, fmt.Errorf("MONGO_DB_URI environment variable is not set")
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(dburi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+
+	if err = client.Ping(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	logger.Println("Successfully connected to MongoDB")
+
+	repo := &TaskTemplateRepository{
+		cli:    client,
+		logger: logger,
+		tracer: tracer,
+	}
+
+	_, err = repo.getCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_run_at", Value: 1}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create task template indexes: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (ttr *TaskTemplateRepository) Disconnect(ctx context.Context) error {
+	ctx, span := ttr.tracer.Start(ctx, "TaskTemplateRepository.Disconnect")
+	defer span.End()
+
+	err := ttr.cli.Disconnect(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully disconnected")
+	return nil
+}
+
+func (ttr *TaskTemplateRepository) getCollection() *mongo.Collection {
+	taskDatabase := ttr.cli.Database("mongoTask")
+	templatesCollection := taskDatabase.Collection("task_templates")
+	return templatesCollection
+}
+
+func (ttr *TaskTemplateRepository) SaveTaskTemplate(ctx context.Context, template *model.TaskTemplate) error {
+	ctx, span := ttr.tracer.Start(ctx, "TaskTemplateRepository.SaveTaskTemplate")
+	defer span.End()
+
+	if template.ID.IsZero() {
+		template.ID = primitive.NewObjectID()
+	}
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+
+	_, err := ttr.getCollection().InsertOne(ctx, template)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		ttr.logger.Printf("Failed to save task template: %v", err)
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully saved task template")
+	return nil
+}
+
+func (ttr *TaskTemplateRepository) GetTaskTemplateByID(ctx context.Context, templateID primitive.ObjectID) (*model.TaskTemplate, error) {
+	ctx, span := ttr.tracer.Start(ctx, "TaskTemplateRepository.GetTaskTemplateByID")
+	defer span.End()
+
+	var template model.TaskTemplate
+	err := ttr.getCollection().FindOne(ctx, bson.M{"_id": templateID}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			span.SetStatus(codes.Ok, "No task template found")
+			return nil, nil
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		ttr.logger.Printf("Failed to find task template: %v", err)
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched task template")
+	return &template, nil
+}
+
+func (ttr *TaskTemplateRepository) GetTaskTemplatesByProjectID(ctx context.Context, projectID string) (model.TaskTemplates, error) {
+	ctx, span := ttr.tracer.Start(ctx, "TaskTemplateRepository.GetTaskTemplatesByProjectID")
+	defer span.End()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := ttr.getCollection().Find(ctx, bson.M{"project_id": projectID}, opts)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		ttr.logger.Printf("Failed to find task templates: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := model.TaskTemplates{}
+	if err = cursor.All(ctx, &templates); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		ttr.logger.Printf("Failed to decode task templates: %v", err)
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched task templates")
+	return templates, nil
+}
+
+// GetDueTaskTemplates returns active templates whose next occurrence is not after now
+func (ttr *TaskTemplateRepository) GetDueTaskTemplates(ctx context.Context, now time.Time, limit int64) (model.TaskTemplates, error) {
+	ctx, span := ttr.tracer.Start(ctx, "TaskTemplateRepository.GetDueTaskTemplates")
+	defer span.End()
+
+	filter := bson.M{
+		"status":      model.TemplateActive,
+		"next_run_at": bson.M{"$lte": now},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "next_run_at", Value: 1}}).SetLimit(limit)
+
+	cursor, err := ttr.getCollection().Find(ctx, filter, opts)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := model.TaskTemplates{}
+	if err = cursor.All(ctx, &templates); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched due task templates")
+	return templates, nil
+}
+
+// AdvanceTaskTemplate moves a template past the occurrence at runAt. It only succeeds while the
+// template is active and still points at runAt, so when several replicas handle the same
+// occurrence exactly one of them advances it, and a series paused meanwhile stays where it is.
+// A nil next ends the series.
+func (ttr *TaskTemplateRepository) AdvanceTaskTemplate(ctx context.Context, template *model.TaskTemplate, runAt time.Time, next *time.Time) (bool, error) {
+	ctx, span := ttr.tracer.Start(ctx, "TaskTemplateRepository.AdvanceTaskTemplate")
+	defer span.End()
+
+	now := time.Now()
+	set := bson.M{
+		"last_run_at": runAt,
+		"updated_at":  now,
+	}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"generated_count": 1},
+	}
+	if next != nil {
+		set["next_run_at"] = *next
+	} else {
+		set["status"] = model.TemplateEnded
+		update["$unset"] = bson.M{"next_run_at": ""}
+	}
+
+	filter := bson.M{"_id": template.ID, "status": model.TemplateActive, "next_run_at": runAt}
+	result, err := ttr.getCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to advance task template: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully advanced task template")
+	return result.ModifiedCount == 1, nil
+}
+
+// UpdateTaskTemplateStatus pauses, resumes or ends a series
+func (ttr *TaskTemplateRepository) UpdateTaskTemplateStatus(ctx context.Context, template *model.TaskTemplate, status model.TaskTemplateStatus, next *time.Time) error {
+	ctx, span := ttr.tracer.Start(ctx, "TaskTemplateRepository.UpdateTaskTemplateStatus")
+	defer span.End()
+
+	set := bson.M{
+		"status":     status,
+		"updated_at": time.Now(),
+	}
+	unset := bson.M{"paused_with_project": ""}
+	update := bson.M{"$set": set, "$unset": unset}
+	if next != nil {
+		set["next_run_at"] = *next
+	} else {
+		unset["next_run_at"] = ""
+	}
+
+	_, err := ttr.getCollection().UpdateOne(ctx, bson.M{"_id": template.ID}, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to update task template status: %v", err)
+	}
+
+	template.Status = status
+	template.NextRunAt = next
+	template.PausedWithProject = false
+	span.SetStatus(codes.Ok, "Successfully updated task template status")
+	return nil
+}
+
+// PauseProjectTaskTemplates pauses the active series of a project that is being deleted
+func (ttr *TaskTemplateRepository) PauseProjectTaskTemplates(ctx context.Context, projectID string) (int64, error) {
+	ctx, span := ttr.tracer.Start(ctx, "TaskTemplateRepository.PauseProjectTaskTemplates")
+	defer span.End()
+
+	result, err := ttr.getCollection().UpdateMany(ctx,
+		bson.M{"project_id": projectID, "status": model.TemplateActive},
+		bson.M{"$set": bson.M{
+			"status":              model.TemplatePaused,
+			"paused_with_project": true,
+			"updated_at":          time.Now(),
+		}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to pause task templates: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully paused task templates")
+	return result.ModifiedCount, nil
+}
+
+// GetTaskTemplatesPausedWithProject returns the series that were paused when their project was
+// deleted
+func (ttr *TaskTemplateRepository) GetTaskTemplatesPausedWithProject(ctx context.Context, projectID string) (model.TaskTemplates, error) {
+	ctx, span := ttr.tracer.Start(ctx, "TaskTemplateRepository.GetTaskTemplatesPausedWithProject")
+	defer span.End()
+
+	filter := bson.M{"project_id": projectID, "status": model.TemplatePaused, "paused_with_project": true}
+	cursor, err := ttr.getCollection().Find(ctx, filter)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := model.TaskTemplates{}
+	if err = cursor.All(ctx, &templates); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched paused task templates")
+	return templates, nil
+}
+
+// EndProjectTaskTemplates ends every series of a project that was purged
+func (ttr *TaskTemplateRepository) EndProjectTaskTemplates(ctx context.Context, projectID string) (int64, error) {
+	ctx, span := ttr.tracer.Start(ctx, "TaskTemplateRepository.EndProjectTaskTemplates")
+	defer span.End()
+
+	result, err := ttr.getCollection().UpdateMany(ctx,
+		bson.M{"project_id": projectID, "status": bson.M{"$ne": model.TemplateEnded}},
+		bson.M{
+			"$set":   bson.M{"status": model.TemplateEnded, "updated_at": time.Now()},
+			"$unset": bson.M{"next_run_at": "", "paused_with_project": ""},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to end task templates: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully ended task templates")
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type UploadSessionRepository struct {
+	cli    *mongo.Client
+	logger *log.Logger
+	tracer trace.Tracer
+}
+
+func NewUploadSessionRepository(ctx context.Context, logger *log.Logger, tracer trace.Tracer) (*UploadSessionRepository, error) {
+	dburi := os.Getenv("MONGO_DB_URI")
+	if dburi == "" {
+		return nil, fmt.Errorf("MONGO_DB_URI environment variable is not set")
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(dburi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+
+	if err = client.Ping(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	logger.Println("Successfully connected to MongoDB")
+
+	repo := &UploadSessionRepository{
+		cli:    client,
+		logger: logger,
+		tracer: tracer,
+	}
+
+	_, err = repo.getCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "expires_at", Value: 1}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload session indexes: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (usr *UploadSessionRepository) Disconnect(ctx context.Context) error {
+	ctx, span := usr.tracer.Start(ctx, "UploadSessionRepository.Disconnect")
+	defer span.End()
+
+	err := usr.cli.Disconnect(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully disconnected")
+	return nil
+}
+
+func (usr *UploadSessionRepository) getCollection() *mongo.Collection {
+	taskDatabase := usr.cli.Database("mongoTask")
+	sessionsCollection := taskDatabase.Collection("upload_sessions")
+	return sessionsCollection
+}
+
+func (usr *UploadSessionRepository) Insert(ctx context.Context, session *model.UploadSession) error {
+	ctx, span := usr.tracer.Start(ctx, "UploadSessionRepository.Insert")
+	defer span.End()
+
+	session.ID = primitive.NewObjectID()
+	session.CreatedAt = time.Now()
+	session.Chunks = []model.UploadChunk{}
+	if _, err := usr.getCollection().InsertOne(ctx, session); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to insert upload session: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully inserted upload session")
+	return nil
+}
+
+// GetByID returns an upload session, or nil when there is no such session
+func (usr *UploadSessionRepository) GetByID(ctx context.Context, id string) (*model.UploadSession, error) {
+	ctx, span := usr.tracer.Start(ctx, "UploadSessionRepository.GetByID")
+	defer span.End()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		span.SetStatus(codes.Ok, "Invalid upload session ID")
+		return nil, nil
+	}
+	var session model.UploadSession
+	err = usr.getCollection().FindOne(ctx, bson.M{"_id": objID}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		span.SetStatus(codes.Ok, "Upload session not found")
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to get upload session: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully got upload session")
+	return &session, nil
+}
+
+// AppendChunk records a stored chunk and moves the offset of the session past it. It reports
+// false when the offset of the session is no longer the offset of the chunk, which happens
+// when another request appended to the session first.
+func (usr *UploadSessionRepository) AppendChunk(ctx context.Context, id primitive.ObjectID, chunk model.UploadChunk, expiresAt time.Time) (bool, error) {
+	ctx, span := usr.tracer.Start(ctx, "UploadSessionRepository.AppendChunk")
+	defer span.End()
+
+	result, err := usr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": id, "offset": chunk.Offset},
+		bson.M{
+			"$set":  bson.M{"offset": chunk.Offset + chunk.Size, "expires_at": expiresAt},
+			"$push": bson.M{"chunks": chunk},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to append chunk to upload session %s: %v", id.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully appended chunk")
+	return result.ModifiedCount == 1, nil
+}
+
+// StartFinalizing claims a complete session for finalization until the given time, so that
+// two requests cannot turn it into two documents. It reports false when the session is not
+// complete or another request is finalizing it.
+func (usr *UploadSessionRepository) StartFinalizing(ctx context.Context, id primitive.ObjectID, until time.Time) (bool, error) {
+	ctx, span := usr.tracer.Start(ctx, "UploadSessionRepository.StartFinalizing")
+	defer span.End()
+
+	result, err := usr.getCollection().UpdateOne(ctx,
+		bson.M{
+			"_id":   id,
+			"$expr": bson.M{"$eq": bson.A{"$offset", "$length"}},
+			"$or": bson.A{
+				bson.M{"finalizing_until": bson.M{"$exists": false}},
+				bson.M{"finalizing_until": bson.M{"$lt": time.Now()}},
+			},
+		},
+		bson.M{"$set": bson.M{"finalizing_until": until, "expires_at": until}},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("failed to start finalizing upload session %s: %v", id.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully claimed upload session")
+	return result.ModifiedCount == 1, nil
+}
+
+// StopFinalizing releases a session whose finalization failed, so that it can be retried
+// until it expires
+func (usr *UploadSessionRepository) StopFinalizing(ctx context.Context, id primitive.ObjectID, expiresAt time.Time) error {
+	ctx, span := usr.tracer.Start(ctx, "UploadSessionRepository.StopFinalizing")
+	defer span.End()
+
+	_, err := usr.getCollection().UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set":   bson.M{"expires_at": expiresAt},
+			"$unset": bson.M{"finalizing_until": ""},
+		},
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to release upload session %s: %v", id.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully released upload session")
+	return nil
+}
+
+func (usr *UploadSessionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, span := usr.tracer.Start(ctx, "UploadSessionRepository.Delete")
+	defer span.End()
+
+	if _, err := usr.getCollection().DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to delete upload session %s: %v", id.Hex(), err)
+	}
+	span.SetStatus(codes.Ok, "Successfully deleted upload session")
+	return nil
+}
+
+// FindExpired returns up to limit sessions that expired before cutoff
+func (usr *UploadSessionRepository) FindExpired(ctx context.Context, cutoff time.Time, limit int64) ([]model.UploadSession, error) {
+	ctx, span := usr.tracer.Start(ctx, "UploadSessionRepository.FindExpired")
+	defer span.End()
+
+	sessions := []model.UploadSession{}
+	cursor, err := usr.getCollection().Find(ctx,
+		bson.M{"expires_at": bson.M{"$lt": cutoff}},
+		options.Find().SetLimit(limit),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &sessions); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got expired upload sessions")
+	return sessions, nil
+}
+
+// All returns every upload session, expired or not
+func (usr *UploadSessionRepository) All(ctx context.Context) ([]model.UploadSession, error) {
+	ctx, span := usr.tracer.Start(ctx, "UploadSessionRepository.All")
+	defer span.End()
+
+	sessions := []model.UploadSession{}
+	cursor, err := usr.getCollection().Find(ctx, bson.M{})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err = cursor.All(ctx, &sessions); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully got upload sessions")
+	return sessions, nil
+}
@@ -0,0 +1,334 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"task--service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrTimerAlreadyRunning is returned when a user tries to start a second timer
+var ErrTimerAlreadyRunning = errors.New("a timer is already running for this user")
+
+type WorkLogRepository struct {
+	cli    *mongo.Client
+	logger *log.Logger
+	tracer trace.Tracer
+}
+
+func NewWorkLogRepository(ctx context.Context, logger *log.Logger, tracer trace.Tracer) (*WorkLogRepository, error) {
+	dburi := os.Getenv("MONGO_DB_URI")
+	if dburi == "" {
+		return nil, fmt.Errorf("MONGO_DB_URI environment variable is not set")
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(dburi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+
+	if err = client.Ping(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	logger.Println("Successfully connected to MongoDB")
+
+	repo := &WorkLogRepository{
+		cli:    client,
+		logger: logger,
+		tracer: tracer,
+	}
+
+	// the database is the one place that can guarantee a single running timer per user
+	_, err = repo.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"running": true}).
+				SetName("one_running_timer_per_user"),
+		},
+		{
+			Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "started_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "project_id", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work log indexes: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (wr *WorkLogRepository) Disconnect(ctx context.Context) error {
+	ctx, span := wr.tracer.Start(ctx, "WorkLogRepository.Disconnect")
+	defer span.End()
+
+	err := wr.cli.Disconnect(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully disconnected")
+	return nil
+}
+
+func (wr *WorkLogRepository) getCollection() *mongo.Collection {
+	taskDatabase := wr.cli.Database("mongoTask")
+	workLogsCollection := taskDatabase.Collection("work_logs")
+	return workLogsCollection
+}
+
+// SaveWorkLog inserts a work log. Inserting a running log while the user already has one
+// fails with ErrTimerAlreadyRunning.
+func (wr *WorkLogRepository) SaveWorkLog(ctx context.Context, workLog *model.WorkLog) error {
+	ctx, span := wr.tracer.Start(ctx, "WorkLogRepository.SaveWorkLog")
+	defer span.End()
+
+	if workLog.ID.IsZero() {
+		workLog.ID = primitive.NewObjectID()
+	}
+	workLog.CreatedAt = time.Now()
+	workLog.UpdatedAt = workLog.CreatedAt
+
+	_, err := wr.getCollection().InsertOne(ctx, workLog)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			span.SetStatus(codes.Error, ErrTimerAlreadyRunning.Error())
+			return ErrTimerAlreadyRunning
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		wr.logger.Printf("Failed to save work log: %v", err)
+		return err
+	}
+	span.SetStatus(codes.Ok, "Successfully saved work log")
+	return nil
+}
+
+// GetRunningTimer returns the running timer of a user, or nil when there is none
+func (wr *WorkLogRepository) GetRunningTimer(ctx context.Context, userID string) (*model.WorkLog, error) {
+	ctx, span := wr.tracer.Start(ctx, "WorkLogRepository.GetRunningTimer")
+	defer span.End()
+
+	var workLog model.WorkLog
+	err := wr.getCollection().FindOne(ctx, bson.M{"user_id": userID, "running": true}).Decode(&workLog)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			span.SetStatus(codes.Ok, "No running timer")
+			return nil, nil
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		wr.logger.Printf("Failed to find running timer: %v", err)
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched running timer")
+	return &workLog, nil
+}
+
+// StopTimer closes a running timer at endedAt and stores the tracked duration
+func (wr *WorkLogRepository) StopTimer(ctx context.Context, workLog *model.WorkLog, endedAt time.Time) error {
+	ctx, span := wr.tracer.Start(ctx, "WorkLogRepository.StopTimer")
+	defer span.End()
+
+	duration := int64(endedAt.Sub(workLog.StartedAt).Seconds())
+	if duration < 0 {
+		duration = 0
+	}
+
+	filter := bson.M{"_id": workLog.ID, "running": true}
+	update := bson.M{"$set": bson.M{
+		"running":          false,
+		"ended_at":         endedAt,
+		"duration_seconds": duration,
+		"updated_at":       endedAt,
+	}}
+
+	result, err := wr.getCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to stop timer: %v", err)
+	}
+	if result.ModifiedCount == 0 {
+		span.SetStatus(codes.Error, "Timer was already stopped")
+		return fmt.Errorf("timer was already stopped")
+	}
+
+	workLog.Running = false
+	workLog.EndedAt = &endedAt
+	workLog.DurationSeconds = duration
+	workLog.UpdatedAt = endedAt
+	span.SetStatus(codes.Ok, "Successfully stopped timer")
+	return nil
+}
+
+func (wr *WorkLogRepository) GetWorkLogByID(ctx context.Context, workLogID primitive.ObjectID) (*model.WorkLog, error) {
+	ctx, span := wr.tracer.Start(ctx, "WorkLogRepository.GetWorkLogByID")
+	defer span.End()
+
+	var workLog model.WorkLog
+	err := wr.getCollection().FindOne(ctx, bson.M{"_id": workLogID}).Decode(&workLog)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			span.SetStatus(codes.Ok, "No work log found")
+			return nil, nil
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		wr.logger.Printf("Failed to find work log: %v", err)
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched work log")
+	return &workLog, nil
+}
+
+func (wr *WorkLogRepository) GetWorkLogsByTaskID(ctx context.Context, taskID string) (model.WorkLogs, error) {
+	ctx, span := wr.tracer.Start(ctx, "WorkLogRepository.GetWorkLogsByTaskID")
+	defer span.End()
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: 1}})
+	cursor, err := wr.getCollection().Find(ctx, bson.M{"task_id": taskID}, opts)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		wr.logger.Printf("Failed to find work logs: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	workLogs := model.WorkLogs{}
+	if err = cursor.All(ctx, &workLogs); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		wr.logger.Printf("Failed to decode work logs: %v", err)
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "Successfully fetched work logs")
+	return workLogs, nil
+}
+
+// UpdateWorkLog stores the edited start, duration and note of a finished work log
+func (wr *WorkLogRepository) UpdateWorkLog(ctx context.Context, workLog *model.WorkLog) error {
+	ctx, span := wr.tracer.Start(ctx, "WorkLogRepository.UpdateWorkLog")
+	defer span.End()
+
+	workLog.UpdatedAt = time.Now()
+	endedAt := workLog.StartedAt.Add(time.Duration(workLog.DurationSeconds) * time.Second)
+	workLog.EndedAt = &endedAt
+
+	filter := bson.M{"_id": workLog.ID, "running": false}
+	update := bson.M{"$set": bson.M{
+		"started_at":       workLog.StartedAt,
+		"ended_at":         endedAt,
+		"duration_seconds": workLog.DurationSeconds,
+		"note":             workLog.Note,
+		"updated_at":       workLog.UpdatedAt,
+	}}
+
+	result, err := wr.getCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to update work log: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		span.SetStatus(codes.Error, "Work log not found or still running")
+		return fmt.Errorf("work log not found or still running")
+	}
+	span.SetStatus(codes.Ok, "Successfully updated work log")
+	return nil
+}
+
+func (wr *WorkLogRepository) DeleteWorkLog(ctx context.Context, workLogID primitive.ObjectID) error {
+	ctx, span := wr.tracer.Start(ctx, "WorkLogRepository.DeleteWorkLog")
+	defer span.End()
+
+	_, err := wr.getCollection().DeleteOne(ctx, bson.M{"_id": workLogID})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to delete work log: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully deleted work log")
+	return nil
+}
+
+// DeleteByTaskID removes the work logs of a purged task
+func (wr *WorkLogRepository) DeleteByTaskID(ctx context.Context, taskID string) error {
+	ctx, span := wr.tracer.Start(ctx, "WorkLogRepository.DeleteByTaskID")
+	defer span.End()
+
+	_, err := wr.getCollection().DeleteMany(ctx, bson.M{"task_id": taskID})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to delete work logs: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully deleted work logs")
+	return nil
+}
+
+// GetProjectTotals sums up the finished work logs of a project per task and per member
+func (wr *WorkLogRepository) GetProjectTotals(ctx context.Context, projectID string) (*model.WorkLogTotals, error) {
+	ctx, span := wr.tracer.Start(ctx, "WorkLogRepository.GetProjectTotals")
+	defer span.End()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"project_id": projectID, "running": false}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     bson.M{"task_id": "$task_id", "user_id": "$user_id"},
+			"seconds": bson.M{"$sum": "$duration_seconds"},
+		}}},
+	}
+
+	cursor, err := wr.getCollection().Aggregate(ctx, pipeline)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		wr.logger.Printf("Failed to aggregate work logs: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID struct {
+			TaskID string `bson:"task_id"`
+			UserID string `bson:"user_id"`
+		} `bson:"_id"`
+		Seconds int64 `bson:"seconds"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		wr.logger.Printf("Failed to decode work log totals: %v", err)
+		return nil, err
+	}
+
+	totals := &model.WorkLogTotals{
+		MemberSeconds: map[string]int64{},
+		TaskSeconds:   map[string]int64{},
+	}
+	for _, row := range rows {
+		totals.TotalSeconds += row.Seconds
+		totals.MemberSeconds[row.ID.UserID] += row.Seconds
+		totals.TaskSeconds[row.ID.TaskID] += row.Seconds
+	}
+	span.SetStatus(codes.Ok, "Successfully aggregated work logs")
+	return totals, nil
+}
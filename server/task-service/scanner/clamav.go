@@ -0,0 +1,104 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamAVChunkSize is the size of the chunks a file is streamed to clamd in. clamd refuses
+// chunks above its StreamMaxLength, which defaults to 25 MB for the whole stream.
+const clamAVChunkSize = 64 << 10
+
+// ClamAVScanner scans files with a clamd daemon through the INSTREAM command. maxSize has to
+// match the StreamMaxLength of clamd.
+type ClamAVScanner struct {
+	address string
+	timeout time.Duration
+	maxSize int64
+}
+
+func NewClamAVScanner(address string, timeout time.Duration, maxSize int64) *ClamAVScanner {
+	return &ClamAVScanner{address: address, timeout: timeout, maxSize: maxSize}
+}
+
+func (s *ClamAVScanner) Name() string {
+	return "clamav"
+}
+
+func (s *ClamAVScanner) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Scan streams the file to clamd as length prefixed chunks ended by an empty chunk, and reads
+// back "stream: OK" or "stream: <signature> FOUND"
+func (s *ClamAVScanner) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %v", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set clamd deadline: %v", err)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("failed to send clamd command: %v", err)
+	}
+	buf := make([]byte, 4+clamAVChunkSize)
+	var streamed int64
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if streamed += int64(n); s.maxSize > 0 && streamed > s.maxSize {
+			return nil, ErrTooLarge
+		}
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, writeErr := conn.Write(buf[:4+n]); writeErr != nil {
+				return nil, fmt.Errorf("failed to stream file to clamd: %v", writeErr)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %v", err)
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return nil, fmt.Errorf("failed to end clamd stream: %v", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read clamd reply: %v", err)
+	}
+	return parseClamAVReply(reply)
+}
+
+func parseClamAVReply(reply string) (*Result, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	verdict := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case verdict == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	case strings.Contains(verdict, "size limit exceeded"):
+		return nil, fmt.Errorf("%w: %s", ErrTooLarge, reply)
+	default:
+		// other errors leave the file unscanned
+		return nil, fmt.Errorf("clamd could not scan the file: %s", reply)
+	}
+}
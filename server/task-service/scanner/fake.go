@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+// eicar is the standard antivirus test file, https://www.eicar.org/download-anti-malware-testfile/
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// FakeScanner reports files that contain the EICAR test string as infected, for development
+// and tests without a clamd daemon
+type FakeScanner struct{}
+
+func NewFakeScanner() *FakeScanner {
+	return &FakeScanner{}
+}
+
+func (s *FakeScanner) Name() string {
+	return "fake"
+}
+
+func (s *FakeScanner) MaxSize() int64 {
+	return 0
+}
+
+// Scan looks for the test string across the whole file, keeping only a tail of the previous
+// read so that a string split between two reads is still found
+func (s *FakeScanner) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	signature := []byte(eicar)
+	window := make([]byte, 0, 64<<10+len(signature))
+	buf := make([]byte, 64<<10)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := r.Read(buf)
+		window = append(window, buf[:n]...)
+		if bytes.Contains(window, signature) {
+			return &Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+		}
+		if len(window) > len(signature) {
+			window = append(window[:0], window[len(window)-len(signature):]...)
+		}
+		if err == io.EOF {
+			return &Result{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %v", err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package scanner checks uploaded document files for malware. The scanner is chosen with
+// DOCUMENT_SCANNER: none (the default), clamav or fake.
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Result is the verdict on a scanned file. Signature names what was found in an infected file.
+type Result struct {
+	Infected  bool
+	Signature string
+}
+
+// ErrTooLarge means the file is larger than the scanner accepts, scanning it again does not help
+var ErrTooLarge = errors.New("file is too large to be scanned")
+
+// Scanner scans the content of a file. An error means the file could not be scanned and its
+// verdict is still open, unless it is ErrTooLarge.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+	Name() string
+	// MaxSize is the size of the largest file the scanner accepts, 0 when there is no limit
+	MaxSize() int64
+}
+
+const (
+	defaultClamAVAddress   = "clamav:3310"
+	defaultClamAVTimeout   = 10 * time.Minute
+	defaultClamAVMaxSizeMB = 25
+)
+
+// NewScanner creates the scanner configured in the environment, or nil when files are not
+// scanned. CLAMAV_ADDRESS is the clamd daemon of the clamav scanner and CLAMAV_MAX_SIZE_MB its
+// StreamMaxLength, 25 MB like the default of clamd.
+func NewScanner(logger *log.Logger) (Scanner, error) {
+	switch name := strings.ToLower(os.Getenv("DOCUMENT_SCANNER")); name {
+	case "", "none":
+		logger.Println("Uploaded documents are not scanned for malware")
+		return nil, nil
+	case "clamav":
+		address := os.Getenv("CLAMAV_ADDRESS")
+		if address == "" {
+			address = defaultClamAVAddress
+		}
+		maxSizeMB, err := strconv.ParseInt(os.Getenv("CLAMAV_MAX_SIZE_MB"), 10, 64)
+		if err != nil || maxSizeMB <= 0 {
+			maxSizeMB = defaultClamAVMaxSizeMB
+		}
+		logger.Printf("Scanning uploaded documents up to %d MB with clamd at %s", maxSizeMB, address)
+		return NewClamAVScanner(address, defaultClamAVTimeout, maxSizeMB<<20), nil
+	case "fake":
+		logger.Println("Scanning uploaded documents with the fake scanner, only the EICAR test file is detected")
+		return NewFakeScanner(), nil
+	default:
+		return nil, fmt.Errorf("unknown document scanner %q, use none, clamav or fake", name)
+	}
+}
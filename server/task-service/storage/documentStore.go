@@ -0,0 +1,85 @@
+// Package storage keeps the files of task documents. The backend is chosen with
+// DOCUMENT_STORE: hdfs (the default), local or memory.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrNotFound is returned for a path that holds no file
+var ErrNotFound = errors.New("document file not found")
+
+// FileInfo describes a stored file
+type FileInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// DocumentStore keeps files under slash separated paths such as "/documents/<id>_report.pdf".
+// Put replaces a file as a whole, readers never see a partly written file.
+type DocumentStore interface {
+	Put(ctx context.Context, path string, r io.Reader) (int64, error)
+	// Get opens a file for reading, the caller closes it
+	Get(ctx context.Context, path string) (io.ReadSeekCloser, error)
+	Stat(ctx context.Context, path string) (*FileInfo, error)
+	// Delete removes a file, a file that does not exist is reported with ErrNotFound
+	Delete(ctx context.Context, path string) error
+	// List returns the files under a directory and its subdirectories
+	List(ctx context.Context, dir string) ([]FileInfo, error)
+	Close() error
+}
+
+const (
+	defaultHDFSAddress = "namenode:9000"
+	defaultLocalDir    = "/data/documents"
+)
+
+// NewDocumentStore creates the store configured in the environment. HDFS_ADDRESS is the
+// namenode of the hdfs store and DOCUMENT_STORE_DIR the root directory of the local store.
+func NewDocumentStore(logger *log.Logger, tracer trace.Tracer) (DocumentStore, error) {
+	switch backend := strings.ToLower(os.Getenv("DOCUMENT_STORE")); backend {
+	case "", "hdfs":
+		address := strings.TrimPrefix(os.Getenv("HDFS_ADDRESS"), "hdfs://")
+		if address == "" {
+			address = defaultHDFSAddress
+		}
+		return NewHDFSStore(address, logger, tracer)
+	case "local":
+		dir := os.Getenv("DOCUMENT_STORE_DIR")
+		if dir == "" {
+			dir = defaultLocalDir
+		}
+		return NewLocalStore(dir, logger, tracer)
+	case "memory":
+		return NewMemoryStore(logger, tracer), nil
+	default:
+		return nil, fmt.Errorf("unknown document store %q, use hdfs, local or memory", backend)
+	}
+}
+
+// cleanPath turns a path into its canonical absolute form, so that "../" cannot leave the root
+func cleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// isUnder reports whether the clean path p lies in the clean directory dir
+func isUnder(p, dir string) bool {
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// isTempFile reports whether a file is one that Put is still writing
+func isTempFile(p string) bool {
+	name := path.Base(p)
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".tmp")
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/colinmarc/hdfs/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HDFSStore keeps the files in HDFS through one client for the lifetime of the service
+type HDFSStore struct {
+	cli    *hdfs.Client
+	logger *log.Logger
+	tracer trace.Tracer
+}
+
+func NewHDFSStore(address string, logger *log.Logger, tracer trace.Tracer) (*HDFSStore, error) {
+	client, err := hdfs.New(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize HDFS client: %v", err)
+	}
+	logger.Printf("Storing documents in HDFS at %s", address)
+	return &HDFSStore{cli: client, logger: logger, tracer: tracer}, nil
+}
+
+// Put writes the file next to its destination first and renames it into place
+func (s *HDFSStore) Put(ctx context.Context, p string, r io.Reader) (int64, error) {
+	_, span := s.tracer.Start(ctx, "HDFSStore.Put")
+	defer span.End()
+
+	p = cleanPath(p)
+	if err := s.cli.MkdirAll(path.Dir(p), 0755); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to create directory in HDFS: %v", err)
+	}
+	tmp := path.Join(path.Dir(p), "."+uuid.New().String()+".tmp")
+	file, err := s.cli.Create(tmp)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to create file in HDFS: %v", err)
+	}
+	size, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = s.cli.Rename(tmp, p)
+	}
+	if err != nil {
+		if removeErr := s.cli.Remove(tmp); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			s.logger.Printf("Failed to remove temporary HDFS file %s: %v", tmp, removeErr)
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to write HDFS file: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully wrote HDFS file")
+	return size, nil
+}
+
+func (s *HDFSStore) Get(ctx context.Context, p string) (io.ReadSeekCloser, error) {
+	_, span := s.tracer.Start(ctx, "HDFSStore.Get")
+	defer span.End()
+
+	file, err := s.cli.Open(cleanPath(p))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to open HDFS file: %v", err)
+	}
+	if file.Stat().IsDir() {
+		file.Close()
+		return nil, ErrNotFound
+	}
+	span.SetStatus(codes.Ok, "Successfully opened HDFS file")
+	return file, nil
+}
+
+func (s *HDFSStore) Stat(ctx context.Context, p string) (*FileInfo, error) {
+	_, span := s.tracer.Start(ctx, "HDFSStore.Stat")
+	defer span.End()
+
+	p = cleanPath(p)
+	info, err := s.cli.Stat(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to stat HDFS file: %v", err)
+	}
+	if info.IsDir() {
+		return nil, ErrNotFound
+	}
+	span.SetStatus(codes.Ok, "Successfully got HDFS file info")
+	return &FileInfo{Path: p, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (s *HDFSStore) Delete(ctx context.Context, p string) error {
+	_, span := s.tracer.Start(ctx, "HDFSStore.Delete")
+	defer span.End()
+
+	if err := s.cli.Remove(cleanPath(p)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to remove HDFS file: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully removed HDFS file")
+	return nil
+}
+
+func (s *HDFSStore) List(ctx context.Context, dir string) ([]FileInfo, error) {
+	_, span := s.tracer.Start(ctx, "HDFSStore.List")
+	defer span.End()
+
+	files := []FileInfo{}
+	err := s.cli.Walk(cleanPath(dir), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !isTempFile(p) {
+			files = append(files, FileInfo{Path: filepath.ToSlash(p), Size: info.Size(), ModTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to list HDFS files: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully listed HDFS files")
+	return files, nil
+}
+
+func (s *HDFSStore) Close() error {
+	return s.cli.Close()
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LocalStore keeps the files in a directory of the local filesystem
+type LocalStore struct {
+	root   string
+	logger *log.Logger
+	tracer trace.Tracer
+}
+
+func NewLocalStore(root string, logger *log.Logger, tracer trace.Tracer) (*LocalStore, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document directory: %v", err)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create document directory: %v", err)
+	}
+	logger.Printf("Storing documents in %s", root)
+	return &LocalStore{root: root, logger: logger, tracer: tracer}, nil
+}
+
+func (s *LocalStore) file(p string) string {
+	return filepath.Join(s.root, filepath.FromSlash(cleanPath(p)))
+}
+
+// Put writes the file next to its destination first and renames it into place
+func (s *LocalStore) Put(ctx context.Context, p string, r io.Reader) (int64, error) {
+	_, span := s.tracer.Start(ctx, "LocalStore.Put")
+	defer span.End()
+
+	name := s.file(p)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to create directory: %v", err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(name), ".*.tmp")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to create file: %v", err)
+	}
+	size, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), name)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to write file: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully wrote file")
+	return size, nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, p string) (io.ReadSeekCloser, error) {
+	_, span := s.tracer.Start(ctx, "LocalStore.Get")
+	defer span.End()
+
+	file, err := os.Open(s.file(p))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	if info, err := file.Stat(); err != nil || info.IsDir() {
+		file.Close()
+		return nil, ErrNotFound
+	}
+	span.SetStatus(codes.Ok, "Successfully opened file")
+	return file, nil
+}
+
+func (s *LocalStore) Stat(ctx context.Context, p string) (*FileInfo, error) {
+	_, span := s.tracer.Start(ctx, "LocalStore.Stat")
+	defer span.End()
+
+	info, err := os.Stat(s.file(p))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to stat file: %v", err)
+	}
+	if info.IsDir() {
+		return nil, ErrNotFound
+	}
+	span.SetStatus(codes.Ok, "Successfully got file info")
+	return &FileInfo{Path: cleanPath(p), Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, p string) error {
+	_, span := s.tracer.Start(ctx, "LocalStore.Delete")
+	defer span.End()
+
+	if err := os.Remove(s.file(p)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to remove file: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully removed file")
+	return nil
+}
+
+func (s *LocalStore) List(ctx context.Context, dir string) ([]FileInfo, error) {
+	_, span := s.tracer.Start(ctx, "LocalStore.List")
+	defer span.End()
+
+	files := []FileInfo{}
+	err := filepath.WalkDir(s.file(dir), func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || isTempFile(name) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.root, name)
+		if err != nil {
+			return err
+		}
+		files = append(files, FileInfo{Path: cleanPath(filepath.ToSlash(rel)), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to list files: %v", err)
+	}
+	span.SetStatus(codes.Ok, "Successfully listed files")
+	return files, nil
+}
+
+func (s *LocalStore) Close() error {
+	return nil
+}
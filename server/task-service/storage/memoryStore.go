@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MemoryStore keeps the files in memory, for development and tests. Everything is lost when
+// the service stops.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	files  map[string]memoryFile
+	logger *log.Logger
+	tracer trace.Tracer
+}
+
+type memoryFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+func NewMemoryStore(logger *log.Logger, tracer trace.Tracer) *MemoryStore {
+	logger.Println("Storing documents in memory, they are lost on restart")
+	return &MemoryStore{files: map[string]memoryFile{}, logger: logger, tracer: tracer}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, p string, r io.Reader) (int64, error) {
+	_, span := s.tracer.Start(ctx, "MemoryStore.Put")
+	defer span.End()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to read file: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[cleanPath(p)] = memoryFile{data: data, modTime: time.Now()}
+	return int64(len(data)), nil
+}
+
+// Get returns a reader over the file as it is now, a later Put does not change it
+func (s *MemoryStore) Get(ctx context.Context, p string) (io.ReadSeekCloser, error) {
+	_, span := s.tracer.Start(ctx, "MemoryStore.Get")
+	defer span.End()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	file, ok := s.files[cleanPath(p)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return nopCloser{bytes.NewReader(file.data)}, nil
+}
+
+func (s *MemoryStore) Stat(ctx context.Context, p string) (*FileInfo, error) {
+	_, span := s.tracer.Start(ctx, "MemoryStore.Stat")
+	defer span.End()
+
+	p = cleanPath(p)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	file, ok := s.files[p]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &FileInfo{Path: p, Size: int64(len(file.data)), ModTime: file.modTime}, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, p string) error {
+	_, span := s.tracer.Start(ctx, "MemoryStore.Delete")
+	defer span.End()
+
+	p = cleanPath(p)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[p]; !ok {
+		return ErrNotFound
+	}
+	delete(s.files, p)
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, dir string) ([]FileInfo, error) {
+	_, span := s.tracer.Start(ctx, "MemoryStore.List")
+	defer span.End()
+
+	dir = cleanPath(dir)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	files := []FileInfo{}
+	for p, file := range s.files {
+		if isUnder(p, dir) {
+			files = append(files, FileInfo{Path: p, Size: int64(len(file.data)), ModTime: file.modTime})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -332,46 +332,133 @@ func (t *WorkflowHandler) HandleProjectDeleted(projectID string) {
 	span.SetStatus(codes.Ok, "Successfully deleted all workflows")
 }
 
-func (t *WorkflowHandler) DeletedWorkflows(projectID string) {
-	_, span := t.tracer.Start(context.Background(), "WorkflowHandler.HandleProjectDeleted")
+func (w *WorkflowHandler) RollbackWorkflows(ctx context.Context, projectID string) {
+	_, span := w.tracer.Start(context.Background(), "WorkflowHandler.HandleProjectDeleted")
 	defer span.End()
 
-	err := t.repo.DeleteAllWorkflowByProjectId(projectID)
+	err := w.repo.UpdateAllWorkflowByProjectId(projectID, false)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		t.logger.Printf("Failed to delete workflows for project %s: %v", projectID, err)
+		w.logger.Printf("Failed to delete workflows for project %s: %v", projectID, err)
 
-		_ = t.nc.Publish("WorkflowsDeletionFailed", []byte(projectID))
+		_ = w.nc.Publish("WorkflowsDeletionFailed", []byte(projectID))
 	}
-	t.logger.Printf("Successfully deleted all tasks for project %s", projectID)
+	w.logger.Printf("Successfully deleted all tasks for project %s", projectID)
 
-	err = t.nc.Publish("WorkflowsDeleted", []byte(projectID))
+	err = w.nc.Publish("WorkflowsDeleted", []byte(projectID))
 	if err != nil {
-		t.logger.Printf("Failed to publish TasksDeleted event for project %s: %v", projectID, err)
+		w.logger.Printf("Failed to publish TasksDeleted event for project %s: %v", projectID, err)
 	}
 
 	span.SetStatus(codes.Ok, "Successfully deleted all workflows")
 }
 
-func (w *WorkflowHandler) RollbackWorkflows(ctx context.Context, projectID string) {
-	_, span := w.tracer.Start(context.Background(), "WorkflowHandler.HandleProjectDeleted")
+// HandleTaskDeletionStarted removes the node of a task that task-service is deleting and
+// reports the outcome back with the same message
+func (w *WorkflowHandler) HandleTaskDeletionStarted(ctx context.Context, data []byte) {
+	ctx, span := w.tracer.Start(ctx, "WorkflowHandler.HandleTaskDeletionStarted")
 	defer span.End()
 
-	err := w.repo.UpdateAllWorkflowByProjectId(projectID, false)
+	var message struct {
+		TaskID    string `json:"taskId"`
+		ProjectID string `json:"projectId"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		w.logger.Printf("Error unmarshalling TaskDeletionStarted message: %v", err)
+		return
+	}
+
+	deleted, err := w.repo.DeleteTaskNode(ctx, message.TaskID)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		w.logger.Printf("Failed to delete workflows for project %s: %v", projectID, err)
+		w.logger.Printf("Failed to delete workflow node of task %s: %v", message.TaskID, err)
+		if err := w.nc.Publish("TaskWorkflowDeletionFailed", data); err != nil {
+			w.logger.Printf("Failed to publish TaskWorkflowDeletionFailed event for task %s: %v", message.TaskID, err)
+		}
+		return
+	}
+	w.logger.Printf("Deleted %d workflow nodes of task %s", deleted, message.TaskID)
 
-		_ = w.nc.Publish("WorkflowsDeletionFailed", []byte(projectID))
+	if err := w.nc.Publish("TaskWorkflowDeleted", data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		w.logger.Printf("Failed to publish TaskWorkflowDeleted event for task %s: %v", message.TaskID, err)
+		return
 	}
-	w.logger.Printf("Successfully deleted all tasks for project %s", projectID)
+	span.SetStatus(codes.Ok, "Successfully deleted workflow node")
+}
 
-	err = w.nc.Publish("WorkflowsDeleted", []byte(projectID))
-	if err != nil {
-		w.logger.Printf("Failed to publish TasksDeleted event for project %s: %v", projectID, err)
+// HandleTaskRestoreStarted recreates the node of a task that task-service is restoring and
+// reports the outcome back with the same message
+func (w *WorkflowHandler) HandleTaskRestoreStarted(ctx context.Context, data []byte) {
+	ctx, span := w.tracer.Start(ctx, "WorkflowHandler.HandleTaskRestoreStarted")
+	defer span.End()
+
+	var message struct {
+		TaskID       string          `json:"taskId"`
+		ProjectID    string          `json:"projectId"`
+		Task         model.TaskGraph `json:"task"`
+		DependencyOf []string        `json:"dependencyOf"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		w.logger.Printf("Error unmarshalling TaskRestoreStarted message: %v", err)
+		return
 	}
 
-	span.SetStatus(codes.Ok, "Successfully deleted all workflows")
+	if err := w.repo.RestoreTaskNode(ctx, &message.Task, message.DependencyOf); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		w.logger.Printf("Failed to restore workflow node of task %s: %v", message.TaskID, err)
+		if err := w.nc.Publish("TaskWorkflowRestoreFailed", data); err != nil {
+			w.logger.Printf("Failed to publish TaskWorkflowRestoreFailed event for task %s: %v", message.TaskID, err)
+		}
+		return
+	}
+
+	if err := w.nc.Publish("TaskWorkflowRestored", data); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		w.logger.Printf("Failed to publish TaskWorkflowRestored event for task %s: %v", message.TaskID, err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully restored workflow node")
+}
+
+// HandleProjectRestored makes the workflow of a project restored from the trash visible again
+func (w *WorkflowHandler) HandleProjectRestored(projectID string) {
+	_, span := w.tracer.Start(context.Background(), "WorkflowHandler.HandleProjectRestored")
+	defer span.End()
+
+	if err := w.repo.UpdateAllWorkflowByProjectId(projectID, false); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		w.logger.Printf("Failed to restore workflows for project %s: %v", projectID, err)
+		return
+	}
+	span.SetStatus(codes.Ok, "Successfully restored all workflows")
+}
+
+// HandleProjectPurged removes the workflow of a project that left the trash for good
+func (w *WorkflowHandler) HandleProjectPurged(projectID string) {
+	_, span := w.tracer.Start(context.Background(), "WorkflowHandler.HandleProjectPurged")
+	defer span.End()
+
+	if err := w.repo.DeleteAllWorkflowByProjectId(projectID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		w.logger.Printf("Failed to purge workflows for project %s: %v", projectID, err)
+		return
+	}
+	// project-service removes the project only after this answer and asks again otherwise
+	if err := w.nc.Publish("WorkflowsPurged", []byte(projectID)); err != nil {
+		span.RecordError(err)
+		w.logger.Printf("Failed to publish WorkflowsPurged event for project %s: %v", projectID, err)
+	}
+	span.SetStatus(codes.Ok, "Successfully purged all workflows")
 }
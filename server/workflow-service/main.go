@@ -76,12 +76,12 @@ func main() {
 	}
 	defer sub.Unsubscribe()
 
-	sub2, err := nc.Subscribe("WorkflowsDeletionComplete", func(msg *nats.Msg) {
+	sub2, err := nc.QueueSubscribe("ProjectPurged", "workflow-queue", func(msg *nats.Msg) {
 		projectID := string(msg.Data)
-		workflowHandler.DeletedWorkflows(projectID)
+		workflowHandler.HandleProjectPurged(projectID)
 	})
 	if err != nil {
-		logger.Fatalf("Failed to subscribe to ProjectDeleted: %v", err)
+		logger.Fatalf("Failed to subscribe to ProjectPurged: %v", err)
 	}
 	defer sub2.Unsubscribe()
 	sub3, err := nc.Subscribe("TaskDeletionFailed", func(msg *nats.Msg) {
@@ -95,6 +95,35 @@ func main() {
 	}
 	defer sub3.Unsubscribe()
 
+	sub4, err := nc.QueueSubscribe("TaskDeletionStarted", "workflow-queue", func(msg *nats.Msg) {
+		timeoutContext, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		workflowHandler.HandleTaskDeletionStarted(timeoutContext, msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to TaskDeletionStarted: %v", err)
+	}
+	defer sub4.Unsubscribe()
+
+	sub5, err := nc.QueueSubscribe("TaskRestoreStarted", "workflow-queue", func(msg *nats.Msg) {
+		timeoutContext, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		workflowHandler.HandleTaskRestoreStarted(timeoutContext, msg.Data)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to TaskRestoreStarted: %v", err)
+	}
+	defer sub5.Unsubscribe()
+
+	sub6, err := nc.QueueSubscribe("ProjectRestored", "workflow-queue", func(msg *nats.Msg) {
+		projectID := string(msg.Data)
+		workflowHandler.HandleProjectRestored(projectID)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to subscribe to ProjectRestored: %v", err)
+	}
+	defer sub6.Unsubscribe()
+
 	defer func() {
 		if err := nc.Drain(); err != nil {
 			logger.Printf("Error draining NATS connection: %v", err)
@@ -488,3 +488,86 @@ func (w *WorkflowRepo) DeleteAllWorkflowByProjectId(projectID string) error {
 
 	return nil
 }
+
+// DeleteTaskNode removes a task node together with its DEPENDS_ON edges. A task without a
+// node is not an error, it was never added to the workflow.
+func (w *WorkflowRepo) DeleteTaskNode(ctx context.Context, taskID string) (int64, error) {
+	ctx, span := w.tracer.Start(ctx, "WorkflowRepo.DeleteTaskNode")
+	defer span.End()
+
+	query := `
+		MATCH (t:Task {id: $taskID})
+		DETACH DELETE t
+		RETURN COUNT(t) AS deletedCount
+	`
+
+	session := w.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := tx.Run(ctx, query, map[string]any{"taskID": taskID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute delete query: %w", err)
+		}
+		if res.Next(ctx) {
+			return res.Record().Values[0], nil
+		}
+		return int64(0), res.Err()
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("failed to delete workflow node of task %s: %w", taskID, err)
+	}
+	deletedCount, _ := result.(int64)
+	span.SetStatus(codes.Ok, "Successfully deleted workflow node")
+	return deletedCount, nil
+}
+
+// RestoreTaskNode recreates the node of a restored task and links it again to the tasks it
+// depended on and the tasks that depended on it, as long as their nodes still exist
+func (w *WorkflowRepo) RestoreTaskNode(ctx context.Context, task *model.TaskGraph, dependencyOf []string) error {
+	ctx, span := w.tracer.Start(ctx, "WorkflowRepo.RestoreTaskNode")
+	defer span.End()
+
+	query := `
+		MERGE (t:Task {id: $id})
+		SET t.projectId = $projectId, t.name = $name, t.description = $description, t.status = $status,
+		    t.created_at = $created_at, t.updated_at = $updated_at, t.user_ids = $user_ids,
+		    t.dependencies = $dependencies, t.blocked = $blocked, t.pending_deletion = false
+		WITH t
+		OPTIONAL MATCH (d:Task) WHERE d.id IN $dependencies
+		FOREACH (_ IN CASE WHEN d IS NULL THEN [] ELSE [1] END | MERGE (t)-[:DEPENDS_ON]->(d))
+		WITH DISTINCT t
+		OPTIONAL MATCH (b:Task) WHERE b.id IN $dependencyOf
+		FOREACH (_ IN CASE WHEN b IS NULL THEN [] ELSE [1] END | MERGE (b)-[:DEPENDS_ON]->(t))
+	`
+
+	session := w.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := tx.Run(ctx, query, map[string]any{
+			"id":           task.ID,
+			"projectId":    task.ProjectID,
+			"name":         task.Name,
+			"description":  task.Description,
+			"status":       task.Status,
+			"created_at":   task.CreatedAt,
+			"updated_at":   task.UpdatedAt,
+			"user_ids":     task.UserIds,
+			"dependencies": task.Dependencies,
+			"blocked":      task.Blocked,
+			"dependencyOf": dependencyOf,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute restore query: %w", err)
+		}
+		return res.Consume(ctx)
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to restore workflow node of task %s: %w", task.ID, err)
+	}
+	span.SetStatus(codes.Ok, "Successfully restored workflow node")
+	return nil
+}